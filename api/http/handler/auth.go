@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/binding"
+	"github.com/lomifile/api/pkg/token"
+)
+
+// AuthHandler Token endpoints
+type AuthHandler struct {
+	tm *token.Manager
+}
+
+// RefreshRequest Body of refresh endpoint
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// NewAuthHandler Creates new auth handler
+func NewAuthHandler(tm *token.Manager) *AuthHandler {
+	return &AuthHandler{tm: tm}
+}
+
+// Refresh exchanges refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	pair, err := h.tm.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, token.ErrInvalidToken) || errors.Is(err, token.ErrWrongType) {
+			return apperror.Unauthorized("Invalid or expired token").WithCode("invalid_token")
+		}
+		return apperror.Internal(fmt.Errorf("token refresh: %w", err))
+	}
+
+	return success(c, fiber.StatusOK, pair)
+}
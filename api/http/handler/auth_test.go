@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/token"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+func TestAuthHandler_Refresh(t *testing.T) {
+	l := logger.New(logger.Config{Debug: true})
+	tm, err := token.New("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+	h := NewAuthHandler(tm)
+
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(l)})
+	app.Post("/refresh", h.Refresh)
+
+	pair, _ := tm.IssuePair("user-1")
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"valid refresh token", `{"refresh_token":"` + pair.RefreshToken + `"}`, 200},
+		{"access token", `{"refresh_token":"` + pair.AccessToken + `"}`, 401},
+		{"invalid token", `{"refresh_token":"nope"}`, 401},
+		{"missing token", `{}`, 422},
+		{"malformed body", `{`, 400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/refresh", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != 200 {
+				return
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			var got utils.SuccessResponseMap[token.Pair]
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if _, err := tm.Verify(got.Data.AccessToken, token.Access); err != nil {
+				t.Errorf("returned access token invalid: %v", err)
+			}
+		})
+	}
+
+	_ = l.Sync()
+}
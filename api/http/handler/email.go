@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"html/template"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/email"
+)
+
+// _unsubscribeForm confirms unsubscribe with the same POST mail clients send, RFC 8058
+var _unsubscribeForm = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body>
+<form method="post" action="?token={{.Token}}">
+<p>Stop sending emails to {{.Address}}?</p>
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+</body>
+</html>`))
+
+// EmailHandler One-click unsubscribe endpoint of List-Unsubscribe links
+type EmailHandler struct {
+	u *email.Unsubscriber
+}
+
+// NewEmailHandler Creates new email handler
+func NewEmailHandler(u *email.Unsubscriber) *EmailHandler {
+	return &EmailHandler{u: u}
+}
+
+// UnsubscribeForm shows confirmation form, GET doesn't unsubscribe because link scanners
+// open links in emails
+func (h *EmailHandler) UnsubscribeForm(c *fiber.Ctx) error {
+	token := c.Query("token")
+	addr, err := h.u.Address(token)
+	if err != nil {
+		return unsubscribeError(err)
+	}
+
+	c.Type("html", "utf-8")
+	return _unsubscribeForm.Execute(c, map[string]string{"Token": token, "Address": addr})
+}
+
+// Unsubscribe suppresses address of token, mail clients post List-Unsubscribe=One-Click
+func (h *EmailHandler) Unsubscribe(c *fiber.Ctx) error {
+	if _, err := h.u.Unsubscribe(c.UserContext(), c.Query("token")); err != nil {
+		return unsubscribeError(err)
+	}
+
+	return success(c, fiber.StatusOK, MessageResponse{Message: "Unsubscribed"})
+}
+
+// SuppressionHandler Admin endpoint lifting email suppressions
+type SuppressionHandler struct {
+	list email.Suppressions
+}
+
+// NewSuppressionHandler Creates new suppression handler
+func NewSuppressionHandler(list email.Suppressions) *SuppressionHandler {
+	return &SuppressionHandler{list: list}
+}
+
+// Remove lifts suppression of :address, e.g. once bounced mailbox works again
+func (h *SuppressionHandler) Remove(c *fiber.Ctx) error {
+	addr, err := url.PathUnescape(c.Params("address"))
+	if err != nil || addr == "" {
+		return apperror.BadRequest("Invalid address").WithCode("invalid_address")
+	}
+	if err := h.list.Remove(c.UserContext(), addr); err != nil {
+		return apperror.Internal(err)
+	}
+
+	return success(c, fiber.StatusOK, MessageResponse{Message: "Suppression removed"})
+}
+
+func unsubscribeError(err error) error {
+	if errors.Is(err, email.ErrInvalidToken) {
+		return apperror.BadRequest("Invalid unsubscribe link").WithCode("invalid_token")
+	}
+	return apperror.Internal(err)
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/email"
+	"github.com/lomifile/api/pkg/logger"
+)
+
+// suppressions records suppressed addresses
+type suppressions struct {
+	email.Suppressions
+	suppressed map[string]email.Reason
+}
+
+func (s *suppressions) Suppress(_ context.Context, addr string, r email.Reason, _ string) error {
+	s.suppressed[addr] = r
+	return nil
+}
+
+func (s *suppressions) Remove(_ context.Context, addr string) error {
+	delete(s.suppressed, addr)
+	return nil
+}
+
+func TestSuppressionHandler_Remove(t *testing.T) {
+	list := &suppressions{suppressed: map[string]email.Reason{
+		"ana@example.com": email.ReasonBounce,
+	}}
+	h := NewSuppressionHandler(list)
+
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(logger.New(logger.Config{}))})
+	app.Delete("/suppressions/:address", h.Remove)
+
+	req := httptest.NewRequest("DELETE", "/suppressions/ana%40example.com", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("Status = %d, want 200", resp.StatusCode)
+	}
+	if _, ok := list.suppressed["ana@example.com"]; ok {
+		t.Error("suppression should be removed")
+	}
+}
+
+func TestEmailHandler_Unsubscribe(t *testing.T) {
+	list := &suppressions{suppressed: map[string]email.Reason{}}
+	u := email.NewUnsubscriber("secret", "https://api.example.com/email/unsubscribe", list)
+	h := NewEmailHandler(u)
+
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(logger.New(logger.Config{}))})
+	app.Get("/email/unsubscribe", h.UnsubscribeForm)
+	app.Post("/email/unsubscribe", h.Unsubscribe)
+
+	token := u.Token("ana@example.com")
+	do := func(method, token string) (int, string) {
+		// mail clients post RFC 8058 body
+		form := strings.NewReader("List-Unsubscribe=One-Click")
+		req := httptest.NewRequest(method, "/email/unsubscribe?token="+token, form)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	status, body := do("GET", token)
+	if status != 200 || !strings.Contains(body, "ana@example.com") ||
+		!strings.Contains(body, `action="?token=`+token+`"`) {
+		t.Errorf("GET = %d %s", status, body)
+	}
+	if len(list.suppressed) != 0 {
+		t.Error("GET shouldn't unsubscribe")
+	}
+
+	if status, _ := do("POST", token); status != 200 {
+		t.Errorf("POST = %d, want 200", status)
+	}
+	if list.suppressed["ana@example.com"] != email.ReasonUnsubscribe {
+		t.Errorf("suppressed = %v", list.suppressed)
+	}
+
+	for _, method := range []string{"GET", "POST"} {
+		if status, body := do(method, "forged"); status != 400 ||
+			!strings.Contains(body, "invalid_token") {
+			t.Errorf("%s with forged token = %d %s", method, status, body)
+		}
+	}
+}
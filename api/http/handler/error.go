@@ -1,14 +1,32 @@
 package handler
 
 import (
-	"time"
+	"errors"
+	"net/http"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
 	"github.com/lomifile/api/pkg/logger"
-	"github.com/lomifile/api/pkg/utils"
+	"github.com/lomifile/api/pkg/tracing"
 	"go.uber.org/zap"
 )
 
+// MIMEProblemJSON RFC 9457 media type
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem RFC 9457 problem details with stable code and field errors as extensions
+type Problem struct {
+	Type      string                `json:"type"`
+	Title     string                `json:"title"`
+	Status    int                   `json:"status"`
+	Detail    string                `json:"detail,omitempty"`
+	Instance  string                `json:"instance,omitempty"`
+	Code      string                `json:"code"`
+	RequestID string                `json:"request_id,omitempty"`
+	Errors    []apperror.FieldError `json:"errors,omitempty"`
+}
+
 type ErrorResponder struct {
 	l *logger.Logger
 }
@@ -17,6 +35,39 @@ func NewErrorResponder(l *logger.Logger) *ErrorResponder {
 	return &ErrorResponder{l: l}
 }
 
+// NewErrorHandler returns fiber.ErrorHandler rendering every returned error as problem+json
+func NewErrorHandler(l *logger.Logger) fiber.ErrorHandler {
+	return NewErrorResponder(l.Named("error")).Problem
+}
+
+// Problem writes err as problem+json, apperror.Error and fiber.Error keep their status,
+// anything else is internal error. Server errors are logged with their cause.
+func (r *ErrorResponder) Problem(c *fiber.Ctx, err error) error {
+	p := Problem{Type: "about:blank", Instance: c.OriginalURL(), RequestID: requestID(c)}
+
+	var fe *fiber.Error
+	if ae, ok := apperror.As(err); ok {
+		p.Status, p.Code, p.Detail, p.Errors = ae.Status(), ae.Code, ae.Message, ae.Fields
+	} else if errors.As(err, &fe) {
+		p.Status, p.Code, p.Detail = fe.Code, statusCode(fe.Code), fe.Message
+	} else {
+		p.Status, p.Code = fiber.StatusInternalServerError, apperror.CodeInternal
+	}
+	p.Title = http.StatusText(p.Status)
+
+	if p.Status >= fiber.StatusInternalServerError {
+		r.logger(c).Error(
+			"request_failed",
+			zap.Int("status", p.Status),
+			zap.String("code", p.Code),
+			zap.Error(err),
+		)
+	}
+
+	return r.write(c, p)
+}
+
+// Error writes problem+json with status and detail, logs under logKey when it's not empty
 func (r *ErrorResponder) Error(
 	c *fiber.Ctx,
 	status int,
@@ -24,23 +75,64 @@ func (r *ErrorResponder) Error(
 	logKey string,
 	fields ...zap.Field,
 ) error {
-	requestID := c.Get(fiber.HeaderXRequestID)
-	now := time.Now().String()
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    message,
+		Instance:  c.OriginalURL(),
+		Code:      statusCode(status),
+		RequestID: requestID(c),
+	}
 
 	if logKey != "" {
-		r.l.Error(
-			logKey,
-			append([]zap.Field{
-				zap.String("request_id", requestID),
-				zap.Int("status", status),
-			}, fields...)...,
-		)
+		r.logger(c).Error(logKey, append([]zap.Field{zap.Int("status", status)}, fields...)...)
 	}
 
-	return c.Status(status).JSON(utils.ErrorResponseMap{
-		RequestID: requestID,
-		Status:    status,
-		Error:     message,
-		TS:        now,
-	})
+	return r.write(c, p)
+}
+
+// logger returns request logger under r's name, requests that skipped LoggerMiddleware get
+// request id and trace id added here
+func (r *ErrorResponder) logger(c *fiber.Ctx) *logger.Logger {
+	if _, ok := c.Locals(logger.LocalsKey).(*logger.Logger); ok {
+		return r.l.Ctx(c.UserContext())
+	}
+
+	fields := append(
+		[]zap.Field{zap.String("request_id", requestID(c))},
+		tracing.Fields(c.UserContext())...,
+	)
+	return r.l.With(fields...)
+}
+
+func (r *ErrorResponder) write(c *fiber.Ctx, p Problem) error {
+	return c.Status(p.Status).JSON(p, MIMEProblemJSON)
+}
+
+// requestID prefers id generated by requestid middleware over client supplied header
+func requestID(c *fiber.Ctx) string {
+	if id := c.GetRespHeader(fiber.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Get(fiber.HeaderXRequestID)
+}
+
+// statusCode derives stable code from status, e.g. 405 is method_not_allowed
+func statusCode(status int) string {
+	switch status {
+	case fiber.StatusUnprocessableEntity:
+		return apperror.CodeValidation
+	case fiber.StatusTooManyRequests:
+		return apperror.CodeRateLimited
+	case fiber.StatusInternalServerError:
+		return apperror.CodeInternal
+	}
+
+	text := http.StatusText(status)
+	if text == "" {
+		return apperror.CodeInternal
+	}
+	text = strings.ReplaceAll(strings.ToLower(text), "-", "_")
+	return strings.ReplaceAll(text, " ", "_")
 }
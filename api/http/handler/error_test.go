@@ -2,14 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/lomifile/api/pkg/apperror"
 	"github.com/lomifile/api/pkg/logger"
-	"github.com/lomifile/api/pkg/utils"
 	"go.uber.org/zap"
 )
 
@@ -54,7 +55,7 @@ func TestErrorResponder_Error(t *testing.T) {
 	}
 
 	body, _ := io.ReadAll(resp.Body)
-	var errorResp utils.ErrorResponseMap
+	var errorResp Problem
 	if err := json.Unmarshal(body, &errorResp); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
@@ -62,14 +63,14 @@ func TestErrorResponder_Error(t *testing.T) {
 	if errorResp.Status != 400 {
 		t.Errorf("Response.Status = %d, want 400", errorResp.Status)
 	}
-	if errorResp.Error != "Bad request" {
-		t.Errorf("Response.Error = %v, want 'Bad request'", errorResp.Error)
+	if errorResp.Detail != "Bad request" {
+		t.Errorf("Response.Detail = %v, want 'Bad request'", errorResp.Detail)
 	}
 	if errorResp.RequestID != customReqID {
 		t.Errorf("Response.RequestID = %v, want %v", errorResp.RequestID, customReqID)
 	}
-	if errorResp.TS == "" {
-		t.Error("Response.TS should not be empty")
+	if errorResp.Title != "Bad Request" || errorResp.Code != "bad_request" {
+		t.Errorf("Response.Title, Code = %v, %v", errorResp.Title, errorResp.Code)
 	}
 
 	_ = l.Sync()
@@ -113,13 +114,13 @@ func TestErrorResponder_Error_DifferentStatusCodes(t *testing.T) {
 			}
 
 			body, _ := io.ReadAll(resp.Body)
-			var errorResp utils.ErrorResponseMap
+			var errorResp Problem
 			if err := json.Unmarshal(body, &errorResp); err != nil {
 				t.Fatalf("Failed to unmarshal response: %v", err)
 			}
 
-			if errorResp.Error != tt.message {
-				t.Errorf("Response.Error = %v, want %v", errorResp.Error, tt.message)
+			if errorResp.Detail != tt.message {
+				t.Errorf("Response.Detail = %v, want %v", errorResp.Detail, tt.message)
 			}
 		})
 	}
@@ -183,13 +184,13 @@ func TestErrorResponder_Error_EmptyLogKey(t *testing.T) {
 	}
 
 	body, _ := io.ReadAll(resp.Body)
-	var errorResp utils.ErrorResponseMap
+	var errorResp Problem
 	if err := json.Unmarshal(body, &errorResp); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
 
-	if errorResp.Error != "Error without logging" {
-		t.Errorf("Response.Error = %v, want 'Error without logging'", errorResp.Error)
+	if errorResp.Detail != "Error without logging" {
+		t.Errorf("Response.Detail = %v, want 'Error without logging'", errorResp.Detail)
 	}
 
 	_ = l.Sync()
@@ -217,7 +218,7 @@ func TestErrorResponder_Error_RequestIDPropagation(t *testing.T) {
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
-	var errorResp utils.ErrorResponseMap
+	var errorResp Problem
 	if err := json.Unmarshal(body, &errorResp); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
@@ -248,9 +249,128 @@ func TestErrorResponder_Error_ContentType(t *testing.T) {
 	defer resp.Body.Close()
 
 	contentType := resp.Header.Get("Content-Type")
-	if contentType != "application/json" {
-		t.Errorf("Content-Type = %v, want application/json", contentType)
+	if contentType != MIMEProblemJSON {
+		t.Errorf("Content-Type = %v, want %v", contentType, MIMEProblemJSON)
 	}
 
 	_ = l.Sync()
 }
+
+func TestNewErrorHandler(t *testing.T) {
+	l := logger.New(logger.Config{Debug: true})
+
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(l)})
+	app.Use(requestid.New())
+	app.Post("/users", func(c *fiber.Ctx) error {
+		return apperror.Validation("Invalid user", apperror.FieldError{
+			Field:   "email",
+			Code:    "invalid_email",
+			Message: "Email address is invalid",
+		})
+	})
+	app.Get("/conflict", func(c *fiber.Ctx) error {
+		return apperror.Conflict("Email already registered").WithCode("email_taken")
+	})
+	app.Get("/fiber", func(c *fiber.Ctx) error {
+		return fiber.ErrMethodNotAllowed
+	})
+	app.Get("/internal", func(c *fiber.Ctx) error {
+		return errors.New("connection refused")
+	})
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"POST", "/users", 422, "validation_failed", "Invalid user"},
+		{"GET", "/conflict", 409, "email_taken", "Email already registered"},
+		{"GET", "/fiber", 405, "method_not_allowed", "Method Not Allowed"},
+		{"GET", "/internal", 500, "internal", ""},
+		{"GET", "/missing", 404, "not_found", "Cannot GET /missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			if err != nil {
+				t.Fatalf("app.Test failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != MIMEProblemJSON {
+				t.Errorf("Content-Type = %v, want %v", ct, MIMEProblemJSON)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			var p Problem
+			if err := json.Unmarshal(body, &p); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if p.Status != tt.wantStatus || p.Code != tt.wantCode || p.Detail != tt.wantDetail {
+				t.Errorf("Problem = %+v", p)
+			}
+			if p.Type != "about:blank" || p.Instance != tt.path || p.RequestID == "" {
+				t.Errorf("Problem type, instance, request id = %+v", p)
+			}
+		})
+	}
+
+	_ = l.Sync()
+}
+
+func TestNewErrorHandler_FieldErrors(t *testing.T) {
+	l := logger.New(logger.Config{Debug: true})
+
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(l)})
+	app.Get("/", func(c *fiber.Ctx) error {
+		return apperror.Validation(
+			"Invalid user",
+			apperror.FieldError{Field: "email", Code: "invalid_email", Message: "bad"},
+			apperror.FieldError{Field: "password", Code: "weak_password", Message: "short"},
+		)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var p Problem
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(p.Errors) != 2 {
+		t.Fatalf("Problem.Errors = %+v, want 2", p.Errors)
+	}
+	if p.Errors[1].Field != "password" || p.Errors[1].Code != "weak_password" {
+		t.Errorf("Problem.Errors[1] = %+v", p.Errors[1])
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := map[int]string{
+		400: "bad_request",
+		404: "not_found",
+		405: "method_not_allowed",
+		413: "request_entity_too_large",
+		422: "validation_failed",
+		429: "rate_limited",
+		500: "internal",
+		503: "service_unavailable",
+		999: "internal",
+	}
+
+	for status, want := range tests {
+		if got := statusCode(status); got != want {
+			t.Errorf("statusCode(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
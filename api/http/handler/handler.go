@@ -1,2 +1,18 @@
 // Package handler contains HTTP endpoint handlers
 package handler
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+func success[T any](c *fiber.Ctx, status int, data T) error {
+	return c.Status(status).JSON(utils.SuccessResponseMap[T]{
+		RequestID: c.Get(fiber.HeaderXRequestID),
+		Status:    status,
+		Data:      data,
+		TS:        time.Now().String(),
+	})
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/health"
+)
+
+// HealthHandler Health, readiness and liveness probes
+type HealthHandler struct {
+	r *health.Registry
+}
+
+// NewHealthHandler Creates new health handler
+func NewHealthHandler(r *health.Registry) *HealthHandler {
+	return &HealthHandler{r: r}
+}
+
+// Healthz runs every registered check
+func (h *HealthHandler) Healthz(c *fiber.Ctx) error {
+	return report(c, h.r.Health(c.UserContext()))
+}
+
+// Readyz runs readiness checks, fails while server is draining
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	return report(c, h.r.Ready(c.UserContext()))
+}
+
+// Livez runs liveness checks
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	return report(c, h.r.Live(c.UserContext()))
+}
+
+func report(c *fiber.Ctx, r health.Report) error {
+	status := fiber.StatusOK
+	if !r.Healthy() {
+		status = fiber.StatusServiceUnavailable
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(r)
+}
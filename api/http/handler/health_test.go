@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/health"
+)
+
+func TestHealthHandler(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("refused") }
+
+	r := health.New()
+	r.Register("db", up)
+	r.Register("smtp", down, health.Optional())
+	r.Register("loop", up, health.WithScope(health.Liveness))
+
+	h := NewHealthHandler(r)
+	app := fiber.New()
+	app.Get("/healthz", h.Healthz)
+	app.Get("/readyz", h.Readyz)
+	app.Get("/livez", h.Livez)
+
+	get := func(path string) (int, health.Report) {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		var report health.Report
+		if err := json.Unmarshal(body, &report); err != nil {
+			t.Fatalf("Failed to unmarshal report: %v", err)
+		}
+		return resp.StatusCode, report
+	}
+
+	status, report := get("/healthz")
+	if status != 200 || report.Status != health.StatusDegraded {
+		t.Errorf("/healthz = %d %v, want 200 degraded", status, report.Status)
+	}
+	if report.Checks["smtp"].Error != "refused" {
+		t.Errorf("smtp error = %v, want refused", report.Checks["smtp"].Error)
+	}
+
+	status, report = get("/livez")
+	if status != 200 || len(report.Checks) != 1 {
+		t.Errorf("/livez = %d with %d checks, want 200 with 1", status, len(report.Checks))
+	}
+
+	if status, _ = get("/readyz"); status != 200 {
+		t.Errorf("/readyz = %d, want 200", status)
+	}
+
+	r.Drain()
+
+	if status, _ = get("/readyz"); status != 503 {
+		t.Errorf("/readyz while draining = %d, want 503", status)
+	}
+	if status, _ = get("/livez"); status != 200 {
+		t.Errorf("/livez while draining = %d, want 200", status)
+	}
+}
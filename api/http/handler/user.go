@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/internal/domain/model"
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/internal/domain/service"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/binding"
+	"github.com/lomifile/api/pkg/token"
+)
+
+// UserHandler Account endpoints
+type UserHandler struct {
+	s *service.UserService
+}
+
+// RegisterRequest Body of register endpoint
+type RegisterRequest struct {
+	Email    string `json:"email"    validate:"required"`
+	Name     string `json:"name"`
+	Password string `json:"password" validate:"required"`
+}
+
+// LoginRequest Body of login endpoint
+type LoginRequest struct {
+	Email    string `json:"email"    validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// LoginResponse Logged in user and issued tokens
+type LoginResponse struct {
+	User   *model.User `json:"user"`
+	Tokens *token.Pair `json:"tokens"`
+}
+
+// EmailRequest Body of endpoints that only need email
+type EmailRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+// TokenRequest Body of email verification endpoint
+type TokenRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResetPasswordRequest Body of password reset endpoint
+type ResetPasswordRequest struct {
+	Token    string `json:"token"    validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// MessageResponse Generic acknowledgement
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// NewUserHandler Creates new user handler
+func NewUserHandler(s *service.UserService) *UserHandler {
+	return &UserHandler{s: s}
+}
+
+// Register creates new account
+func (h *UserHandler) Register(c *fiber.Ctx) error {
+	var req RegisterRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	u, err := h.s.Register(c.UserContext(), req.Email, req.Name, req.Password)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusCreated, u)
+}
+
+// Login checks credentials and returns token pair
+func (h *UserHandler) Login(c *fiber.Ctx) error {
+	var req LoginRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	u, pair, err := h.s.Login(c.UserContext(), req.Email, req.Password)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusOK, LoginResponse{User: u, Tokens: pair})
+}
+
+// VerifyEmail confirms email address with token from verification email
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req TokenRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.s.VerifyEmail(c.UserContext(), req.Token); err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusOK, MessageResponse{Message: "Email verified"})
+}
+
+// ResendVerification sends new verification email
+func (h *UserHandler) ResendVerification(c *fiber.Ctx) error {
+	var req EmailRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.s.ResendVerification(c.UserContext(), req.Email); err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusAccepted, MessageResponse{
+		Message: "If the account exists and is not verified, an email has been sent",
+	})
+}
+
+// ForgotPassword sends password reset email
+func (h *UserHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req EmailRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.s.RequestPasswordReset(c.UserContext(), req.Email); err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusAccepted, MessageResponse{
+		Message: "If the account exists, a password reset email has been sent",
+	})
+}
+
+// ResetPassword sets new password with token from reset email
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := binding.Body(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.s.ResetPassword(c.UserContext(), req.Token, req.Password); err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusOK, MessageResponse{Message: "Password updated"})
+}
+
+// Me returns currently authenticated user, route must be behind AuthMiddleware
+func (h *UserHandler) Me(c *fiber.Ctx) error {
+	claims, ok := c.Locals(token.ClaimsKey).(*token.Claims)
+	if !ok {
+		return apperror.Unauthorized("Unauthorized")
+	}
+
+	id, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return apperror.Unauthorized("Unauthorized")
+	}
+
+	u, err := h.s.GetByID(c.UserContext(), id)
+	if err != nil {
+		return serviceError(err)
+	}
+
+	return success(c, fiber.StatusOK, u)
+}
+
+// serviceError maps user service errors to application errors
+func serviceError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail):
+		return apperror.Validation(err.Error(), apperror.FieldError{
+			Field:   "email",
+			Code:    "invalid_email",
+			Message: "Email address is invalid",
+		})
+	case errors.Is(err, service.ErrWeakPassword):
+		return apperror.Validation(err.Error(), apperror.FieldError{
+			Field:   "password",
+			Code:    "weak_password",
+			Message: "Password must be at least 8 characters",
+		})
+	case errors.Is(err, service.ErrEmailTaken):
+		return apperror.Conflict("Email already registered").WithCode("email_taken")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return apperror.Unauthorized("Invalid email or password").
+			WithCode("invalid_credentials")
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return apperror.Forbidden("Email not verified").WithCode("email_not_verified")
+	case errors.Is(err, service.ErrInvalidToken):
+		return apperror.BadRequest("Invalid or expired token").WithCode("invalid_token")
+	case errors.Is(err, repository.ErrNotFound):
+		return apperror.NotFound("User not found").WithCode("user_not_found")
+	default:
+		return apperror.Internal(err)
+	}
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/internal/domain/model"
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/internal/domain/service"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/token"
+)
+
+// stubUserRepo keeps users in memory, tokens are accepted but never consumed
+type stubUserRepo struct {
+	users []*model.User
+}
+
+func (r *stubUserRepo) Create(_ context.Context, u *model.User) error {
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return repository.ErrDuplicate
+		}
+	}
+	u.ID = int64(len(r.users) + 1)
+	r.users = append(r.users, u)
+	return nil
+}
+
+func (r *stubUserRepo) GetByID(_ context.Context, id int64) (*model.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *stubUserRepo) GetByEmail(_ context.Context, addr string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Email == addr {
+			return u, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *stubUserRepo) MarkEmailVerified(context.Context, int64) error { return nil }
+
+func (r *stubUserRepo) UpdatePassword(context.Context, int64, string) error { return nil }
+
+func (r *stubUserRepo) CreateToken(context.Context, *model.UserToken) error { return nil }
+
+func (r *stubUserRepo) ConsumeToken(
+	context.Context,
+	model.TokenPurpose,
+	string,
+) (*model.UserToken, error) {
+	return nil, repository.ErrNotFound
+}
+
+func newUserApp(t *testing.T) (*fiber.App, *token.Manager) {
+	t.Helper()
+
+	l := logger.New(logger.Config{Debug: true})
+	tm, err := token.New("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+	s, err := service.NewUserService(&stubUserRepo{}, nil, tm, l, service.UserServiceConfig{})
+	if err != nil {
+		t.Fatalf("NewUserService() error = %v", err)
+	}
+	h := NewUserHandler(s)
+
+	app := fiber.New(fiber.Config{ErrorHandler: NewErrorHandler(l)})
+	app.Post("/register", h.Register)
+	app.Post("/login", h.Login)
+	app.Post("/verify-email", h.VerifyEmail)
+	app.Post("/forgot-password", h.ForgotPassword)
+	app.Get("/me", func(c *fiber.Ctx) error {
+		if raw := c.Get("X-Test-Token"); raw != "" {
+			claims, err := tm.Verify(raw, token.Access)
+			if err != nil {
+				return err
+			}
+			c.Locals(token.ClaimsKey, claims)
+		}
+		return h.Me(c)
+	})
+
+	return app, tm
+}
+
+func TestUserHandler_Flow(t *testing.T) {
+	app, tm := newUserApp(t)
+
+	accessFor := func(sub string) string {
+		raw, _, _ := tm.Issue(sub, token.Access)
+		return raw
+	}
+
+	const (
+		alice     = `{"email":"a@example.com","password":"password123"}`
+		aliceBad  = `{"email":"a@example.com","password":"nope-nope"}`
+		weak      = `{"email":"b@example.com","password":"x"}`
+		unknown   = `{"email":"x@example.com"}`
+		badToken  = `{"token":"abc"}`
+		malformed = `{`
+	)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		header     string
+		wantStatus int
+	}{
+		{"register", "POST", "/register", alice, "", 201},
+		{"register duplicate", "POST", "/register", alice, "", 409},
+		{"register weak password", "POST", "/register", weak, "", 422},
+		{"register bad body", "POST", "/register", malformed, "", 400},
+		{"login unverified", "POST", "/login", alice, "", 403},
+		{"login wrong password", "POST", "/login", aliceBad, "", 401},
+		{"verify bad token", "POST", "/verify-email", badToken, "", 400},
+		{"forgot unknown", "POST", "/forgot-password", unknown, "", 202},
+		{"me unauthenticated", "GET", "/me", "", "", 401},
+		{"me", "GET", "/me", "", accessFor("1"), 200},
+		{"me unknown user", "GET", "/me", "", accessFor("99"), 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.header != "" {
+				req.Header.Set("X-Test-Token", tt.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestServiceError(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{service.ErrInvalidEmail, 422, "validation_failed"},
+		{service.ErrWeakPassword, 422, "validation_failed"},
+		{service.ErrEmailTaken, 409, "email_taken"},
+		{service.ErrInvalidCredentials, 401, "invalid_credentials"},
+		{service.ErrEmailNotVerified, 403, "email_not_verified"},
+		{service.ErrInvalidToken, 400, "invalid_token"},
+		{repository.ErrNotFound, 404, "user_not_found"},
+		{errors.New("db down"), 500, "internal"},
+	}
+
+	for _, tt := range tests {
+		ae, ok := apperror.As(serviceError(tt.err))
+		if !ok {
+			t.Fatalf("serviceError(%v) is not application error", tt.err)
+		}
+		if ae.Status() != tt.wantStatus || ae.Code != tt.wantCode {
+			t.Errorf("serviceError(%v) = %d %s, want %d %s",
+				tt.err, ae.Status(), ae.Code, tt.wantStatus, tt.wantCode)
+		}
+	}
+}
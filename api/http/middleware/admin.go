@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/api/http/handler"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// AdminMiddleware allows requests carrying admin token as bearer token
+func AdminMiddleware(adminToken string, l *logger.Logger) fiber.Handler {
+	adminLog := l.Named("admin")
+	responder := handler.NewErrorResponder(adminLog)
+
+	return func(c *fiber.Ctx) error {
+		raw, err := utils.ExtractJwtTokenFromHeader(c)
+		if err != nil {
+			return responder.Problem(
+				c,
+				apperror.Unauthorized("Missing bearer token").WithCode("missing_token"),
+			)
+		}
+
+		if subtle.ConstantTimeCompare([]byte(raw), []byte(adminToken)) != 1 {
+			adminLog.Warn("admin_auth_failed", zap.String("ip", c.IP()))
+			return responder.Problem(
+				c,
+				apperror.Unauthorized("Invalid admin token").WithCode("invalid_token"),
+			)
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/logger"
+)
+
+func TestAdminMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(AdminMiddleware("admin-secret", logger.New(logger.Config{Debug: true})))
+	app.Get("/admin", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid", "Bearer admin-secret", 200},
+		{"missing", "", 401},
+		{"wrong", "Bearer admin-secreT", 401},
+		{"basic", "Basic admin-secret", 401},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/api/http/handler"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/token"
+	"github.com/lomifile/api/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// AuthMiddleware verifies bearer access token and stores claims on fiber.Ctx.Locals, request
+// logger gets user_id
+func AuthMiddleware(tm *token.Manager, l *logger.Logger) fiber.Handler {
+	authLog := l.Named("auth")
+	responder := handler.NewErrorResponder(authLog)
+
+	return func(c *fiber.Ctx) error {
+		raw, err := utils.ExtractJwtTokenFromHeader(c)
+		if err != nil {
+			return responder.Problem(
+				c,
+				apperror.Unauthorized("Missing bearer token").WithCode("missing_token"),
+			)
+		}
+
+		claims, err := tm.Verify(raw, token.Access)
+		if err != nil {
+			// bad tokens come from clients, logging them above debug lets anyone flood logs
+			authLog.Ctx(c.UserContext()).Debug("auth_failed", zap.Error(err))
+			return responder.Problem(
+				c,
+				apperror.Unauthorized("Invalid or expired token").WithCode("invalid_token"),
+			)
+		}
+
+		c.Locals(token.ClaimsKey, claims)
+		SetLogger(c, Logger(c).With(zap.String("user_id", claims.Subject)))
+
+		return c.Next()
+	}
+}
+
+// Claims returns claims stored by AuthMiddleware
+func Claims(c *fiber.Ctx) (*token.Claims, bool) {
+	claims, ok := c.Locals(token.ClaimsKey).(*token.Claims)
+	return claims, ok
+}
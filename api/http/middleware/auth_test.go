@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/token"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func newAuthApp(t *testing.T) (*fiber.App, *token.Manager, *logger.Logger) {
+	t.Helper()
+
+	l := logger.New(logger.Config{Debug: true})
+	tm, err := token.New(testSecret)
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(AuthMiddleware(tm, l))
+	app.Get("/me", func(c *fiber.Ctx) error {
+		claims, ok := Claims(c)
+		if !ok {
+			return c.SendStatus(500)
+		}
+		return c.SendString(claims.Subject)
+	})
+
+	return app, tm, l
+}
+
+func TestAuthMiddleware_ValidToken(t *testing.T) {
+	app, tm, l := newAuthApp(t)
+
+	pair, err := tm.IssuePair("user-42")
+	if err != nil {
+		t.Fatalf("IssuePair() error = %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+pair.AccessToken)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("Status = %d, want 200", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "user-42" {
+		t.Errorf("Body = %v, want user-42", string(body))
+	}
+
+	_ = l.Sync()
+}
+
+func TestAuthMiddleware_Rejects(t *testing.T) {
+	app, tm, l := newAuthApp(t)
+
+	pair, err := tm.IssuePair("user-42")
+	if err != nil {
+		t.Fatalf("IssuePair() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"missing header", ""},
+		{"malformed header", "Bearer"},
+		{"garbage token", "Bearer not-a-jwt"},
+		{"refresh token", "Bearer " + pair.RefreshToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/me", nil)
+			if tt.header != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != 401 {
+				t.Errorf("Status = %d, want 401", resp.StatusCode)
+			}
+		})
+	}
+
+	_ = l.Sync()
+}
+
+func TestAuthMiddleware_InvalidTokenLogsDebug(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	l := logger.New(logger.Config{Output: path, Level: "debug"})
+	tm, err := token.New(testSecret)
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(AuthMiddleware(tm, l))
+	app.Get("/me", func(c *fiber.Ctx) error { return c.SendStatus(200) })
+
+	req := httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer not-a-token")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+	_ = l.Sync()
+
+	raw, _ := os.ReadFile(path)
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line isn't JSON: %v\n%s", err, line)
+		}
+		if e["msg"] == "auth_failed" && e["level"] != "debug" {
+			t.Errorf("auth_failed logged at %v, want debug", e["level"])
+		}
+		if e["level"] == "error" {
+			t.Errorf("client error logged at error level: %s", line)
+		}
+	}
+	if !strings.Contains(string(raw), "auth_failed") {
+		t.Error("auth_failed not logged at debug level")
+	}
+}
+
+func TestClaims_Missing(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if _, ok := Claims(c); ok {
+			return c.SendStatus(500)
+		}
+		return c.SendStatus(200)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("Status = %d, want 200", resp.StatusCode)
+	}
+}
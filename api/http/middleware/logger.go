@@ -1,37 +1,48 @@
 package middleware
 
 import (
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/tracing"
 	"go.uber.org/zap"
 )
 
+// LoggerMiddleware logs every request and attaches request logger with request id, route,
+// user id and trace id to fiber.Ctx and c.UserContext(), must run after requestid and tracing
 func LoggerMiddleware(l *logger.Logger) fiber.Handler {
 	httpLog := l.Named("http")
 
 	return func(c *fiber.Ctx) error {
 		start := time.Now()
+		route := &lazyRoute{c: c, self: c.Route()}
+
+		fields := []zap.Field{
+			zap.String("request_id", requestID(c)),
+			zap.String("http_method", c.Method()),
+			zap.String("http_path", c.Path()),
+		}
+		fields = append(fields, tracing.Fields(c.UserContext())...)
+		SetLogger(c, l.With(fields...).WithStringer("http_route", route))
+
 		err := c.Next()
 
 		latency := time.Since(start)
+		route.resolve()
 
-		reqID := c.GetRespHeader(fiber.HeaderXRequestID)
-		if reqID == "" {
-			reqID = c.Get(fiber.HeaderXRequestID)
-		}
-
-		status := c.Response().StatusCode()
+		status := responseStatus(c, err)
 
-		fields := []zap.Field{
-			zap.String("request_id", reqID),
-			zap.String("http_method", c.Method()),
-			zap.String("http_path", c.Path()),
+		fields = append(fields,
+			zap.Stringer("http_route", route),
 			zap.Int("status", status),
 			zap.Int64("latency_ms", latency.Milliseconds()),
 			zap.String("client_ip", c.IP()),
 			zap.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+		)
+		if claims, ok := Claims(c); ok {
+			fields = append(fields, zap.String("user_id", claims.Subject))
 		}
 
 		httpLog.Info("http_request", fields...)
@@ -39,3 +50,55 @@ func LoggerMiddleware(l *logger.Logger) fiber.Handler {
 		return err
 	}
 }
+
+// Logger returns request logger attached by LoggerMiddleware
+func Logger(c *fiber.Ctx) *logger.Logger {
+	if l, ok := c.Locals(logger.LocalsKey).(*logger.Logger); ok {
+		return l
+	}
+	return logger.FromContext(c.UserContext())
+}
+
+// SetLogger replaces request logger, e.g. to add fields known later in the chain
+func SetLogger(c *fiber.Ctx, l *logger.Logger) {
+	c.Locals(logger.LocalsKey, l)
+	c.SetUserContext(logger.NewContext(c.UserContext(), l))
+}
+
+// lazyRoute is matched route template, known only once the chain reaches the handler. Entries
+// logged earlier get route of current middleware, request end fixes it for later ones.
+type lazyRoute struct {
+	mu    sync.Mutex
+	c     *fiber.Ctx
+	self  *fiber.Route
+	route string
+}
+
+func (r *lazyRoute) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.c != nil {
+		return routeTemplate(r.c, r.self)
+	}
+	return r.route
+}
+
+// resolve fixes route, after it fiber.Ctx isn't read again so it can be reused
+func (r *lazyRoute) resolve() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.c != nil {
+		r.route = routeTemplate(r.c, r.self)
+		r.c = nil
+	}
+}
+
+// requestID prefers id generated by requestid middleware over client supplied header
+func requestID(c *fiber.Ctx) string {
+	if id := c.GetRespHeader(fiber.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.Get(fiber.HeaderXRequestID)
+}
@@ -1,13 +1,18 @@
 package middleware
 
 import (
+	"encoding/json"
 	"io"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/token"
 )
 
 func TestLoggerMiddleware(t *testing.T) {
@@ -239,3 +244,68 @@ func TestLoggerMiddleware_ChainedHandlers(t *testing.T) {
 
 	_ = l.Sync()
 }
+
+func TestLoggerMiddleware_RequestLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	l := logger.New(logger.Config{Output: path})
+	tm, err := token.New(testSecret)
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(LoggerMiddleware(l))
+	api := app.Group("/api", AuthMiddleware(tm, l))
+	api.Get("/users/:id", func(c *fiber.Ctx) error {
+		Logger(c).Info("from locals")
+		logger.FromContext(c.UserContext()).Named("service").Info("from context")
+		return c.SendStatus(200)
+	})
+
+	pair, err := tm.IssuePair("user-42")
+	if err != nil {
+		t.Fatalf("IssuePair() error = %v", err)
+	}
+	req := httptest.NewRequest("GET", "/api/users/7", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer "+pair.AccessToken)
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+	_ = l.Sync()
+
+	raw, _ := os.ReadFile(path)
+	entries := map[string]map[string]any{}
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("log line isn't JSON: %v\n%s", err, line)
+		}
+		entries[e["msg"].(string)] = e
+	}
+
+	for _, msg := range []string{"from locals", "from context", "http_request"} {
+		e, ok := entries[msg]
+		if !ok {
+			t.Errorf("%q not logged", msg)
+			continue
+		}
+		want := map[string]string{
+			"request_id": "req-1",
+			"http_route": "/api/users/:id",
+			"user_id":    "user-42",
+		}
+		for k, v := range want {
+			if e[k] != v {
+				t.Errorf("%q %s = %v, want %s", msg, k, e[k], v)
+			}
+		}
+	}
+	if entries["from context"]["logger"] != "service" {
+		t.Errorf("logger = %v, want service", entries["from context"]["logger"])
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/metrics"
+)
+
+// MetricsMiddleware records request count and latency labelled by route template
+func MetricsMiddleware(m *metrics.Metrics) fiber.Handler {
+	inFlight := m.InFlight()
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		self := c.Route()
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		err := c.Next()
+
+		m.ObserveHTTP(
+			routeTemplate(c, self),
+			c.Method(),
+			strconv.Itoa(responseStatus(c, err)),
+			time.Since(start).Seconds(),
+		)
+
+		return err
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/metrics"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := metrics.New()
+
+	app := fiber.New()
+	app.Use(MetricsMiddleware(m))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		return c.SendString(c.Params("id"))
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusBadRequest, "bad")
+	})
+	app.Get("/metrics", m.Handler())
+
+	for _, path := range []string{"/users/1", "/users/2", "/fail", "/random/path"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`api_http_requests_total{method="GET",route="/users/:id",status="200"} 2`,
+		`api_http_requests_total{method="GET",route="/fail",status="400"} 1`,
+		`api_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q\n%s", want, body)
+		}
+	}
+	if strings.Contains(string(body), "/random/path") {
+		t.Error("unmatched path should not be used as label")
+	}
+}
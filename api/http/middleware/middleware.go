@@ -1,2 +1,37 @@
 // Package middleware contains custom HTTP middleware
 package middleware
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+)
+
+// unmatchedRoute label used for requests that didn't match any route,
+// keeps label cardinality bounded when clients probe random paths
+const unmatchedRoute = "unmatched"
+
+// routeTemplate returns matched route path, self is the calling middleware's route
+// which stays current when no handler matched the path
+func routeTemplate(c *fiber.Ctx, self *fiber.Route) string {
+	if c.Route() == self {
+		return unmatchedRoute
+	}
+	return c.Route().Path
+}
+
+// responseStatus returns status that will be sent, including errors handled later by ErrorHandler
+func responseStatus(c *fiber.Ctx, err error) int {
+	if ae, ok := apperror.As(err); ok {
+		return ae.Status()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	if err != nil {
+		return fiber.StatusInternalServerError
+	}
+	return c.Response().StatusCode()
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/api/http/handler"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/ratelimit"
+	"github.com/lomifile/api/pkg/token"
+	"github.com/lomifile/api/pkg/utils"
+	"go.uber.org/zap"
+)
+
+// RateLimitKey Returns identity requests are counted by, empty means key does not apply
+type RateLimitKey func(c *fiber.Ctx) string
+
+// RateLimitByIP counts requests per client IP
+func RateLimitByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// RateLimitByHeader counts requests per header value, e.g. API key. Use only for values
+// validated before this middleware, otherwise clients get fresh buckets by changing it.
+// Value is hashed so secrets are not stored.
+func RateLimitByHeader(name string) RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		v := c.Get(name)
+		if v == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(v))
+		return "hdr:" + hex.EncodeToString(sum[:8])
+	}
+}
+
+// RateLimitBySubject counts requests per JWT subject, uses claims from AuthMiddleware
+// or verifies bearer access token itself when running before it
+func RateLimitBySubject(tm *token.Manager) RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		if claims, ok := Claims(c); ok {
+			return "sub:" + claims.Subject
+		}
+
+		raw, err := utils.ExtractJwtTokenFromHeader(c)
+		if err != nil {
+			return ""
+		}
+		claims, err := tm.Verify(raw, token.Access)
+		if err != nil {
+			return ""
+		}
+		return "sub:" + claims.Subject
+	}
+}
+
+// RateLimitFirst uses first key that applies
+func RateLimitFirst(keys ...RateLimitKey) RateLimitKey {
+	return func(c *fiber.Ctx) string {
+		for _, key := range keys {
+			if id := key(c); id != "" {
+				return id
+			}
+		}
+		return ""
+	}
+}
+
+// RateLimitPolicy Named limit, buckets of different policies are independent
+type RateLimitPolicy struct {
+	Name  string
+	Limit ratelimit.Limit
+	// Key defaults to RateLimitByIP, which is also used when Key returns empty string
+	Key RateLimitKey
+}
+
+// RateLimitMiddleware enforces policy and sets RateLimit-* headers, responds 429 with
+// Retry-After when bucket is empty. Store errors are logged and requests allowed.
+func RateLimitMiddleware(
+	store ratelimit.Store,
+	p RateLimitPolicy,
+	l *logger.Logger,
+) fiber.Handler {
+	rl := l.Named("ratelimit")
+	responder := handler.NewErrorResponder(rl)
+	key := p.Key
+	if key == nil {
+		key = RateLimitByIP
+	}
+	policy := fmt.Sprintf("%d;w=%d", p.Limit.Burst, ceilSeconds(p.Limit.Window()))
+
+	return func(c *fiber.Ctx) error {
+		id := key(c)
+		if id == "" {
+			id = RateLimitByIP(c)
+		}
+
+		res, err := store.Take(c.UserContext(), p.Name+":"+id, p.Limit)
+		if err != nil {
+			rl.Warn(
+				"rate limit store failed, allowing request",
+				zap.String("policy", p.Name),
+				zap.Error(err),
+			)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Policy", policy)
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			return responder.Problem(c, apperror.RateLimited("Too many requests"))
+		}
+
+		return c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
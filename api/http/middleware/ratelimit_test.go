@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/ratelimit"
+	"github.com/lomifile/api/pkg/token"
+)
+
+func newRateLimitApp(store ratelimit.Store, p RateLimitPolicy) *fiber.App {
+	app := fiber.New()
+	app.Use(RateLimitMiddleware(store, p, logger.New(logger.Config{})))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	return app
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	app := newRateLimitApp(ratelimit.NewMemoryStore(), RateLimitPolicy{
+		Name:  "test",
+		Limit: ratelimit.Limit{Rate: 1, Burst: 2},
+	})
+
+	for i, want := range []int{200, 200, 429} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode != want {
+			t.Fatalf("request %d status = %d, want %d", i, resp.StatusCode, want)
+		}
+		if got := resp.Header.Get("RateLimit-Policy"); got != "2;w=2" {
+			t.Errorf("RateLimit-Policy = %q, want 2;w=2", got)
+		}
+		if got := resp.Header.Get("RateLimit-Limit"); got != "2" {
+			t.Errorf("RateLimit-Limit = %q, want 2", got)
+		}
+		if want == 429 && resp.Header.Get(fiber.HeaderRetryAfter) != "1" {
+			t.Errorf("Retry-After = %q, want 1", resp.Header.Get(fiber.HeaderRetryAfter))
+		}
+		if want == 200 && resp.Header.Get("RateLimit-Remaining") != []string{"1", "0"}[i] {
+			t.Errorf("RateLimit-Remaining = %q", resp.Header.Get("RateLimit-Remaining"))
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("db down")
+}
+
+func TestRateLimitMiddleware_FailOpen(t *testing.T) {
+	app := newRateLimitApp(failingStore{}, RateLimitPolicy{
+		Name:  "test",
+		Limit: ratelimit.Limit{Rate: 1, Burst: 1},
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("Status = %d, want 200 when store fails", resp.StatusCode)
+	}
+}
+
+func TestRateLimitMiddleware_PerIdentity(t *testing.T) {
+	app := newRateLimitApp(ratelimit.NewMemoryStore(), RateLimitPolicy{
+		Name:  "test",
+		Limit: ratelimit.Limit{Rate: 0.001, Burst: 1},
+		Key:   RateLimitByHeader("X-API-Key"),
+	})
+
+	status := func(apiKey string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status("a") != 200 || status("a") != 429 {
+		t.Error("second request with same key should be limited")
+	}
+	if status("b") != 200 {
+		t.Error("different key should have own bucket")
+	}
+	if status("") != 200 || status("") != 429 {
+		t.Error("requests without key should fall back to IP bucket")
+	}
+}
+
+func TestRateLimitBySubject(t *testing.T) {
+	tm, err := token.New(testSecret)
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+	pair, err := tm.IssuePair("user-42")
+	if err != nil {
+		t.Fatalf("IssuePair() error = %v", err)
+	}
+
+	var got string
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		got = RateLimitFirst(RateLimitBySubject(tm), RateLimitByIP)(c)
+		return nil
+	})
+
+	for header, want := range map[string]string{
+		"Bearer " + pair.AccessToken:  "sub:user-42",
+		"Bearer " + pair.RefreshToken: "ip:0.0.0.0",
+		"":                            "ip:0.0.0.0",
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set(fiber.HeaderAuthorization, header)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		_ = resp.Body.Close()
+
+		if got != want {
+			t.Errorf("key = %q, want %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Swappable Middleware whose implementation can be replaced while server is running,
+// used to apply reloaded config to middleware configured at construction
+type Swappable struct {
+	h atomic.Pointer[fiber.Handler]
+}
+
+// NewSwappable creates swappable middleware starting with h
+func NewSwappable(h fiber.Handler) *Swappable {
+	s := &Swappable{}
+	s.Swap(h)
+
+	return s
+}
+
+// Swap replaces handler, requests already inside old handler finish with it
+func (s *Swappable) Swap(h fiber.Handler) {
+	s.h.Store(&h)
+}
+
+// Handler returns fiber handler delegating to current implementation
+func (s *Swappable) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return (*s.h.Load())(c)
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestSwappable(t *testing.T) {
+	header := func(v string) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			c.Set("X-Version", v)
+			return c.Next()
+		}
+	}
+
+	sw := NewSwappable(header("one"))
+	app := fiber.New()
+	app.Use(sw.Handler())
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	get := func() string {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatalf("app.Test failed: %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		return resp.Header.Get("X-Version")
+	}
+
+	if got := get(); got != "one" {
+		t.Errorf("X-Version = %v, want one", got)
+	}
+
+	sw.Swap(header("two"))
+	if got := get(); got != "two" {
+		t.Errorf("X-Version after Swap = %v, want two", got)
+	}
+}
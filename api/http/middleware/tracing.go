@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fiberCarrier adapts fasthttp request headers to propagation.TextMapCarrier
+type fiberCarrier struct {
+	c *fiber.Ctx
+}
+
+var _ propagation.TextMapCarrier = fiberCarrier{}
+
+func (fc fiberCarrier) Get(key string) string { return fc.c.Get(key) }
+
+func (fc fiberCarrier) Set(key, value string) { fc.c.Request().Header.Set(key, value) }
+
+func (fc fiberCarrier) Keys() []string {
+	keys := make([]string, 0)
+	fc.c.Request().Header.VisitAll(func(k, _ []byte) {
+		keys = append(keys, string(k))
+	})
+	return keys
+}
+
+// TracingMiddleware starts server span per request, honouring incoming W3C traceparent,
+// and stores span context in c.UserContext() for downstream calls
+func TracingMiddleware() fiber.Handler {
+	tracer := tracing.Tracer()
+
+	return func(c *fiber.Ctx) error {
+		self := c.Route()
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), fiberCarrier{c: c})
+
+		ctx, span := tracer.Start(
+			ctx,
+			c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+				attribute.String("client.address", c.IP()),
+				attribute.String("user_agent.original", c.Get(fiber.HeaderUserAgent)),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		route := routeTemplate(c, self)
+		status := responseStatus(c, err)
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(
+			attribute.String("http.route", route),
+			attribute.Int("http.response.status_code", status),
+		)
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := tracing.New(context.Background(), tracing.Config{
+		Exporter:    tracing.ExporterStdout,
+		SampleRatio: 1,
+		Writer:      &buf,
+	})
+	if err != nil {
+		t.Fatalf("tracing.New() error = %v", err)
+	}
+
+	var got trace.SpanContext
+	app := fiber.New()
+	app.Use(TracingMiddleware())
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		got = trace.SpanContextFromContext(c.UserContext())
+		return c.SendString(c.Params("id"))
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/users/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	if got.TraceID().String() != traceID {
+		t.Errorf("handler trace id = %v, want %v", got.TraceID(), traceID)
+	}
+	if !strings.Contains(buf.String(), `"Name":"GET /users/:id"`) {
+		t.Errorf("exported spans = %s, want span named after route", buf.String())
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/internal/domain/repository"
+)
+
+// errRollback signals transaction rollback for responses that failed without error
+var errRollback = errors.New("middleware: rollback")
+
+// TransactionMiddleware runs rest of the chain in transaction available from c.UserContext(),
+// it's committed when response status is below 400 and rolled back otherwise.
+// Handlers can't be replayed, so tx shouldn't retry, e.g. TxManager with TxRetries(0).
+func TransactionMiddleware(tx repository.Transactor) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		defer c.SetUserContext(ctx)
+
+		var handlerErr error
+		err := tx.Do(ctx, func(txCtx context.Context) error {
+			c.SetUserContext(txCtx)
+			handlerErr = c.Next()
+			if responseStatus(c, handlerErr) >= fiber.StatusBadRequest {
+				return errRollback
+			}
+			return nil
+		})
+		if handlerErr != nil {
+			return handlerErr
+		}
+		if err != nil && !errors.Is(err, errRollback) {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type txKey struct{}
+
+// fakeTx records outcome of last transaction
+type fakeTx struct {
+	committed, rolledBack bool
+	err                   error
+}
+
+func (f *fakeTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.committed, f.rolledBack = false, false
+	if err := fn(context.WithValue(ctx, txKey{}, f)); err != nil {
+		f.rolledBack = true
+		return err
+	}
+	if f.err != nil {
+		return f.err
+	}
+	f.committed = true
+	return nil
+}
+
+func TestTransactionMiddleware(t *testing.T) {
+	tx := &fakeTx{}
+	app := fiber.New()
+	app.Use(TransactionMiddleware(tx))
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		if c.UserContext().Value(txKey{}) == nil {
+			t.Error("handler context doesn't carry transaction")
+		}
+		return c.SendString("ok")
+	})
+	app.Get("/error", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, "taken")
+	})
+	app.Get("/status", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusBadRequest)
+	})
+
+	tests := []struct {
+		path       string
+		commitErr  error
+		wantStatus int
+		wantCommit bool
+	}{
+		{"/ok", nil, fiber.StatusOK, true},
+		{"/error", nil, fiber.StatusConflict, false},
+		{"/status", nil, fiber.StatusBadRequest, false},
+		{"/ok", errors.New("commit failed"), fiber.StatusInternalServerError, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			tx.err = tt.commitErr
+			resp, err := app.Test(httptest.NewRequest("GET", tt.path, nil))
+			if err != nil {
+				t.Fatalf("app.Test failed: %v", err)
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tx.committed != tt.wantCommit {
+				t.Errorf("committed = %v, rolled back = %v", tx.committed, tx.rolledBack)
+			}
+		})
+	}
+}
@@ -3,15 +3,98 @@ package router
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/lomifile/api/api/http/handler"
+	"github.com/lomifile/api/api/http/middleware"
 	"github.com/lomifile/api/config"
 	"github.com/lomifile/api/internal/adapter"
+	"github.com/lomifile/api/internal/domain/service"
+	"github.com/lomifile/api/pkg/email"
+	"github.com/lomifile/api/pkg/health"
 	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/metrics"
+	"github.com/lomifile/api/pkg/ratelimit"
+	"github.com/lomifile/api/pkg/token"
 )
 
+// _authLimit per IP limit of credential endpoints, slows down brute force and email flooding
+var _authLimit = ratelimit.Limit{Rate: 10.0 / 60, Burst: 10}
+
 func NewRouter(
 	app *fiber.App,
 	db *adapter.PostgresAdapter,
 	l *logger.Logger,
 	c *config.Config,
-) {
+	tm *token.Manager,
+	mailer service.Mailer,
+	limits ratelimit.Store,
+) error {
+	userService, err := service.NewUserService(
+		adapter.NewUserRepository(db),
+		mailer,
+		tm,
+		l,
+		service.UserServiceConfig{AppURL: c.AppURL, Locale: c.Email.Locale},
+	)
+	if err != nil {
+		return err
+	}
+
+	// handlers can't be replayed, so request transactions don't retry
+	tx := middleware.TransactionMiddleware(adapter.NewTxManager(db, adapter.TxRetries(0)))
+
+	authHandler := handler.NewAuthHandler(tm)
+	userHandler := handler.NewUserHandler(userService)
+
+	auth := app.Group("/auth", middleware.RateLimitMiddleware(limits, middleware.RateLimitPolicy{
+		Name:  "auth",
+		Limit: _authLimit,
+	}, l))
+	auth.Post("/register", tx, userHandler.Register)
+	auth.Post("/login", userHandler.Login)
+	auth.Post("/refresh", authHandler.Refresh)
+	auth.Post("/verify-email", tx, userHandler.VerifyEmail)
+	auth.Post("/resend-verification", userHandler.ResendVerification)
+	auth.Post("/forgot-password", userHandler.ForgotPassword)
+	auth.Post("/reset-password", tx, userHandler.ResetPassword)
+
+	api := app.Group("/api", middleware.AuthMiddleware(tm, l))
+	api.Get("/me", userHandler.Me)
+
+	return nil
+}
+
+// NewHealthRouter registers /healthz, /readyz and /livez probes
+func NewHealthRouter(app *fiber.App, hr *health.Registry) {
+	h := handler.NewHealthHandler(hr)
+
+	app.Get("/healthz", h.Healthz)
+	app.Get("/readyz", h.Readyz)
+	app.Get("/livez", h.Livez)
+}
+
+// NewEmailRouter registers one-click unsubscribe endpoint of List-Unsubscribe links
+func NewEmailRouter(app *fiber.App, u *email.Unsubscriber) {
+	h := handler.NewEmailHandler(u)
+
+	app.Get("/email/unsubscribe", h.UnsubscribeForm)
+	app.Post("/email/unsubscribe", h.Unsubscribe)
+}
+
+// NewAdminRouter registers admin endpoints guarded by token, GET and PUT /admin/log/level
+// read and change log level without restart, DELETE /admin/email/suppressions/:address lifts
+// suppression
+func NewAdminRouter(app *fiber.App, l *logger.Logger, token string, list email.Suppressions) {
+	admin := app.Group("/admin", middleware.AdminMiddleware(token, l))
+
+	level := adaptor.HTTPHandler(l.LevelHandler())
+	admin.Get("/log/level", level)
+	admin.Put("/log/level", level)
+
+	admin.Delete("/email/suppressions/:address", handler.NewSuppressionHandler(list).Remove)
+}
+
+// NewMetricsRouter registers Prometheus /metrics endpoint
+func NewMetricsRouter(app *fiber.App, m *metrics.Metrics) {
+	app.Get("/metrics", m.Handler())
 }
@@ -1,16 +1,39 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/lomifile/api/config"
 	"github.com/lomifile/api/internal/app"
 )
 
 func main() {
-	c := config.New()
-	err := c.ParseConfig()
+	printConfig := flag.Bool(
+		"print-config",
+		false,
+		"Print effective config with secrets redacted and exit",
+	)
+
+	// loader is kept so the app can reload config on SIGHUP
+	loader := config.NewLoader(config.WithoutValidation())
+	c, err := loader.Load()
 	if err != nil {
-		panic(err)
+		fail(err)
+	}
+	if *printConfig {
+		fmt.Print(c.Redacted())
+		return
 	}
+	if err := c.Validate(); err != nil {
+		fail(err)
+	}
+
+	app.Start(c, loader)
+}
 
-	app.Start(c)
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lomifile/api/pkg/email"
+)
+
+const usage = `Usage: email-preview [flags] TEMPLATE
+
+Renders email template with sample data to HTML and text files for review.
+
+Flags:
+`
+
+// sampler Returns template's sample data with fields from JSON applied
+type sampler func(raw []byte) (email.TemplateData, error)
+
+func sample[T email.TemplateData](v T) sampler {
+	return func(raw []byte) (email.TemplateData, error) {
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fmt.Errorf("data: %w", err)
+			}
+		}
+		return v, nil
+	}
+}
+
+var _samples = map[string]sampler{
+	"verify_email": sample(email.VerifyEmailData{
+		Name: "Ana Horvat",
+		Link: "https://app.example.com/verify-email?token=0123456789abcdef",
+	}),
+	"reset_password": sample(email.ResetPasswordData{
+		Name:      "Ana Horvat",
+		Link:      "https://app.example.com/reset-password?token=0123456789abcdef",
+		ExpiresIn: time.Hour,
+	}),
+}
+
+func main() {
+	locale := flag.String("locale", "en", "Template locale")
+	out := flag.String("out", "", "Output HTML file, default TEMPLATE.LOCALE.html")
+	data := flag.String("data", "", `JSON overriding sample data, e.g. {"Name":"Ivo"}`)
+	dir := flag.String("dir", "", "Render templates from directory instead of embedded ones")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	name := flag.Arg(0)
+
+	var opts []email.RendererOption
+	if *dir != "" {
+		opts = append(opts, email.WithTemplates(os.DirFS(*dir)))
+	}
+	r, err := email.NewRenderer(opts...)
+	if err != nil {
+		fail(err)
+	}
+
+	newData, ok := _samples[name]
+	if !ok {
+		fail(fmt.Errorf("unknown template %q, available: %s", name,
+			strings.Join(r.Templates(), ", ")))
+	}
+	td, err := newData([]byte(*data))
+	if err != nil {
+		fail(err)
+	}
+
+	msg, err := r.Render(*locale, td)
+	if err != nil {
+		fail(err)
+	}
+
+	if *out == "" {
+		*out = fmt.Sprintf("%s.%s.html", name, *locale)
+	}
+	text := strings.TrimSuffix(*out, ".html") + ".txt"
+	if err := os.WriteFile(*out, []byte(msg.HTML), 0o644); err != nil {
+		fail(err)
+	}
+	if err := os.WriteFile(text, []byte(msg.AlternativeString), 0o644); err != nil {
+		fail(err)
+	}
+
+	fmt.Printf("subject: %s\nhtml:    %s\ntext:    %s\n", msg.Subject, *out, text)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "email-preview:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lomifile/api/config"
+	"github.com/lomifile/api/migrations"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/migrate"
+	"github.com/lomifile/api/pkg/postgres"
+)
+
+const usage = `Usage: migrate [flags] <command> [args]
+
+Commands:
+  up             apply all pending migrations
+  down [N]       roll back N most recent migrations (default 1)
+  redo           roll back and reapply the most recent migration
+  status         list migrations and when they were applied
+  create NAME    create new empty up/down files in -dir
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", "./migrations", "Directory for new migration files")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	// only the DSN is needed, full validation would demand unrelated secrets
+	c, err := config.Load(config.WithoutValidation())
+	if err != nil {
+		fail(err)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		if len(args) < 2 {
+			fail(fmt.Errorf("create requires migration name"))
+		}
+		up, down, err := migrate.Create(*dir, args[1], time.Now())
+		if err != nil {
+			fail(err)
+		}
+		fmt.Println(up)
+		fmt.Println(down)
+		return
+	}
+
+	if c.Database.Dsn == "" {
+		fail(fmt.Errorf("database DSN is required, set DB_URL or -db-dsn"))
+	}
+
+	l := logger.New(logger.Config{Service: "migrate", Env: c.Environment, Debug: true})
+	defer func() { _ = l.Sync() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p, err := postgres.New(ctx, c.Database.Dsn, l)
+	if err != nil {
+		fail(err)
+	}
+	defer p.Close()
+
+	m := migrate.New(p.Pool, migrations.FS, l)
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("applied %d migration(s)\n", n)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				fail(fmt.Errorf("down: N must be a positive integer"))
+			}
+		}
+		n, err := m.Down(ctx, steps)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("rolled back %d migration(s)\n", n)
+
+	case "redo":
+		if err := m.Redo(ctx); err != nil {
+			fail(err)
+		}
+		fmt.Println("redo complete")
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			fail(err)
+		}
+		printStatus(statuses)
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func printStatus(statuses []migrate.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		applied := "pending"
+		if s.AppliedAt != nil {
+			applied = s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Missing {
+			applied += " (file missing)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, applied)
+	}
+	_ = w.Flush()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "migrate:", err)
+	os.Exit(1)
+}
@@ -2,10 +2,10 @@
 package config
 
 import (
-	"flag"
-	"os"
-
-	"github.com/joho/godotenv"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
 )
 
 type DatabaseOptions struct {
@@ -13,88 +13,205 @@ type DatabaseOptions struct {
 	MaxOpenConns int
 	MaxIdleConns int
 	MaxIdleTime  string
+	AutoMigrate  bool
+	MinConns     int
+	MinIdleConns int
+	// MaxConnLifetime closes connections after lifetime plus random jitter up to LifetimeJitter
+	MaxConnLifetime   time.Duration
+	LifetimeJitter    time.Duration
+	HealthCheckPeriod time.Duration
+	// QueryExecMode cache_statement, cache_describe, describe_exec, exec or simple_protocol
+	QueryExecMode   string
+	ConnectTimeout  time.Duration
+	ApplicationName string
+	// ReplicaDsns comma separated read replica DSNs
+	ReplicaDsns     string
+	ReplicaBalancer string
+	MaxReplicaLag   time.Duration
 }
 
 type LimiterOptions struct {
 	RPS     float64
 	Burst   int
 	Enabled bool
+	Store   string
+}
+
+type JobsOptions struct {
+	Concurrency  int
+	PollInterval time.Duration
+	LockTimeout  time.Duration
+	DrainTimeout time.Duration
+	// Retention finished and dead jobs older than it are purged
+	Retention time.Duration
+}
+
+type AuthOptions struct {
+	Issuer     string
+	Audience   string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type TracingOptions struct {
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	SampleRatio float64
+}
+
+type LogOptions struct {
+	// Output stderr, stdout or file path, files rotate after MaxSize megabytes
+	Output string
+	// Encoding json or console, empty is console in development and json otherwise
+	Encoding   string
+	MaxSize    int
+	MaxAge     time.Duration
+	MaxBackups int
+	Compress   bool
+	// SampleInitial entries with same message logged each second before every
+	// SampleThereafter-th one, zero disables sampling
+	SampleInitial    int
+	SampleThereafter int
+}
+
+type CORSOptions struct {
+	AllowOrigins string
 }
 
 type Config struct {
 	Port        string
 	Environment string
-	SecretKey   string
-	Database    DatabaseOptions
-	Limiter     LimiterOptions
-	Email       Email
-	CookieKey   string
+	LogLevel    string
+	Log         LogOptions
+	AppURL      string
+	DrainDelay  time.Duration
+	// TrustedProxies comma separated IPs or CIDRs whose ProxyHeader gives client IP, empty
+	// uses connection address so clients can't pick their own rate limit key
+	TrustedProxies string
+	ProxyHeader    string
+	SecretKey      string
+	Database       DatabaseOptions
+	Limiter        LimiterOptions
+	Jobs           JobsOptions
+	Auth           AuthOptions
+	Tracing        TracingOptions
+	CORS           CORSOptions
+	Email          Email
+	CookieKey      string
+	// AdminToken bearer token of admin endpoints, empty disables them
+	AdminToken string
 }
 
 type Email struct {
-	Host     string
-	Username string
-	Password string
+	// Transport smtp, file or memory
+	Transport string
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	// TLSPolicy mandatory, opportunistic, none or ssl (implicit TLS)
+	TLSPolicy string
+	// Auth plain, login, cram-md5, scram-sha-256 or none
+	Auth string
+	// Dir maildir written by file transport
+	Dir string
+	// From sender address, default is Username
+	From string
+	// Locale of templated emails, variants fall back to en
+	Locale string
+	// DKIMDomain signs emails with DKIM key of DKIMSelector read from DKIMKeyFile
+	DKIMDomain   string
+	DKIMSelector string
+	DKIMKeyFile  string
+	// UnsubscribeURL public URL of this API's one-click unsubscribe endpoint, empty disables
+	// unsubscribe links. app_url is the frontend, so it isn't derived from it.
+	UnsubscribeURL string
 }
 
+// Enabled reports whether emails are sent, smtp transport needs host
+func (e Email) Enabled() bool {
+	return e.Transport != "smtp" || e.Host != ""
+}
+
+// Sender returns sender address
+func (e Email) Sender() string {
+	if e.From != "" {
+		return e.From
+	}
+	return e.Username
+}
+
+// Level returns log_level, empty one is debug in development and info elsewhere so
+// production doesn't log debug entries unless asked to
+func (c *Config) Level() string {
+	if c.LogLevel != "" {
+		return c.LogLevel
+	}
+	if c.Environment == "development" {
+		return "debug"
+	}
+	return "info"
+}
+
+// New returns config populated with defaults only
 func New() *Config {
-	err := godotenv.Load("./.env")
-	if err != nil {
-		panic(err)
+	c := &Config{}
+	for _, f := range c.fields() {
+		// defaults are static and covered by tests, parse errors are not possible
+		_ = f.set(f.def)
 	}
 
-	return &Config{}
+	return c
 }
 
+// ParseConfig loads c from config file, .env, environment and command line flags
 func (c *Config) ParseConfig() error {
-	flag.StringVar(&c.Port, "port", os.Getenv("PORT"), "API port")
-	flag.StringVar(
-		&c.Environment,
-		"environment",
-		os.Getenv("ENVIRONMENT"),
-		"Environment (production|development)",
-	)
-	flag.StringVar(&c.SecretKey, "secret-key", os.Getenv("SECRET_KEY"), "JWT secret key")
-	flag.StringVar(&c.CookieKey, "cookie-eky", os.Getenv("COOKIE_KEY"), "Cookie secret key")
-
-	flag.StringVar(&c.Database.Dsn, "db-dsn", os.Getenv("DB_URL"), "PostgreSQL DSN")
-
-	flag.IntVar(
-		&c.Database.MaxOpenConns,
-		"db-max-open-conns",
-		25,
-		"PostgreSQL max open connections",
-	)
-	flag.IntVar(
-		&c.Database.MaxIdleConns,
-		"db-max-idle-conns",
-		25,
-		"PostgreSQL max idle connections",
-	)
-	flag.StringVar(
-		&c.Database.MaxIdleTime,
-		"db-max-idle-time",
-		"15m",
-		"PostgreSQL max connection idle time",
-	)
-
-	flag.Float64Var(&c.Limiter.RPS, "limiter-rps", 2, "Rate limiter maximum requests per second")
-	flag.IntVar(&c.Limiter.Burst, "limiter-burst", 4, "Rate limiter maximum burst")
-	flag.BoolVar(&c.Limiter.Enabled, "limiter-enabled", true, "Enable rate limiter")
-
-	flag.StringVar(&c.Email.Host, "email-host", os.Getenv("EMAIL_SERVER"), "Email dsn")
-	flag.StringVar(
-		&c.Email.Username,
-		"email-username",
-		os.Getenv("EMAIL_USERNAME"),
-		"Email username",
-	)
-	flag.StringVar(
-		&c.Email.Password,
-		"email-password",
-		os.Getenv("EMAIL_PASSWORD"),
-		"Email password",
-	)
+	loaded, err := Load()
+	if err != nil {
+		return err
+	}
+	*c = *loaded
 
 	return nil
 }
+
+// Redacted returns effective config one key per line with secrets masked
+func (c *Config) Redacted() string {
+	var b strings.Builder
+	for _, f := range c.fields() {
+		v := f.value()
+		if f.secret {
+			v = redact(v)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", f.key, v)
+	}
+
+	return b.String()
+}
+
+// String implements fmt.Stringer so printing config never leaks secrets
+func (c *Config) String() string {
+	return c.Redacted()
+}
+
+// redact keeps URL structure of DSNs visible and masks everything else,
+// comma separated DSN lists are redacted one by one
+func redact(v string) string {
+	if v == "" {
+		return ""
+	}
+	if parts := strings.Split(v, ","); len(parts) > 1 {
+		for i, p := range parts {
+			parts[i] = redact(p)
+		}
+		return strings.Join(parts, ",")
+	}
+	if u, err := url.Parse(v); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			return u.Redacted()
+		}
+	}
+
+	return "***"
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 )
 
 func TestConfig_Struct(t *testing.T) {
@@ -255,3 +256,43 @@ func TestConfig_DevelopmentSettings(t *testing.T) {
 		t.Error("Limiter should be disabled in development")
 	}
 }
+
+func TestConfig_Level(t *testing.T) {
+	tests := []struct {
+		env, level, want string
+	}{
+		{"development", "", "debug"},
+		{"staging", "", "info"},
+		{"production", "", "info"},
+		{"production", "warn", "warn"},
+	}
+
+	for _, tt := range tests {
+		c := &Config{Environment: tt.env, LogLevel: tt.level}
+		if got := c.Level(); got != tt.want {
+			t.Errorf("Level() in %s with %q = %v, want %v", tt.env, tt.level, got, tt.want)
+		}
+	}
+	if New().LogLevel != "" {
+		t.Error("log_level should default to empty so environment picks it")
+	}
+}
+
+func TestAuthOptions(t *testing.T) {
+	opts := AuthOptions{
+		Issuer:     "api",
+		Audience:   "web,mobile",
+		AccessTTL:  15 * time.Minute,
+		RefreshTTL: 24 * time.Hour,
+	}
+
+	if opts.Issuer != "api" {
+		t.Errorf("Issuer = %v, want api", opts.Issuer)
+	}
+	if opts.AccessTTL != 15*time.Minute {
+		t.Errorf("AccessTTL = %v, want 15m", opts.AccessTTL)
+	}
+	if opts.RefreshTTL <= opts.AccessTTL {
+		t.Error("RefreshTTL should be longer than AccessTTL")
+	}
+}
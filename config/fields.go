@@ -0,0 +1,614 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// field Describes one setting and where each layer reads it from
+type field struct {
+	// key dotted path in config files, e.g. database.dsn
+	key    string
+	env    string
+	flag   string
+	def    string
+	usage  string
+	secret bool
+	// reloadable setting takes effect on reload, others need restart
+	reloadable bool
+	ptr        any
+}
+
+func (c *Config) fields() []field {
+	return []field{
+		{key: "port", env: "PORT", flag: "port", def: "8080", usage: "API port", ptr: &c.Port},
+		{
+			key:   "environment",
+			env:   "ENVIRONMENT",
+			flag:  "environment",
+			def:   "development",
+			usage: "Environment (development|staging|production|test)",
+			ptr:   &c.Environment,
+		},
+		{
+			key:        "log_level",
+			env:        "LOG_LEVEL",
+			flag:       "log-level",
+			usage:      "Minimum log level (debug|info|warn|error), default depends on environment",
+			reloadable: true,
+			ptr:        &c.LogLevel,
+		},
+		{
+			key:   "log.output",
+			env:   "LOG_OUTPUT",
+			flag:  "log-output",
+			def:   "stderr",
+			usage: "Log output (stderr|stdout|file path), files are rotated",
+			ptr:   &c.Log.Output,
+		},
+		{
+			key:   "log.encoding",
+			env:   "LOG_ENCODING",
+			flag:  "log-encoding",
+			usage: "Log encoding (json|console), default is console in development",
+			ptr:   &c.Log.Encoding,
+		},
+		{
+			key:   "log.max_size",
+			env:   "LOG_MAX_SIZE",
+			flag:  "log-max-size",
+			def:   "100",
+			usage: "Megabytes written to log file before it is rotated",
+			ptr:   &c.Log.MaxSize,
+		},
+		{
+			key:   "log.max_age",
+			env:   "LOG_MAX_AGE",
+			flag:  "log-max-age",
+			def:   "168h",
+			usage: "How long rotated log files are kept, rounded up to days",
+			ptr:   &c.Log.MaxAge,
+		},
+		{
+			key:   "log.max_backups",
+			env:   "LOG_MAX_BACKUPS",
+			flag:  "log-max-backups",
+			def:   "5",
+			usage: "Rotated log files kept, 0 keeps all",
+			ptr:   &c.Log.MaxBackups,
+		},
+		{
+			key:   "log.compress",
+			env:   "LOG_COMPRESS",
+			flag:  "log-compress",
+			def:   "false",
+			usage: "Gzip rotated log files",
+			ptr:   &c.Log.Compress,
+		},
+		{
+			key:   "log.sample_initial",
+			env:   "LOG_SAMPLE_INITIAL",
+			flag:  "log-sample-initial",
+			def:   "100",
+			usage: "Entries with same message logged each second before sampling, 0 disables",
+			ptr:   &c.Log.SampleInitial,
+		},
+		{
+			key:   "log.sample_thereafter",
+			env:   "LOG_SAMPLE_THEREAFTER",
+			flag:  "log-sample-thereafter",
+			def:   "100",
+			usage: "Every n-th entry logged once sampling starts",
+			ptr:   &c.Log.SampleThereafter,
+		},
+		{
+			key:   "app_url",
+			env:   "APP_URL",
+			flag:  "app-url",
+			usage: "Public app URL used in email links",
+			ptr:   &c.AppURL,
+		},
+		{
+			key:   "shutdown_drain_delay",
+			env:   "SHUTDOWN_DRAIN_DELAY",
+			flag:  "shutdown-drain-delay",
+			def:   "0s",
+			usage: "Time readiness reports failing before listener closes on shutdown",
+			ptr:   &c.DrainDelay,
+		},
+		{
+			key:   "trusted_proxies",
+			env:   "TRUSTED_PROXIES",
+			flag:  "trusted-proxies",
+			usage: "Comma separated proxy IPs or CIDRs trusted to set proxy_header",
+			ptr:   &c.TrustedProxies,
+		},
+		{
+			key:   "proxy_header",
+			env:   "PROXY_HEADER",
+			flag:  "proxy-header",
+			def:   "X-Forwarded-For",
+			usage: "Header with client IP set by trusted proxies",
+			ptr:   &c.ProxyHeader,
+		},
+		{
+			key:    "secret_key",
+			env:    "SECRET_KEY",
+			flag:   "secret-key",
+			usage:  "JWT secret key",
+			secret: true,
+			ptr:    &c.SecretKey,
+		},
+		{
+			key:    "cookie_key",
+			env:    "COOKIE_KEY",
+			flag:   "cookie-key",
+			usage:  "Cookie secret key, base64 encoded 32 bytes",
+			secret: true,
+			ptr:    &c.CookieKey,
+		},
+		{
+			key:    "admin_token",
+			env:    "ADMIN_TOKEN",
+			flag:   "admin-token",
+			usage:  "Bearer token of admin endpoints, empty disables them",
+			secret: true,
+			ptr:    &c.AdminToken,
+		},
+
+		{
+			key:    "database.dsn",
+			env:    "DB_URL",
+			flag:   "db-dsn",
+			usage:  "PostgreSQL DSN",
+			secret: true,
+			ptr:    &c.Database.Dsn,
+		},
+		{
+			key:   "database.max_open_conns",
+			env:   "DB_MAX_OPEN_CONNS",
+			flag:  "db-max-open-conns",
+			def:   "25",
+			usage: "PostgreSQL max open connections",
+			ptr:   &c.Database.MaxOpenConns,
+		},
+		{
+			key:   "database.max_idle_conns",
+			env:   "DB_MAX_IDLE_CONNS",
+			flag:  "db-max-idle-conns",
+			def:   "25",
+			usage: "PostgreSQL max idle connections",
+			ptr:   &c.Database.MaxIdleConns,
+		},
+		{
+			key:   "database.max_idle_time",
+			env:   "DB_MAX_IDLE_TIME",
+			flag:  "db-max-idle-time",
+			def:   "15m",
+			usage: "PostgreSQL max connection idle time",
+			ptr:   &c.Database.MaxIdleTime,
+		},
+		{
+			key:   "database.auto_migrate",
+			env:   "DB_AUTO_MIGRATE",
+			flag:  "db-auto-migrate",
+			def:   "false",
+			usage: "Apply pending migrations on startup",
+			ptr:   &c.Database.AutoMigrate,
+		},
+		{
+			key:   "database.min_conns",
+			env:   "DB_MIN_CONNS",
+			flag:  "db-min-conns",
+			def:   "0",
+			usage: "PostgreSQL connections kept open when idle",
+			ptr:   &c.Database.MinConns,
+		},
+		{
+			key:   "database.min_idle_conns",
+			env:   "DB_MIN_IDLE_CONNS",
+			flag:  "db-min-idle-conns",
+			def:   "0",
+			usage: "PostgreSQL idle connections kept ready",
+			ptr:   &c.Database.MinIdleConns,
+		},
+		{
+			key:   "database.max_conn_lifetime",
+			env:   "DB_MAX_CONN_LIFETIME",
+			flag:  "db-max-conn-lifetime",
+			def:   "1h",
+			usage: "PostgreSQL connection lifetime",
+			ptr:   &c.Database.MaxConnLifetime,
+		},
+		{
+			key:   "database.max_conn_lifetime_jitter",
+			env:   "DB_MAX_CONN_LIFETIME_JITTER",
+			flag:  "db-max-conn-lifetime-jitter",
+			def:   "0s",
+			usage: "Random time added to connection lifetime",
+			ptr:   &c.Database.LifetimeJitter,
+		},
+		{
+			key:   "database.health_check_period",
+			env:   "DB_HEALTH_CHECK_PERIOD",
+			flag:  "db-health-check-period",
+			def:   "1m",
+			usage: "PostgreSQL idle connection health check period",
+			ptr:   &c.Database.HealthCheckPeriod,
+		},
+		{
+			key:   "database.query_exec_mode",
+			env:   "DB_QUERY_EXEC_MODE",
+			flag:  "db-query-exec-mode",
+			def:   "cache_statement",
+			usage: "Query mode (cache_statement|cache_describe|describe_exec|exec|simple_protocol)",
+			ptr:   &c.Database.QueryExecMode,
+		},
+		{
+			key:   "database.connect_timeout",
+			env:   "DB_CONNECT_TIMEOUT",
+			flag:  "db-connect-timeout",
+			def:   "5s",
+			usage: "PostgreSQL connect timeout",
+			ptr:   &c.Database.ConnectTimeout,
+		},
+		{
+			key:   "database.application_name",
+			env:   "DB_APPLICATION_NAME",
+			flag:  "db-application-name",
+			def:   "api",
+			usage: "application_name shown in pg_stat_activity",
+			ptr:   &c.Database.ApplicationName,
+		},
+		{
+			key:    "database.replica_dsns",
+			env:    "DB_REPLICA_URLS",
+			flag:   "db-replica-dsns",
+			usage:  "Comma separated PostgreSQL read replica DSNs",
+			secret: true,
+			ptr:    &c.Database.ReplicaDsns,
+		},
+		{
+			key:   "database.replica_balancer",
+			env:   "DB_REPLICA_BALANCER",
+			flag:  "db-replica-balancer",
+			def:   "round_robin",
+			usage: "Read replica selection (round_robin|least_conns)",
+			ptr:   &c.Database.ReplicaBalancer,
+		},
+		{
+			key:   "database.max_replica_lag",
+			env:   "DB_MAX_REPLICA_LAG",
+			flag:  "db-max-replica-lag",
+			def:   "10s",
+			usage: "Replication lag after which reads fall back to primary",
+			ptr:   &c.Database.MaxReplicaLag,
+		},
+
+		{
+			key:        "limiter.rps",
+			env:        "LIMITER_RPS",
+			flag:       "limiter-rps",
+			def:        "2",
+			usage:      "Rate limiter maximum requests per second",
+			reloadable: true,
+			ptr:        &c.Limiter.RPS,
+		},
+		{
+			key:        "limiter.burst",
+			env:        "LIMITER_BURST",
+			flag:       "limiter-burst",
+			def:        "4",
+			usage:      "Rate limiter maximum burst",
+			reloadable: true,
+			ptr:        &c.Limiter.Burst,
+		},
+		{
+			key:        "limiter.enabled",
+			env:        "LIMITER_ENABLED",
+			flag:       "limiter-enabled",
+			def:        "true",
+			usage:      "Enable rate limiter",
+			reloadable: true,
+			ptr:        &c.Limiter.Enabled,
+		},
+		{
+			key:   "limiter.store",
+			env:   "LIMITER_STORE",
+			flag:  "limiter-store",
+			def:   "memory",
+			usage: "Rate limiter bucket store (memory|postgres), postgres shares limits",
+			ptr:   &c.Limiter.Store,
+		},
+
+		{
+			key:   "jobs.concurrency",
+			env:   "JOBS_CONCURRENCY",
+			flag:  "jobs-concurrency",
+			def:   "10",
+			usage: "Background jobs running at once",
+			ptr:   &c.Jobs.Concurrency,
+		},
+		{
+			key:   "jobs.poll_interval",
+			env:   "JOBS_POLL_INTERVAL",
+			flag:  "jobs-poll-interval",
+			def:   "1s",
+			usage: "How often idle worker looks for due jobs",
+			ptr:   &c.Jobs.PollInterval,
+		},
+		{
+			key:   "jobs.lock_timeout",
+			env:   "JOBS_LOCK_TIMEOUT",
+			flag:  "jobs-lock-timeout",
+			def:   "5m",
+			usage: "Job run time limit, after it job of crashed worker runs again",
+			ptr:   &c.Jobs.LockTimeout,
+		},
+		{
+			key:   "jobs.drain_timeout",
+			env:   "JOBS_DRAIN_TIMEOUT",
+			flag:  "jobs-drain-timeout",
+			def:   "30s",
+			usage: "Time running jobs get to finish on shutdown",
+			ptr:   &c.Jobs.DrainTimeout,
+		},
+		{
+			key:   "jobs.retention",
+			env:   "JOBS_RETENTION",
+			flag:  "jobs-retention",
+			def:   "168h",
+			usage: "How long finished and dead jobs are kept",
+			ptr:   &c.Jobs.Retention,
+		},
+
+		{
+			key:   "auth.issuer",
+			env:   "AUTH_ISSUER",
+			flag:  "auth-issuer",
+			def:   "api",
+			usage: "JWT issuer claim",
+			ptr:   &c.Auth.Issuer,
+		},
+		{
+			key:   "auth.audience",
+			env:   "AUTH_AUDIENCE",
+			flag:  "auth-audience",
+			usage: "JWT audience claim, comma separated",
+			ptr:   &c.Auth.Audience,
+		},
+		{
+			key:   "auth.access_ttl",
+			env:   "AUTH_ACCESS_TTL",
+			flag:  "auth-access-ttl",
+			def:   "15m",
+			usage: "JWT access token TTL",
+			ptr:   &c.Auth.AccessTTL,
+		},
+		{
+			key:   "auth.refresh_ttl",
+			env:   "AUTH_REFRESH_TTL",
+			flag:  "auth-refresh-ttl",
+			def:   "168h",
+			usage: "JWT refresh token TTL",
+			ptr:   &c.Auth.RefreshTTL,
+		},
+
+		{
+			key:   "tracing.exporter",
+			env:   "TRACING_EXPORTER",
+			flag:  "tracing-exporter",
+			def:   "none",
+			usage: "Tracing exporter (none|stdout|otlp)",
+			ptr:   &c.Tracing.Exporter,
+		},
+		{
+			key:   "tracing.endpoint",
+			env:   "OTEL_EXPORTER_OTLP_ENDPOINT",
+			flag:  "tracing-endpoint",
+			usage: "OTLP/HTTP collector host:port",
+			ptr:   &c.Tracing.Endpoint,
+		},
+		{
+			key:   "tracing.insecure",
+			env:   "TRACING_INSECURE",
+			flag:  "tracing-insecure",
+			def:   "false",
+			usage: "Send spans to collector over plain HTTP",
+			ptr:   &c.Tracing.Insecure,
+		},
+		{
+			key:   "tracing.sample_ratio",
+			env:   "TRACING_SAMPLE_RATIO",
+			flag:  "tracing-sample-ratio",
+			def:   "1",
+			usage: "Fraction of new traces sampled",
+			ptr:   &c.Tracing.SampleRatio,
+		},
+
+		{
+			key:        "cors.allow_origins",
+			env:        "CORS_ALLOW_ORIGINS",
+			flag:       "cors-allow-origins",
+			def:        "http://localhost:5173",
+			usage:      "Comma separated origins allowed by CORS",
+			reloadable: true,
+			ptr:        &c.CORS.AllowOrigins,
+		},
+
+		{
+			key:   "email.transport",
+			env:   "EMAIL_TRANSPORT",
+			flag:  "email-transport",
+			def:   "smtp",
+			usage: "Email transport (smtp|file|memory), file writes maildir for development",
+			ptr:   &c.Email.Transport,
+		},
+		{
+			key:        "email.host",
+			env:        "EMAIL_SERVER",
+			flag:       "email-host",
+			usage:      "SMTP server hostname",
+			reloadable: true,
+			ptr:        &c.Email.Host,
+		},
+		{
+			key:        "email.username",
+			env:        "EMAIL_USERNAME",
+			flag:       "email-username",
+			usage:      "Email username",
+			reloadable: true,
+			ptr:        &c.Email.Username,
+		},
+		{
+			key:        "email.password",
+			env:        "EMAIL_PASSWORD",
+			flag:       "email-password",
+			usage:      "Email password",
+			secret:     true,
+			reloadable: true,
+			ptr:        &c.Email.Password,
+		},
+		{
+			key:        "email.port",
+			env:        "EMAIL_PORT",
+			flag:       "email-port",
+			def:        "587",
+			usage:      "SMTP port",
+			reloadable: true,
+			ptr:        &c.Email.Port,
+		},
+		{
+			key:        "email.tls_policy",
+			env:        "EMAIL_TLS_POLICY",
+			flag:       "email-tls-policy",
+			def:        "mandatory",
+			usage:      "SMTP TLS (mandatory|opportunistic|none|ssl)",
+			reloadable: true,
+			ptr:        &c.Email.TLSPolicy,
+		},
+		{
+			key:        "email.auth",
+			env:        "EMAIL_AUTH",
+			flag:       "email-auth",
+			def:        "plain",
+			usage:      "SMTP auth mechanism (plain|login|cram-md5|scram-sha-256|none)",
+			reloadable: true,
+			ptr:        &c.Email.Auth,
+		},
+		{
+			key:        "email.dir",
+			env:        "EMAIL_DIR",
+			flag:       "email-dir",
+			def:        "mail",
+			usage:      "Maildir of file transport",
+			reloadable: true,
+			ptr:        &c.Email.Dir,
+		},
+		{
+			key:        "email.from",
+			env:        "EMAIL_FROM",
+			flag:       "email-from",
+			usage:      "Sender address, default is email.username",
+			reloadable: true,
+			ptr:        &c.Email.From,
+		},
+		{
+			key:   "email.locale",
+			env:   "EMAIL_LOCALE",
+			flag:  "email-locale",
+			def:   "en",
+			usage: "Locale of email templates, e.g. hr or hr-HR",
+			ptr:   &c.Email.Locale,
+		},
+		{
+			key:        "email.dkim_domain",
+			env:        "EMAIL_DKIM_DOMAIN",
+			flag:       "email-dkim-domain",
+			usage:      "Domain signing emails with DKIM, empty disables signing",
+			reloadable: true,
+			ptr:        &c.Email.DKIMDomain,
+		},
+		{
+			key:        "email.dkim_selector",
+			env:        "EMAIL_DKIM_SELECTOR",
+			flag:       "email-dkim-selector",
+			usage:      "DKIM selector, public key is published at <selector>._domainkey.<domain>",
+			reloadable: true,
+			ptr:        &c.Email.DKIMSelector,
+		},
+		{
+			key:        "email.dkim_key_file",
+			env:        "EMAIL_DKIM_KEY_FILE",
+			flag:       "email-dkim-key-file",
+			usage:      "PEM encoded RSA or Ed25519 DKIM private key",
+			reloadable: true,
+			ptr:        &c.Email.DKIMKeyFile,
+		},
+		{
+			key:   "email.unsubscribe_url",
+			env:   "EMAIL_UNSUBSCRIBE_URL",
+			flag:  "email-unsubscribe-url",
+			usage: "Public URL of API's /email/unsubscribe endpoint, empty disables unsubscribe",
+			ptr:   &c.Email.UnsubscribeURL,
+		},
+	}
+}
+
+// parse converts raw string to the field's type without assigning it
+func (f field) parse(raw string) (any, error) {
+	switch f.ptr.(type) {
+	case *string:
+		return raw, nil
+	case *int:
+		return strconv.Atoi(raw)
+	case *bool:
+		return strconv.ParseBool(raw)
+	case *float64:
+		return strconv.ParseFloat(raw, 64)
+	case *time.Duration:
+		return time.ParseDuration(raw)
+	default:
+		return nil, fmt.Errorf("unsupported type %T", f.ptr)
+	}
+}
+
+func (f field) set(raw string) error {
+	v, err := f.parse(raw)
+	if err != nil {
+		return err
+	}
+
+	switch p := f.ptr.(type) {
+	case *string:
+		*p = v.(string)
+	case *int:
+		*p = v.(int)
+	case *bool:
+		*p = v.(bool)
+	case *float64:
+		*p = v.(float64)
+	case *time.Duration:
+		*p = v.(time.Duration)
+	}
+
+	return nil
+}
+
+func (f field) value() string {
+	switch p := f.ptr.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	case *float64:
+		return strconv.FormatFloat(*p, 'g', -1, 64)
+	case *time.Duration:
+		return p.String()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,356 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	_configFileEnv  = "CONFIG_FILE"
+	_configFileFlag = "config"
+	_secretSuffix   = "_FILE"
+)
+
+var _defaultEnvFiles = []string{".env"}
+
+// Loader Merges configuration layers, each overriding the previous one:
+// defaults, config file, .env files, environment variables, <ENV>_FILE secrets, flags
+type Loader struct {
+	mu        sync.Mutex
+	fs        *flag.FlagSet
+	args      []string
+	envFiles  []string
+	file      string
+	lookupEnv func(string) (string, bool)
+	validate  bool
+
+	parsed     bool
+	parseErr   error
+	flags      map[string]*flagValue
+	configPath string
+	// loadedFile config file read by the last Load
+	loadedFile string
+}
+
+// Option Provides function to loader options
+type Option func(*Loader)
+
+// WithFlagSet registers flags on fs and parses args, defaults to flag.CommandLine and os.Args
+func WithFlagSet(fs *flag.FlagSet, args []string) Option {
+	return func(l *Loader) {
+		l.fs = fs
+		l.args = args
+	}
+}
+
+// WithEnvFiles sets dotenv files to read, later files override earlier ones,
+// missing files are skipped
+func WithEnvFiles(paths ...string) Option { return func(l *Loader) { l.envFiles = paths } }
+
+// WithFile sets config file used when neither CONFIG_FILE nor -config is given
+func WithFile(path string) Option { return func(l *Loader) { l.file = path } }
+
+// WithLookupEnv replaces os.LookupEnv, used by tests
+func WithLookupEnv(fn func(string) (string, bool)) Option {
+	return func(l *Loader) { l.lookupEnv = fn }
+}
+
+// WithoutValidation skips Validate, for tools that need only part of the config
+func WithoutValidation() Option { return func(l *Loader) { l.validate = false } }
+
+// NewLoader creates loader, flags are registered and parsed on first Load
+func NewLoader(opts ...Option) *Loader {
+	l := &Loader{
+		fs:        flag.CommandLine,
+		args:      os.Args[1:],
+		envFiles:  _defaultEnvFiles,
+		lookupEnv: os.LookupEnv,
+		validate:  true,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// Load creates loader with opts and loads config once
+func Load(opts ...Option) (*Config, error) {
+	return NewLoader(opts...).Load()
+}
+
+// Load reads every layer again and returns validated config,
+// flags are parsed only once so calling Load repeatedly is safe
+func (l *Loader) Load() (*Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.parseFlags(); err != nil {
+		return nil, err
+	}
+
+	env, err := l.env()
+	if err != nil {
+		return nil, err
+	}
+
+	c := New()
+	fields := c.fields()
+	var errs []error
+	apply := func(f field, source, raw string) {
+		if err := f.set(raw); err != nil {
+			errs = append(errs, fmt.Errorf("config: %s from %s: %w", f.key, source, err))
+		}
+	}
+
+	path := l.file
+	if v, ok := env(_configFileEnv); ok {
+		path = v
+	}
+	if l.configPath != "" {
+		path = l.configPath
+	}
+	l.loadedFile = path
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, unknownKeys(fields, values, path)...)
+		for _, f := range fields {
+			if raw, ok := values[f.key]; ok {
+				apply(f, "file "+path, raw)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		raw, hasValue := env(f.env)
+		if hasValue {
+			apply(f, "env "+f.env, raw)
+		}
+
+		secretPath, ok := env(f.env + _secretSuffix)
+		if !ok {
+			continue
+		}
+		if hasValue {
+			errs = append(errs, fmt.Errorf(
+				"config: both %s and %s%s are set", f.env, f.env, _secretSuffix,
+			))
+			continue
+		}
+		data, err := os.ReadFile(secretPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("config: %s%s: %w", f.env, _secretSuffix, err))
+			continue
+		}
+		apply(f, "env "+f.env+_secretSuffix, strings.TrimRight(string(data), "\r\n"))
+	}
+
+	for _, f := range fields {
+		if fv, ok := l.flags[f.key]; ok && fv.set {
+			apply(f, "flag -"+f.flag, fv.raw)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if l.validate {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Files returns config file read by the last Load and dotenv files, used to watch for changes
+func (l *Loader) Files() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files := make([]string, 0, len(l.envFiles)+1)
+	if l.loadedFile != "" {
+		files = append(files, l.loadedFile)
+	}
+
+	return append(files, l.envFiles...)
+}
+
+func (l *Loader) parseFlags() error {
+	if l.parsed {
+		return l.parseErr
+	}
+	l.parsed = true
+
+	l.flags = make(map[string]*flagValue)
+	for _, f := range New().fields() {
+		if f.flag == "" {
+			continue
+		}
+		fv := &flagValue{f: f}
+		l.flags[f.key] = fv
+		l.fs.Var(fv, f.flag, f.usage)
+	}
+	l.fs.StringVar(
+		&l.configPath,
+		_configFileFlag,
+		"",
+		"Path to YAML, TOML or JSON config file, overrides "+_configFileEnv,
+	)
+
+	if !l.fs.Parsed() {
+		l.parseErr = l.fs.Parse(l.args)
+	}
+
+	return l.parseErr
+}
+
+// env returns lookup where process environment wins over dotenv files. Empty values count as
+// unset, .env templates list keys with empty values and they must not wipe config file ones.
+func (l *Loader) env() (func(string) (string, bool), error) {
+	dotenv := make(map[string]string)
+	for _, path := range l.envFiles {
+		values, err := godotenv.Read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("config: read %s: %w", path, err)
+		}
+		for k, v := range values {
+			dotenv[k] = v
+		}
+	}
+
+	return func(key string) (string, bool) {
+		if key == "" {
+			return "", false
+		}
+		if v, ok := l.lookupEnv(key); ok && v != "" {
+			return v, true
+		}
+		v := dotenv[key]
+		return v, v != ""
+	}, nil
+}
+
+// flagValue Records raw flag value so it can be applied on top of other layers
+type flagValue struct {
+	f   field
+	raw string
+	set bool
+}
+
+func (v *flagValue) String() string {
+	if v.set {
+		return v.raw
+	}
+	return v.f.def
+}
+
+func (v *flagValue) Set(raw string) error {
+	if _, err := v.f.parse(raw); err != nil {
+		return err
+	}
+	v.raw = raw
+	v.set = true
+
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	_, ok := v.f.ptr.(*bool)
+	return ok
+}
+
+// readFile decodes config file by extension and flattens it into dotted keys
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: read %s: %w", path, err)
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&raw)
+	default:
+		return nil, fmt.Errorf("config: unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: decode %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flatten("", raw, values)
+
+	return values, nil
+}
+
+func flatten(prefix string, in map[string]any, out map[string]string) {
+	for k, v := range in {
+		key := strings.ReplaceAll(strings.ToLower(k), "-", "_")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch t := v.(type) {
+		case map[string]any:
+			flatten(key, t, out)
+		case []any:
+			parts := make([]string, len(t))
+			for i, p := range t {
+				parts[i] = fmt.Sprint(p)
+			}
+			out[key] = strings.Join(parts, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(t)
+		}
+	}
+}
+
+func unknownKeys(fields []field, values map[string]string, path string) []error {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.key] = true
+	}
+
+	var unknown []string
+	for k := range values {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+
+	errs := make([]error, 0, len(unknown))
+	for _, k := range unknown {
+		errs = append(errs, fmt.Errorf("config: unknown key %q in %s", k, path))
+	}
+
+	return errs
+}
@@ -0,0 +1,273 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func envMap(m map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := m[k]
+		return v, ok
+	}
+}
+
+func testLoader(env map[string]string, args []string, opts ...Option) *Loader {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	base := []Option{
+		WithFlagSet(fs, args),
+		WithEnvFiles(),
+		WithLookupEnv(envMap(env)),
+		WithoutValidation(),
+	}
+	return NewLoader(append(base, opts...)...)
+}
+
+func TestNew_Defaults(t *testing.T) {
+	c := New()
+
+	for _, f := range c.fields() {
+		if _, err := f.parse(f.def); err != nil && f.def != "" {
+			t.Errorf("default of %s = %q does not parse: %v", f.key, f.def, err)
+		}
+	}
+	if c.Port != "8080" || c.Environment != "development" {
+		t.Errorf("Port, Environment = %v, %v", c.Port, c.Environment)
+	}
+	if c.Auth.RefreshTTL != 7*24*time.Hour {
+		t.Errorf("Auth.RefreshTTL = %v, want 168h", c.Auth.RefreshTTL)
+	}
+	if !c.Limiter.Enabled || c.Tracing.SampleRatio != 1 {
+		t.Error("limiter should be enabled and every trace sampled by default")
+	}
+}
+
+func TestFields_Unique(t *testing.T) {
+	keys, envs, flags := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, f := range New().fields() {
+		if keys[f.key] || envs[f.env] || flags[f.flag] {
+			t.Errorf("duplicate field %s", f.key)
+		}
+		keys[f.key], envs[f.env], flags[f.flag] = true, true, true
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "app.yaml", `
+port: 7000
+environment: staging
+database:
+  dsn: postgres://file/db
+  max_open_conns: 10
+auth:
+  issuer: file
+`)
+	dotenv := writeFile(t, ".env", "PORT=7001\nAUTH_ISSUER=dotenv\nDB_MAX_OPEN_CONNS=11\n")
+
+	c, err := testLoader(
+		map[string]string{"PORT": "7002", "AUTH_ISSUER": "env"},
+		[]string{"-port=7003"},
+		WithFile(file),
+		WithEnvFiles(dotenv),
+	).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if c.Port != "7003" {
+		t.Errorf("Port = %v, want flag value 7003", c.Port)
+	}
+	if c.Auth.Issuer != "env" {
+		t.Errorf("Auth.Issuer = %v, want env value", c.Auth.Issuer)
+	}
+	if c.Database.MaxOpenConns != 11 {
+		t.Errorf("Database.MaxOpenConns = %v, want .env value 11", c.Database.MaxOpenConns)
+	}
+	if c.Environment != "staging" || c.Database.Dsn != "postgres://file/db" {
+		t.Errorf("file values not applied: %v, %v", c.Environment, c.Database.Dsn)
+	}
+	if c.Auth.AccessTTL != 15*time.Minute {
+		t.Errorf("Auth.AccessTTL = %v, want default 15m", c.Auth.AccessTTL)
+	}
+}
+
+func TestLoad_FileFormats(t *testing.T) {
+	files := map[string]string{
+		"app.yaml": "limiter:\n  rps: 0.5\n  enabled: false\nauth:\n  access-ttl: 5m\n",
+		"app.toml": "[limiter]\nrps = 0.5\nenabled = false\n[auth]\naccess_ttl = \"5m\"\n",
+		"app.json": `{"limiter": {"rps": 0.5, "enabled": false}, "auth": {"access_ttl": "5m"}}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			c, err := testLoader(nil, nil, WithFile(writeFile(t, name, content))).Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if c.Limiter.RPS != 0.5 || c.Limiter.Enabled {
+				t.Errorf("Limiter = %+v, want rps 0.5 disabled", c.Limiter)
+			}
+			if c.Auth.AccessTTL != 5*time.Minute {
+				t.Errorf("Auth.AccessTTL = %v, want 5m", c.Auth.AccessTTL)
+			}
+		})
+	}
+}
+
+func TestLoad_FileSelection(t *testing.T) {
+	fromEnv := writeFile(t, "env.json", `{"port": "7100"}`)
+	fromFlag := writeFile(t, "flag.json", `{"port": "7200"}`)
+
+	c, err := testLoader(map[string]string{"CONFIG_FILE": fromEnv}, nil).Load()
+	if err != nil || c.Port != "7100" {
+		t.Errorf("CONFIG_FILE: Port = %v, err = %v", c.Port, err)
+	}
+
+	c, err = testLoader(
+		map[string]string{"CONFIG_FILE": fromEnv},
+		[]string{"-config", fromFlag},
+	).Load()
+	if err != nil || c.Port != "7200" {
+		t.Errorf("-config: Port = %v, err = %v", c.Port, err)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	file := writeFile(t, "app.yaml", "prot: 1\ndatabase:\n  max_open_conns: many\n")
+
+	_, err := testLoader(
+		map[string]string{"AUTH_ACCESS_TTL": "soon"},
+		nil,
+		WithFile(file),
+	).Load()
+	if err == nil {
+		t.Fatal("Load() should fail")
+	}
+	wants := []string{`unknown key "prot"`, "database.max_open_conns", "AUTH_ACCESS_TTL"}
+	for _, want := range wants {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+}
+
+func TestLoad_UnsupportedFile(t *testing.T) {
+	if _, err := testLoader(nil, nil, WithFile(writeFile(t, "app.ini", ""))).Load(); err == nil {
+		t.Error("Load() with .ini file should fail")
+	}
+}
+
+func TestLoad_SecretFile(t *testing.T) {
+	secret := writeFile(t, "secret", "from-file-secret\n")
+
+	c, err := testLoader(map[string]string{"SECRET_KEY_FILE": secret}, nil).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.SecretKey != "from-file-secret" {
+		t.Errorf("SecretKey = %q, want trimmed file content", c.SecretKey)
+	}
+
+	_, err = testLoader(map[string]string{
+		"SECRET_KEY":      "inline",
+		"SECRET_KEY_FILE": secret,
+	}, nil).Load()
+	if err == nil {
+		t.Error("Load() with both SECRET_KEY and SECRET_KEY_FILE should fail")
+	}
+}
+
+func TestLoad_SecretFileWithEmptyDotenv(t *testing.T) {
+	secret := writeFile(t, "secret", "from-file-secret\n")
+	dotenv := writeFile(t, ".env", "SECRET_KEY=\nDB_URL=\n")
+
+	c, err := testLoader(
+		map[string]string{"SECRET_KEY_FILE": secret},
+		nil,
+		WithEnvFiles(dotenv),
+	).Load()
+	if err != nil {
+		t.Fatalf("Load() with empty dotenv SECRET_KEY error = %v", err)
+	}
+	if c.SecretKey != "from-file-secret" {
+		t.Errorf("SecretKey = %q, want file content", c.SecretKey)
+	}
+}
+
+func TestLoad_EmptyEnvKeepsFile(t *testing.T) {
+	file := writeFile(t, "config.yaml", "secret_key: from-file-secret\nport: \"9000\"\n")
+	dotenv := writeFile(t, ".env", "SECRET_KEY=\nPORT=\n")
+
+	c, err := testLoader(
+		map[string]string{"CONFIG_FILE": file, "PORT": ""},
+		nil,
+		WithEnvFiles(dotenv),
+	).Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if c.SecretKey != "from-file-secret" || c.Port != "9000" {
+		t.Errorf("SecretKey = %q, Port = %q, want config file values", c.SecretKey, c.Port)
+	}
+}
+
+func TestLoad_MissingEnvFileIgnored(t *testing.T) {
+	_, err := testLoader(nil, nil, WithEnvFiles(filepath.Join(t.TempDir(), ".env"))).Load()
+	if err != nil {
+		t.Errorf("Load() with missing .env error = %v", err)
+	}
+}
+
+func TestLoad_Validates(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	_, err := NewLoader(
+		WithFlagSet(fs, nil),
+		WithEnvFiles(),
+		WithLookupEnv(envMap(nil)),
+	).Load()
+	if err == nil {
+		t.Error("Load() without required settings should fail validation")
+	}
+}
+
+func TestLoader_ReloadReusesFlags(t *testing.T) {
+	env := map[string]string{"AUTH_ISSUER": "first"}
+	l := testLoader(env, []string{"-port=7300"})
+
+	if _, err := l.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	env["AUTH_ISSUER"] = "second"
+
+	c, err := l.Load()
+	if err != nil {
+		t.Fatalf("second Load() error = %v", err)
+	}
+	if c.Port != "7300" || c.Auth.Issuer != "second" {
+		t.Errorf("Port, Issuer = %v, %v, want 7300, second", c.Port, c.Auth.Issuer)
+	}
+}
+
+func TestFlagValue_RejectsInvalid(t *testing.T) {
+	_, err := testLoader(nil, []string{"-db-max-open-conns=lots"}).Load()
+	if err == nil {
+		t.Error("Load() with invalid flag should fail")
+	}
+}
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lomifile/api/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const _reloadDebounce = 500 * time.Millisecond
+
+// Subscriber Prepares component for next config. Returned apply must not fail and runs only
+// after every subscriber prepared successfully, so no component sees a partial reload.
+// Nil apply means nothing to do.
+type Subscriber func(old, next *Config) (apply func(), err error)
+
+// Change Setting that differs between two configs, secret values are redacted
+type Change struct {
+	Key        string
+	Old        string
+	New        string
+	Reloadable bool
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Key, c.Old, c.New)
+}
+
+// Diff returns settings that differ between old and next
+func Diff(old, next *Config) []Change {
+	of, nf := old.fields(), next.fields()
+
+	var changes []Change
+	for i := range of {
+		ov, nv := of[i].value(), nf[i].value()
+		if ov == nv {
+			continue
+		}
+		if of[i].secret {
+			ov, nv = redact(ov), redact(nv)
+		}
+		changes = append(changes, Change{
+			Key:        of[i].key,
+			Old:        ov,
+			New:        nv,
+			Reloadable: of[i].reloadable,
+		})
+	}
+
+	return changes
+}
+
+type subscription struct {
+	name string
+	fn   Subscriber
+}
+
+// Reloader Applies reloadable settings on SIGHUP or config file change
+type Reloader struct {
+	loader  *Loader
+	l       *logger.Logger
+	mu      sync.Mutex
+	current atomic.Pointer[Config]
+	subs    []subscription
+}
+
+// NewReloader creates reloader starting from current config
+func NewReloader(loader *Loader, current *Config, l *logger.Logger) *Reloader {
+	r := &Reloader{loader: loader, l: l.Named("config")}
+	r.current.Store(current)
+
+	return r
+}
+
+// Subscribe registers component notified on reload, subscribers run in registration order
+func (r *Reloader) Subscribe(name string, fn Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subs = append(r.subs, subscription{name: name, fn: fn})
+}
+
+// Current returns effective config
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// Reload loads config again and applies reloadable changes,
+// changes to other settings are logged and ignored until restart
+func (r *Reloader) Reload(source string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.loader.Load()
+	if err == nil {
+		err = loaded.Validate()
+	}
+	if err != nil {
+		r.l.Error("config reload failed", zap.String("source", source), zap.Error(err))
+		return err
+	}
+
+	old := r.current.Load()
+	next := *old
+	nf, lf := next.fields(), loaded.fields()
+	for i := range nf {
+		if nf[i].reloadable {
+			// values were produced by value() and validated, round trip cannot fail
+			_ = nf[i].set(lf[i].value())
+		}
+	}
+
+	var applied, restart []string
+	for _, ch := range Diff(old, loaded) {
+		if ch.Reloadable {
+			applied = append(applied, ch.String())
+		} else {
+			restart = append(restart, ch.String())
+		}
+	}
+	if len(restart) > 0 {
+		r.l.Warn(
+			"config changes need restart, ignored",
+			zap.String("source", source),
+			zap.Strings("changes", restart),
+		)
+	}
+	if len(applied) == 0 {
+		r.l.Info("config reloaded, nothing to apply", zap.String("source", source))
+		return nil
+	}
+
+	applies := make([]func(), 0, len(r.subs))
+	for _, s := range r.subs {
+		apply, err := s.fn(old, &next)
+		if err != nil {
+			err = fmt.Errorf("config: reload %s: %w", s.name, err)
+			r.l.Error("config reload aborted", zap.String("source", source), zap.Error(err))
+			return err
+		}
+		if apply != nil {
+			applies = append(applies, apply)
+		}
+	}
+	for _, apply := range applies {
+		apply()
+	}
+	r.current.Store(&next)
+
+	r.l.Info("config reloaded", zap.String("source", source), zap.Strings("changes", applied))
+
+	return nil
+}
+
+// Run reloads on SIGHUP and when config or dotenv files change, until ctx is done
+func (r *Reloader) Run(ctx context.Context) error {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("config: watch: %w", err)
+	}
+	defer watcher.Close()
+
+	// directories are watched because editors and secret mounts replace files by rename
+	watched := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, f := range r.loader.Files() {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			continue
+		}
+		watched[abs] = true
+		dir := filepath.Dir(abs)
+		if dirs[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			r.l.Warn("config watch skipped", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		dirs[dir] = true
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-sig:
+			_ = r.Reload("SIGHUP")
+
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			abs, err := filepath.Abs(ev.Name)
+			if err == nil && watched[abs] && !ev.Has(fsnotify.Chmod) {
+				debounce = time.After(_reloadDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.l.Warn("config watch error", zap.Error(err))
+
+		case <-debounce:
+			debounce = nil
+			_ = r.Reload("file")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReloader_RunSIGHUP(t *testing.T) {
+	env := validEnv()
+	r := newTestReloader(t, env)
+
+	reloaded := make(chan *Config, 1)
+	r.Subscribe("test", func(old, next *Config) (func(), error) {
+		return func() { reloaded <- next }, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- r.Run(ctx) }()
+
+	// keeps default SIGHUP action from killing the test before Run registers its handler
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	env["LOG_LEVEL"] = "warn"
+	// Run registers signal handler asynchronously, repeat until it is observed
+	deadline := time.After(5 * time.Second)
+	for {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case c := <-reloaded:
+			if c.LogLevel != "warn" {
+				t.Errorf("LogLevel = %v, want warn", c.LogLevel)
+			}
+			cancel()
+			if err := <-done; err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+			return
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("SIGHUP did not trigger reload")
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const _minSecretKeyLength = 32
+
+var _environments = map[string]bool{
+	"development": true,
+	"staging":     true,
+	"production":  true,
+	"test":        true,
+}
+
+var _queryExecModes = map[string]bool{
+	"cache_statement": true,
+	"cache_describe":  true,
+	"describe_exec":   true,
+	"exec":            true,
+	"simple_protocol": true,
+}
+
+// _maxApplicationName Postgres truncates longer names (NAMEDATALEN - 1)
+const _maxApplicationName = 63
+
+var _replicaBalancers = map[string]bool{"round_robin": true, "least_conns": true}
+
+var _limiterStores = map[string]bool{"memory": true, "postgres": true}
+
+var _exporters = map[string]bool{"none": true, "stdout": true, "otlp": true}
+
+var _logEncodings = map[string]bool{"": true, "json": true, "console": true}
+
+var _emailTransports = map[string]bool{"smtp": true, "file": true, "memory": true}
+
+var _tlsPolicies = map[string]bool{
+	"mandatory":     true,
+	"opportunistic": true,
+	"none":          true,
+	"ssl":           true,
+}
+
+var _smtpAuth = map[string]bool{
+	"plain":         true,
+	"login":         true,
+	"cram-md5":      true,
+	"scram-sha-256": true,
+	"none":          true,
+}
+
+// Validate checks every setting and returns all problems joined
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("config: "+format, args...))
+		}
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port <= 65535, "port %q is not a valid TCP port", c.Port)
+	check(
+		_environments[c.Environment],
+		"environment %q must be development, staging, production or test",
+		c.Environment,
+	)
+	if c.LogLevel != "" {
+		_, err = zapcore.ParseLevel(c.LogLevel)
+		check(err == nil, "log_level %q is not a valid level", c.LogLevel)
+	}
+	check(c.Log.Output != "", "log.output is required")
+	check(
+		_logEncodings[c.Log.Encoding],
+		"log.encoding %q must be json or console",
+		c.Log.Encoding,
+	)
+	check(c.Log.MaxSize > 0, "log.max_size must be positive")
+	check(c.Log.MaxAge >= 0, "log.max_age must not be negative")
+	check(c.Log.MaxBackups >= 0, "log.max_backups must not be negative")
+	check(c.Log.SampleInitial >= 0, "log.sample_initial must not be negative")
+	if c.Log.SampleInitial > 0 {
+		check(c.Log.SampleThereafter > 0, "log.sample_thereafter must be positive")
+	}
+	if c.AppURL != "" {
+		u, err := url.Parse(c.AppURL)
+		check(err == nil && u.Scheme != "" && u.Host != "", "app_url must be absolute URL")
+	}
+	check(c.DrainDelay >= 0, "shutdown_drain_delay must not be negative")
+	if c.TrustedProxies != "" {
+		for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+			proxy = strings.TrimSpace(proxy)
+			_, _, err := net.ParseCIDR(proxy)
+			check(
+				net.ParseIP(proxy) != nil || err == nil,
+				"trusted_proxies %q must be IP or CIDR",
+				proxy,
+			)
+		}
+		check(c.ProxyHeader != "", "proxy_header is required with trusted_proxies")
+	}
+
+	check(
+		len(c.SecretKey) >= _minSecretKeyLength,
+		"secret_key must be at least %d bytes",
+		_minSecretKeyLength,
+	)
+	key, err := base64.StdEncoding.DecodeString(c.CookieKey)
+	check(err == nil && len(key) == 32, "cookie_key must be base64 encoded 32 bytes")
+	// empty token disables admin endpoints
+	check(
+		c.AdminToken == "" || len(c.AdminToken) >= _minSecretKeyLength,
+		"admin_token must be at least %d bytes",
+		_minSecretKeyLength,
+	)
+
+	check(c.Database.Dsn != "", "database.dsn is required")
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+	check(
+		c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns must be between 0 and database.max_open_conns",
+	)
+	idle, err := time.ParseDuration(c.Database.MaxIdleTime)
+	check(
+		err == nil && idle >= 0,
+		"database.max_idle_time %q is not a duration",
+		c.Database.MaxIdleTime,
+	)
+	check(
+		c.Database.MinConns >= 0 && c.Database.MinConns <= c.Database.MaxOpenConns,
+		"database.min_conns must be between 0 and database.max_open_conns",
+	)
+	check(
+		c.Database.MinIdleConns >= 0 && c.Database.MinIdleConns <= c.Database.MaxOpenConns,
+		"database.min_idle_conns must be between 0 and database.max_open_conns",
+	)
+	check(c.Database.MaxConnLifetime >= 0, "database.max_conn_lifetime must not be negative")
+	check(
+		c.Database.LifetimeJitter >= 0 &&
+			(c.Database.LifetimeJitter == 0 || c.Database.MaxConnLifetime > 0),
+		"database.max_conn_lifetime_jitter needs positive database.max_conn_lifetime",
+	)
+	check(
+		c.Database.HealthCheckPeriod >= 0,
+		"database.health_check_period must not be negative",
+	)
+	check(
+		_queryExecModes[c.Database.QueryExecMode],
+		"database.query_exec_mode %q must be cache_statement, cache_describe, describe_exec, "+
+			"exec or simple_protocol",
+		c.Database.QueryExecMode,
+	)
+	check(c.Database.ConnectTimeout > 0, "database.connect_timeout must be positive")
+	check(
+		len(c.Database.ApplicationName) <= _maxApplicationName,
+		"database.application_name must be at most %d bytes",
+		_maxApplicationName,
+	)
+	check(
+		_replicaBalancers[c.Database.ReplicaBalancer],
+		"database.replica_balancer %q must be round_robin or least_conns",
+		c.Database.ReplicaBalancer,
+	)
+	check(c.Database.MaxReplicaLag >= 0, "database.max_replica_lag must not be negative")
+
+	check(
+		_limiterStores[c.Limiter.Store],
+		"limiter.store %q must be memory or postgres",
+		c.Limiter.Store,
+	)
+	if c.Limiter.Enabled {
+		check(c.Limiter.RPS > 0, "limiter.rps must be positive")
+		check(c.Limiter.Burst > 0, "limiter.burst must be positive")
+	}
+
+	check(c.Jobs.Concurrency > 0, "jobs.concurrency must be positive")
+	check(c.Jobs.PollInterval > 0, "jobs.poll_interval must be positive")
+	check(c.Jobs.LockTimeout > 0, "jobs.lock_timeout must be positive")
+	check(c.Jobs.DrainTimeout >= 0, "jobs.drain_timeout must not be negative")
+	check(c.Jobs.Retention > 0, "jobs.retention must be positive")
+
+	for _, origin := range strings.Split(c.CORS.AllowOrigins, ",") {
+		u, err := url.Parse(strings.TrimSpace(origin))
+		// credentials are allowed so wildcard origin is rejected by the CORS middleware too
+		check(
+			err == nil && u.Scheme != "" && u.Host != "",
+			"cors.allow_origins %q must be absolute origin",
+			origin,
+		)
+	}
+
+	check(c.Auth.AccessTTL > 0, "auth.access_ttl must be positive")
+	check(
+		c.Auth.RefreshTTL > c.Auth.AccessTTL,
+		"auth.refresh_ttl must be longer than auth.access_ttl",
+	)
+
+	check(
+		_exporters[c.Tracing.Exporter],
+		"tracing.exporter %q must be none, stdout or otlp",
+		c.Tracing.Exporter,
+	)
+	check(
+		c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio must be between 0 and 1",
+	)
+
+	check(
+		_emailTransports[c.Email.Transport],
+		"email.transport %q must be smtp, file or memory",
+		c.Email.Transport,
+	)
+	if c.Email.Enabled() {
+		check(c.Email.Sender() != "", "email.from or email.username is required to send emails")
+	}
+	if c.Email.Transport == "smtp" && c.Email.Host != "" {
+		check(c.Email.Port > 0 && c.Email.Port <= 65535, "email.port must be valid TCP port")
+		check(
+			_tlsPolicies[c.Email.TLSPolicy],
+			"email.tls_policy %q must be mandatory, opportunistic, none or ssl",
+			c.Email.TLSPolicy,
+		)
+		check(
+			_smtpAuth[c.Email.Auth],
+			"email.auth %q must be plain, login, cram-md5, scram-sha-256 or none",
+			c.Email.Auth,
+		)
+		check(
+			c.Email.Auth == "none" || c.Email.Username != "",
+			"email.username is required when email.auth is set",
+		)
+	}
+	if c.Email.Transport == "file" {
+		check(c.Email.Dir != "", "email.dir is required by file transport")
+	}
+	if c.Email.DKIMDomain != "" {
+		check(
+			c.Email.DKIMSelector != "" && c.Email.DKIMKeyFile != "",
+			"email.dkim_selector and email.dkim_key_file are required by email.dkim_domain",
+		)
+	}
+	if c.Email.UnsubscribeURL != "" {
+		u, err := url.Parse(c.Email.UnsubscribeURL)
+		check(
+			err == nil && u.Scheme != "" && u.Host != "" &&
+				strings.HasSuffix(u.Path, "/email/unsubscribe"),
+			"email.unsubscribe_url must be absolute URL of API's /email/unsubscribe endpoint",
+		)
+	}
+
+	return errors.Join(errs...)
+}
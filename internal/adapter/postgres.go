@@ -4,25 +4,116 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/lomifile/api/pkg/postgres"
 )
 
+// PostgresAdapter Embedded DB is the primary, reads can be routed to replicas with Reader
 type PostgresAdapter struct {
 	*sqlx.DB
+
+	pg      *postgres.Postgres
+	primary *Node
+	nodes   map[*postgres.Node]*Node
+}
+
+// Node Database server that serves queries, Name tells which one in logs
+type Node struct {
+	*sqlx.DB
+	name string
+}
+
+// Node returns server name, primary or replica-N
+func (n *Node) Node() string {
+	return n.name
+}
+
+// AdapterOption Configures database/sql pool sqlx keeps on top of every pgxpool
+type AdapterOption func(*sqlx.DB)
+
+// WithSQLPool limits database/sql connections, each open one holds pgxpool connection,
+// so maxOpen should match pool max conns. Zero maxIdleTime keeps idle connections.
+func WithSQLPool(maxOpen, maxIdle int, maxIdleTime time.Duration) AdapterOption {
+	return func(db *sqlx.DB) {
+		db.SetMaxOpenConns(maxOpen)
+		db.SetMaxIdleConns(maxIdle)
+		db.SetConnMaxIdleTime(maxIdleTime)
+	}
 }
 
-func NewPostgresAdapter(pg *postgres.Postgres) (*PostgresAdapter, error) {
-	sqlDB := stdlib.OpenDBFromPool(pg.Pool)
-	dbx := sqlx.NewDb(sqlDB, "pgx")
+func NewPostgresAdapter(pg *postgres.Postgres, opts ...AdapterOption) (*PostgresAdapter, error) {
+	open := func(pool *pgxpool.Pool) *sqlx.DB {
+		db := sqlx.NewDb(stdlib.OpenDBFromPool(pool), "pgx")
+		for _, opt := range opts {
+			opt(db)
+		}
+		return db
+	}
+	primary := &Node{DB: open(pg.Pool), name: "primary"}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	if err := dbx.PingContext(ctx); err != nil {
+	if err := primary.PingContext(ctx); err != nil {
 		return nil, err
 	}
 
-	return &PostgresAdapter{DB: dbx}, nil
+	a := &PostgresAdapter{
+		DB:      primary.DB,
+		pg:      pg,
+		primary: primary,
+		nodes:   map[*postgres.Node]*Node{},
+	}
+	if pg.Primary != nil {
+		a.nodes[pg.Primary] = primary
+	}
+	for _, n := range pg.Replicas {
+		a.nodes[n] = &Node{DB: open(n.Pool), name: n.Name}
+	}
+
+	return a, nil
+}
+
+type primaryKey struct{}
+
+// ReadFromPrimary returns ctx whose reads skip replicas, e.g. to read own writes
+func ReadFromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// Reader returns connection for read only queries, running transaction when ctx has one,
+// healthy replica when configured and primary otherwise. Replicas may lag behind primary.
+func (a *PostgresAdapter) Reader(ctx context.Context) Querier {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return s.tx
+	}
+	return a.reader(ctx)
+}
+
+func (a *PostgresAdapter) reader(ctx context.Context) *Node {
+	if ctx.Value(primaryKey{}) != nil || a.pg == nil || len(a.pg.Replicas) == 0 {
+		return a.writer()
+	}
+	if n, ok := a.nodes[a.pg.Reader()]; ok {
+		return n
+	}
+	return a.writer()
+}
+
+func (a *PostgresAdapter) writer() *Node {
+	if a.primary == nil {
+		// adapter built by hand, e.g. in tests
+		return &Node{DB: a.DB, name: "primary"}
+	}
+	return a.primary
+}
+
+// NodeName returns name of server q sends queries to, empty when unknown
+func NodeName(q Querier) string {
+	if n, ok := q.(interface{ Node() string }); ok {
+		return n.Node()
+	}
+	return ""
 }
@@ -0,0 +1,354 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/lomifile/api/internal/domain/model"
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/pkg/pagination"
+	"github.com/lomifile/api/pkg/query"
+)
+
+const _defaultIDColumn = "id"
+
+// RepositoryOption Configures Repository
+type RepositoryOption func(*repositoryConfig)
+
+type repositoryConfig struct {
+	id         string
+	softDelete string
+	version    string
+	generated  []string
+}
+
+// WithIDColumn sets primary key column, default is id
+func WithIDColumn(column string) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.id = column
+	}
+}
+
+// WithSoftDelete marks rows deleted by setting timestamp column instead of removing them,
+// deleted rows are hidden from reads
+func WithSoftDelete(column string) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.softDelete = column
+	}
+}
+
+// WithVersion enables optimistic locking, column is incremented on every update and
+// update of stale entity fails with repository.ErrConflict
+func WithVersion(column string) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.version = column
+	}
+}
+
+// WithGenerated sets columns filled by database on insert, default is id column,
+// created_at and updated_at
+func WithGenerated(columns ...string) RepositoryOption {
+	return func(c *repositoryConfig) {
+		c.generated = columns
+	}
+}
+
+// Repository Generic CRUD for struct T mapped by db tags, T must have field for id column
+type Repository[T any, ID comparable] struct {
+	db          *PostgresAdapter
+	table       string
+	cfg         repositoryConfig
+	columns     []string
+	fields      map[string][]int
+	withDeleted bool
+
+	selectSQL string
+	insertSQL string
+	updateSQL string
+}
+
+var _ repository.Repository[model.User, int64] = (*Repository[model.User, int64])(nil)
+
+// NewRepository creates repository for table, panics when T doesn't map configured columns
+func NewRepository[T any, ID comparable](
+	db *PostgresAdapter,
+	table string,
+	opts ...RepositoryOption,
+) *Repository[T, ID] {
+	cfg := repositoryConfig{id: _defaultIDColumn}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if cfg.generated == nil {
+		cfg.generated = []string{cfg.id, "created_at", "updated_at"}
+	}
+
+	r := &Repository[T, ID]{db: db, table: table, cfg: cfg, fields: map[string][]int{}}
+	mapFields(reflect.TypeFor[T](), nil, r.fields, &r.columns)
+
+	for _, col := range []string{cfg.id, cfg.softDelete, cfg.version} {
+		if _, ok := r.fields[col]; col != "" && !ok {
+			panic(fmt.Sprintf("adapter: %T has no field for column %q", *new(T), col))
+		}
+	}
+
+	r.buildSQL()
+	return r
+}
+
+// mapFields collects db tagged fields, embedded structs without tag are flattened
+func mapFields(t reflect.Type, parent []int, fields map[string][]int, columns *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		index := append(slices.Clone(parent), i)
+		name, _, _ := strings.Cut(f.Tag.Get("db"), ",")
+
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			mapFields(f.Type, index, fields, columns)
+			continue
+		}
+		if name == "" || name == "-" || !f.IsExported() {
+			continue
+		}
+		fields[name] = index
+		*columns = append(*columns, name)
+	}
+}
+
+func (r *Repository[T, ID]) buildSQL() {
+	table := query.Ident(r.table)
+	returning := make([]string, len(r.columns))
+	for i, col := range r.columns {
+		returning[i] = query.Ident(col)
+	}
+	cols := strings.Join(returning, ", ")
+
+	r.selectSQL = "SELECT " + cols + " FROM " + table
+
+	var insertCols, values []string
+	for _, col := range r.writable() {
+		insertCols = append(insertCols, query.Ident(col))
+		values = append(values, fmt.Sprintf("$%d", len(values)+1))
+	}
+	if r.cfg.version != "" {
+		insertCols = append(insertCols, query.Ident(r.cfg.version))
+		values = append(values, "1")
+	}
+	r.insertSQL = fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+		table,
+		strings.Join(insertCols, ", "),
+		strings.Join(values, ", "),
+		cols,
+	)
+
+	var sets []string
+	for i, col := range r.writable() {
+		sets = append(sets, fmt.Sprintf("%s = $%d", query.Ident(col), i+1))
+	}
+	next := len(sets) + 1
+	if _, ok := r.fields["updated_at"]; ok && slices.Contains(r.cfg.generated, "updated_at") {
+		sets = append(sets, `"updated_at" = now()`)
+	}
+	where := fmt.Sprintf("%s = $%d", query.Ident(r.cfg.id), next)
+	if r.cfg.version != "" {
+		v := query.Ident(r.cfg.version)
+		sets = append(sets, v+" = "+v+" + 1")
+		where += fmt.Sprintf(" AND %s = $%d", v, next+1)
+	}
+	if r.cfg.softDelete != "" {
+		where += " AND " + query.Ident(r.cfg.softDelete) + " IS NULL"
+	}
+	r.updateSQL = fmt.Sprintf(
+		"UPDATE %s SET %s WHERE %s RETURNING %s",
+		table,
+		strings.Join(sets, ", "),
+		where,
+		cols,
+	)
+}
+
+// writable returns columns set from entity on insert and update
+func (r *Repository[T, ID]) writable() []string {
+	var cols []string
+	for _, col := range r.columns {
+		if slices.Contains(r.cfg.generated, col) ||
+			col == r.cfg.version ||
+			col == r.cfg.softDelete {
+			continue
+		}
+		cols = append(cols, col)
+	}
+	return cols
+}
+
+// WithDeleted returns repository that also reads soft deleted rows
+func (r *Repository[T, ID]) WithDeleted() *Repository[T, ID] {
+	c := *r
+	c.withDeleted = true
+	return &c
+}
+
+// scope adds soft delete filter to conditions
+func (r *Repository[T, ID]) scope(conds ...query.Cond) query.Cond {
+	if r.cfg.softDelete != "" && !r.withDeleted {
+		conds = append(conds, query.IsNull(r.cfg.softDelete))
+	}
+	return query.And(conds...)
+}
+
+func (r *Repository[T, ID]) where(b *query.Builder, conds ...query.Cond) {
+	if c := r.scope(conds...); c != nil {
+		b.Write(" WHERE ").Cond(c)
+	}
+}
+
+func (r *Repository[T, ID]) Get(ctx context.Context, id ID) (*T, error) {
+	b := query.NewBuilder(1).Write(r.selectSQL)
+	r.where(b, query.Eq(r.cfg.id, id))
+
+	var entity T
+	q := r.db.Reader(ctx)
+	if err := q.GetContext(ctx, &entity, b.String(), b.Args()...); err != nil {
+		return nil, readError(q, err)
+	}
+	return &entity, nil
+}
+
+func (r *Repository[T, ID]) List(
+	ctx context.Context,
+	where query.Cond,
+	page *pagination.Params,
+) ([]T, error) {
+	conds := []query.Cond{where}
+	if page != nil && page.Keyset {
+		conds = append(conds, query.Expr(page.Where))
+	}
+
+	b := query.NewBuilder(1).Write(r.selectSQL)
+	r.where(b, conds...)
+	if page != nil {
+		b.Write(" " + page.Clause())
+	} else {
+		b.Write(" ORDER BY " + query.Ident(r.cfg.id))
+	}
+
+	rows := []T{}
+	q := r.db.Reader(ctx)
+	if err := q.SelectContext(ctx, &rows, b.String(), b.Args()...); err != nil {
+		return nil, readError(q, err)
+	}
+	return rows, nil
+}
+
+func (r *Repository[T, ID]) Count(ctx context.Context, where query.Cond) (int, error) {
+	b := query.NewBuilder(1).Write("SELECT count(*) FROM " + query.Ident(r.table))
+	r.where(b, where)
+
+	var n int
+	q := r.db.Reader(ctx)
+	if err := q.GetContext(ctx, &n, b.String(), b.Args()...); err != nil {
+		return 0, readError(q, err)
+	}
+	return n, nil
+}
+
+func (r *Repository[T, ID]) Exists(ctx context.Context, id ID) (bool, error) {
+	return r.exists(ctx, r.db.Reader(ctx), id)
+}
+
+func (r *Repository[T, ID]) exists(ctx context.Context, q Querier, id any) (bool, error) {
+	b := query.NewBuilder(1).Write("SELECT EXISTS (SELECT 1 FROM " + query.Ident(r.table))
+	r.where(b, query.Eq(r.cfg.id, id))
+	b.Write(")")
+
+	var ok bool
+	if err := q.GetContext(ctx, &ok, b.String(), b.Args()...); err != nil {
+		return false, readError(q, err)
+	}
+	return ok, nil
+}
+
+// readError maps err of query on q, unexpected errors name the node so logs show which
+// replica failed
+func readError(q Querier, err error) error {
+	err = mapError(err)
+	if errors.Is(err, repository.ErrNotFound) || errors.Is(err, repository.ErrDuplicate) {
+		return err
+	}
+	if node := NodeName(q); node != "" {
+		return fmt.Errorf("query on %s: %w", node, err)
+	}
+	return err
+}
+
+func (r *Repository[T, ID]) Create(ctx context.Context, entity *T) error {
+	args := r.values(entity, r.writable())
+	return mapError(r.db.Conn(ctx).GetContext(ctx, entity, r.insertSQL, args...))
+}
+
+func (r *Repository[T, ID]) Update(ctx context.Context, entity *T) error {
+	keys := []string{r.cfg.id}
+	if r.cfg.version != "" {
+		keys = append(keys, r.cfg.version)
+	}
+	args := append(r.values(entity, r.writable()), r.values(entity, keys)...)
+
+	err := mapError(r.db.Conn(ctx).GetContext(ctx, entity, r.updateSQL, args...))
+	if !errors.Is(err, repository.ErrNotFound) || r.cfg.version == "" {
+		return err
+	}
+
+	// no row matched id and version, conflict when row still exists
+	ok, err := r.exists(ctx, r.db.Conn(ctx), r.values(entity, []string{r.cfg.id})[0])
+	if err != nil {
+		return err
+	}
+	if ok {
+		return repository.ErrConflict
+	}
+	return repository.ErrNotFound
+}
+
+func (r *Repository[T, ID]) Delete(ctx context.Context, id ID) error {
+	var q string
+	if r.cfg.softDelete != "" {
+		col := query.Ident(r.cfg.softDelete)
+		q = fmt.Sprintf(
+			"UPDATE %s SET %s = now() WHERE %s = $1 AND %s IS NULL",
+			query.Ident(r.table), col, query.Ident(r.cfg.id), col,
+		)
+	} else {
+		q = fmt.Sprintf(
+			"DELETE FROM %s WHERE %s = $1",
+			query.Ident(r.table), query.Ident(r.cfg.id),
+		)
+	}
+
+	res, err := r.db.Conn(ctx).ExecContext(ctx, q, id)
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// values returns entity field values of columns
+func (r *Repository[T, ID]) values(entity *T, columns []string) []any {
+	v := reflect.ValueOf(entity).Elem()
+	args := make([]any, len(columns))
+	for i, col := range columns {
+		args[i] = v.FieldByIndex(r.fields[col]).Interface()
+	}
+	return args
+}
@@ -0,0 +1,188 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/pagination"
+	"github.com/lomifile/api/pkg/postgres"
+	"github.com/lomifile/api/pkg/query"
+)
+
+type timestamps struct {
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type note struct {
+	ID        int64      `db:"id"`
+	Title     string     `db:"title"`
+	Body      string     `db:"body"`
+	Version   int        `db:"version"`
+	DeletedAt *time.Time `db:"deleted_at"`
+	Draft     bool       `db:"-"`
+	timestamps
+}
+
+func TestNewRepository_SQL(t *testing.T) {
+	r := NewRepository[note, int64](
+		nil,
+		"notes",
+		WithSoftDelete("deleted_at"),
+		WithVersion("version"),
+	)
+
+	cols := `"id", "title", "body", "version", "deleted_at", "created_at", "updated_at"`
+	tests := map[string]struct{ got, want string }{
+		"select": {r.selectSQL, `SELECT ` + cols + ` FROM "notes"`},
+		"insert": {
+			r.insertSQL,
+			`INSERT INTO "notes" ("title", "body", "version") VALUES ($1, $2, 1) RETURNING ` + cols,
+		},
+		"update": {
+			r.updateSQL,
+			`UPDATE "notes" SET "title" = $1, "body" = $2, "updated_at" = now(), ` +
+				`"version" = "version" + 1 WHERE "id" = $3 AND "version" = $4 ` +
+				`AND "deleted_at" IS NULL RETURNING ` + cols,
+		},
+	}
+	for name, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s SQL =\n%s\nwant\n%s", name, tt.got, tt.want)
+		}
+	}
+
+	n := note{ID: 3, Title: "t", Body: "b", Version: 2}
+	got := r.values(&n, []string{"title", "id", "version"})
+	if fmt.Sprint(got) != "[t 3 2]" {
+		t.Errorf("values() = %v", got)
+	}
+}
+
+func TestNewRepository_Scope(t *testing.T) {
+	r := NewRepository[note, int64](nil, "notes", WithSoftDelete("deleted_at"))
+
+	b := query.NewBuilder(1)
+	r.where(b, query.Eq("title", "x"))
+	if b.String() != ` WHERE ("title" = $1 AND "deleted_at" IS NULL)` {
+		t.Errorf("where() = %q", b.String())
+	}
+
+	b = query.NewBuilder(1)
+	r.WithDeleted().where(b)
+	if b.String() != "" {
+		t.Errorf("WithDeleted().where() = %q, want no filter", b.String())
+	}
+}
+
+func TestNewRepository_MissingColumn(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewRepository() should panic when version column isn't mapped")
+		}
+	}()
+	NewRepository[note, int64](nil, "notes", WithVersion("revision"))
+}
+
+func TestReadError(t *testing.T) {
+	replica := &Node{name: "replica-1"}
+	cause := errors.New("connection reset")
+
+	err := readError(replica, cause)
+	if !errors.Is(err, cause) || err.Error() != "query on replica-1: connection reset" {
+		t.Errorf("readError() = %v, want cause with node name", err)
+	}
+	if err := readError(replica, sql.ErrNoRows); err != repository.ErrNotFound {
+		t.Errorf("readError() of no rows = %v, want ErrNotFound", err)
+	}
+}
+
+// TestRepository runs against real database when TEST_DATABASE_URL is set
+func TestRepository(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pg, err := postgres.New(ctx, dsn, logger.New(logger.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	db, err := NewPostgresAdapter(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := fmt.Sprintf("notes_test_%d", os.Getpid())
+	_, err = db.ExecContext(ctx, `CREATE TABLE `+table+` (
+		id BIGSERIAL PRIMARY KEY,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		version INT NOT NULL,
+		deleted_at TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = db.ExecContext(ctx, `DROP TABLE `+table) }()
+
+	r := NewRepository[note, int64](
+		db,
+		table,
+		WithSoftDelete("deleted_at"),
+		WithVersion("version"),
+	)
+
+	for _, title := range []string{"a", "b", "c"} {
+		n := note{Title: title, Body: "body"}
+		if err := r.Create(ctx, &n); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if n.ID == 0 || n.Version != 1 || n.CreatedAt.IsZero() {
+			t.Fatalf("Create() didn't fill generated columns: %+v", n)
+		}
+	}
+
+	n, err := r.Get(ctx, 1)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	stale := *n
+	n.Body = "edited"
+	if err := r.Update(ctx, n); err != nil || n.Version != 2 {
+		t.Fatalf("Update() = %v, version %d", err, n.Version)
+	}
+	if err := r.Update(ctx, &stale); !errors.Is(err, repository.ErrConflict) {
+		t.Errorf("Update() of stale entity = %v, want ErrConflict", err)
+	}
+
+	if err := r.Delete(ctx, 2); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := r.Get(ctx, 2); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Get() of deleted = %v, want ErrNotFound", err)
+	}
+	if ok, _ := r.WithDeleted().Exists(ctx, 2); !ok {
+		t.Error("WithDeleted().Exists() should see deleted row")
+	}
+
+	page := pagination.Params{Page: 1, Limit: 1, Order: "ASC"}
+	rows, err := r.List(ctx, query.In[int64]("id", 1, 2, 3), &page)
+	if err != nil || len(rows) != 2 || rows[0].Title != "a" {
+		t.Errorf("List() = %+v, %v, want a and c with extra row", rows, err)
+	}
+	if n, err := r.Count(ctx, nil); err != nil || n != 2 {
+		t.Errorf("Count() = %d, %v, want 2", n, err)
+	}
+}
@@ -0,0 +1,224 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/pkg/retry"
+)
+
+const (
+	_pgSerializationFailure = "40001"
+	_pgDeadlockDetected     = "40P01"
+
+	_defaultTxRetries    = 3
+	_defaultTxBackoff    = 10 * time.Millisecond
+	_defaultTxMaxBackoff = time.Second
+)
+
+// Querier Query methods shared by *sqlx.DB and *sqlx.Tx
+type Querier interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+}
+
+type txKey struct{}
+
+// Tx Transaction and server it runs on
+type Tx struct {
+	*sqlx.Tx
+	name string
+}
+
+// Node returns server name, primary or replica-N
+func (t *Tx) Node() string {
+	return t.name
+}
+
+// txState transaction joined by nested calls, savepoints counts names in use
+type txState struct {
+	tx         *Tx
+	savepoints int
+}
+
+// Conn returns transaction started by TxManager.Do for ctx, or primary when there is none.
+// Repositories must use it for every query to join running transaction.
+func (a *PostgresAdapter) Conn(ctx context.Context) Querier {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return s.tx
+	}
+	return a.writer()
+}
+
+// InTx reports whether ctx carries transaction
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*txState)
+	return ok
+}
+
+// TxOption Configures TxManager
+type TxOption func(*TxManager)
+
+// TxIsolation sets isolation level of started transactions, default is database default
+func TxIsolation(level sql.IsolationLevel) TxOption {
+	return func(m *TxManager) {
+		m.isolation = level
+	}
+}
+
+// TxReadOnly starts read only transactions, they run on replica when one is healthy
+func TxReadOnly() TxOption {
+	return func(m *TxManager) {
+		m.readOnly = true
+	}
+}
+
+// TxRetries sets how many times transaction is retried on serialization failure or deadlock
+func TxRetries(n int) TxOption {
+	return func(m *TxManager) {
+		m.retries = n
+	}
+}
+
+// TxBackoff sets first retry delay and its cap, delay doubles with every attempt
+func TxBackoff(base, maxDelay time.Duration) TxOption {
+	return func(m *TxManager) {
+		m.backoff = base
+		m.maxBackoff = maxDelay
+	}
+}
+
+// TxManager Runs functions in transaction stored in context
+type TxManager struct {
+	db         *PostgresAdapter
+	isolation  sql.IsolationLevel
+	readOnly   bool
+	retries    int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+var _ repository.Transactor = (*TxManager)(nil)
+
+func NewTxManager(db *PostgresAdapter, opts ...TxOption) *TxManager {
+	m := &TxManager{
+		db:         db,
+		retries:    _defaultTxRetries,
+		backoff:    _defaultTxBackoff,
+		maxBackoff: _defaultTxMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// WithOptions returns copy of manager with options applied
+func (m *TxManager) WithOptions(opts ...TxOption) *TxManager {
+	c := *m
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// Do runs fn in transaction, commits when fn returns nil and rolls back otherwise.
+// Called inside running transaction it uses savepoint, so only fn's changes are rolled back.
+// Whole transaction is retried on serialization failure or deadlock, fn must be safe to rerun.
+func (m *TxManager) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.savepoint(ctx, s, fn)
+	}
+
+	return retry.Do(
+		ctx,
+		func(ctx context.Context) error { return m.run(ctx, fn) },
+		retry.WithMaxAttempts(m.retries+1),
+		retry.WithInitial(m.backoff),
+		retry.WithMax(m.maxBackoff),
+		retry.WithMaxElapsed(0),
+		retry.WithRetryIf(Retryable),
+	)
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	node := m.db.writer()
+	if m.readOnly {
+		node = m.db.reader(ctx)
+	}
+	opts := &sql.TxOptions{Isolation: m.isolation, ReadOnly: m.readOnly}
+	sqlTx, err := node.BeginTxx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("begin transaction on %s: %w", node.name, err)
+	}
+	tx := &Tx{Tx: sqlTx, name: node.name}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (m *TxManager) savepoint(
+	ctx context.Context,
+	s *txState,
+	fn func(ctx context.Context) error,
+) (err error) {
+	s.savepoints++
+	name := fmt.Sprintf("sp_%d", s.savepoints)
+	defer func() { s.savepoints-- }()
+
+	if _, err := s.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		if _, rbErr := s.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint: %w", rbErr))
+		}
+		return err
+	}
+
+	if _, err := s.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("release savepoint: %w", err)
+	}
+	return nil
+}
+
+// Retryable reports whether err is serialization failure or deadlock, transaction that
+// failed with them can succeed when run again
+func Retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == _pgSerializationFailure || pgErr.Code == _pgDeadlockDetected
+}
@@ -0,0 +1,128 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/postgres"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&pgconn.PgError{Code: "40001"}, true},
+		{fmt.Errorf("commit: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{&pgconn.PgError{Code: "23505"}, false},
+		{errors.New("boom"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestConn(t *testing.T) {
+	primary := &Node{DB: &sqlx.DB{}, name: "primary"}
+	db := &PostgresAdapter{DB: primary.DB, primary: primary}
+	ctx := context.Background()
+	if db.Conn(ctx) != primary || db.Reader(ctx) != primary || InTx(ctx) {
+		t.Error("Conn() and Reader() without transaction should return primary")
+	}
+
+	tx := &Tx{Tx: &sqlx.Tx{}, name: "replica-1"}
+	ctx = context.WithValue(ctx, txKey{}, &txState{tx: tx})
+	if db.Conn(ctx) != tx || db.Reader(ctx) != tx || !InTx(ctx) {
+		t.Error("Conn() and Reader() should return transaction from context")
+	}
+	if NodeName(db.Conn(ctx)) != "replica-1" {
+		t.Errorf("NodeName() = %q, want replica-1", NodeName(db.Conn(ctx)))
+	}
+}
+
+// TestTxManager runs against real database when TEST_DATABASE_URL is set
+func TestTxManager(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pg, err := postgres.New(ctx, dsn, logger.New(logger.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pg.Close()
+	db, err := NewPostgresAdapter(pg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table := fmt.Sprintf("tx_test_%d", os.Getpid())
+	if _, err := db.ExecContext(ctx, `CREATE TABLE `+table+` (v INT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _, _ = db.ExecContext(ctx, `DROP TABLE `+table) }()
+
+	insert := func(ctx context.Context, v int) error {
+		_, err := db.Conn(ctx).ExecContext(ctx, `INSERT INTO `+table+` (v) VALUES ($1)`, v)
+		return err
+	}
+	errInner := errors.New("inner")
+	tm := NewTxManager(db, TxBackoff(time.Millisecond, 5*time.Millisecond))
+
+	err = tm.Do(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, 1); err != nil {
+			return err
+		}
+		// nested failure rolls back only to its savepoint
+		err := tm.Do(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, 2); err != nil {
+				return err
+			}
+			return errInner
+		})
+		if !errors.Is(err, errInner) {
+			return fmt.Errorf("nested Do() = %v, want errInner", err)
+		}
+		return tm.Do(ctx, func(ctx context.Context) error { return insert(ctx, 3) })
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	_ = tm.Do(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, 4); err != nil {
+			return err
+		}
+		return errInner
+	})
+
+	var got []int
+	if err := db.SelectContext(ctx, &got, `SELECT v FROM `+table+` ORDER BY v`); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != "[1 3]" {
+		t.Errorf("rows = %v, want [1 3]", got)
+	}
+
+	attempts := 0
+	err = tm.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		return &pgconn.PgError{Code: _pgSerializationFailure}
+	})
+	if !Retryable(err) || attempts != _defaultTxRetries+1 {
+		t.Errorf("Do() = %v after %d attempts, want retryable after %d", err, attempts,
+			_defaultTxRetries+1)
+	}
+}
@@ -0,0 +1,160 @@
+package adapter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lomifile/api/internal/domain/model"
+	"github.com/lomifile/api/internal/domain/repository"
+)
+
+const _pgUniqueViolation = "23505"
+
+// UserRepository Postgres implementation of repository.UserRepository
+type UserRepository struct {
+	db *PostgresAdapter
+}
+
+var _ repository.UserRepository = (*UserRepository)(nil)
+
+// NewUserRepository creates user repository on top of postgres adapter
+func NewUserRepository(db *PostgresAdapter) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+func (r *UserRepository) Create(ctx context.Context, u *model.User) error {
+	const q = `
+		INSERT INTO users (email, name, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.Conn(ctx).QueryRowxContext(ctx, q, u.Email, u.Name, u.PasswordHash).
+		Scan(&u.ID, &u.CreatedAt, &u.UpdatedAt)
+
+	return mapError(err)
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	const q = `
+		SELECT id, email, name, password_hash, email_verified_at, created_at, updated_at
+		FROM users
+		WHERE id = $1`
+
+	var u model.User
+	if err := r.db.Conn(ctx).GetContext(ctx, &u, q, id); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &u, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	const q = `
+		SELECT id, email, name, password_hash, email_verified_at, created_at, updated_at
+		FROM users
+		WHERE lower(email) = lower($1)`
+
+	var u model.User
+	if err := r.db.Conn(ctx).GetContext(ctx, &u, q, email); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &u, nil
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id int64) error {
+	const q = `
+		UPDATE users
+		SET email_verified_at = COALESCE(email_verified_at, now()), updated_at = now()
+		WHERE id = $1`
+
+	return r.execOne(ctx, q, id)
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, id int64, hash string) error {
+	const q = `UPDATE users SET password_hash = $2, updated_at = now() WHERE id = $1`
+
+	return r.execOne(ctx, q, id, hash)
+}
+
+func (r *UserRepository) CreateToken(ctx context.Context, t *model.UserToken) error {
+	const q = `
+		INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err := r.db.Conn(ctx).QueryRowxContext(ctx, q, t.UserID, t.Purpose, t.TokenHash, t.ExpiresAt).
+		Scan(&t.ID, &t.CreatedAt)
+
+	return mapError(err)
+}
+
+func (r *UserRepository) ConsumeToken(
+	ctx context.Context,
+	purpose model.TokenPurpose,
+	tokenHash string,
+) (*model.UserToken, error) {
+	const q = `
+		UPDATE user_tokens
+		SET used_at = now()
+		WHERE purpose = $1
+			AND token_hash = $2
+			AND used_at IS NULL
+			AND expires_at > now()
+		RETURNING id, user_id, purpose, token_hash, expires_at, used_at, created_at`
+
+	var t model.UserToken
+	if err := r.db.Conn(ctx).GetContext(ctx, &t, q, purpose, tokenHash); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &t, nil
+}
+
+// DeleteExpiredTokens deletes tokens that expired or were used before t, returns their count
+func (r *UserRepository) DeleteExpiredTokens(ctx context.Context, before time.Time) (int64, error) {
+	const q = `DELETE FROM user_tokens WHERE expires_at < $1 OR used_at < $1`
+
+	res, err := r.db.Conn(ctx).ExecContext(ctx, q, before)
+	if err != nil {
+		return 0, mapError(err)
+	}
+
+	return res.RowsAffected()
+}
+
+func (r *UserRepository) execOne(ctx context.Context, q string, args ...any) error {
+	res, err := r.db.Conn(ctx).ExecContext(ctx, q, args...)
+	if err != nil {
+		return mapError(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == _pgUniqueViolation {
+		return repository.ErrDuplicate
+	}
+
+	return err
+}
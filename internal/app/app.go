@@ -2,76 +2,288 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/encryptcookie"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lomifile/api/api/http/handler"
 	"github.com/lomifile/api/api/http/middleware"
 	"github.com/lomifile/api/api/http/router"
 	"github.com/lomifile/api/config"
 	"github.com/lomifile/api/internal/adapter"
+	"github.com/lomifile/api/internal/domain/service"
 	"github.com/lomifile/api/internal/server"
+	"github.com/lomifile/api/migrations"
+	"github.com/lomifile/api/pkg/email"
+	"github.com/lomifile/api/pkg/health"
+	"github.com/lomifile/api/pkg/jobs"
 	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/metrics"
+	"github.com/lomifile/api/pkg/migrate"
 	"github.com/lomifile/api/pkg/postgres"
+	"github.com/lomifile/api/pkg/ratelimit"
+	"github.com/lomifile/api/pkg/scheduler"
+	"github.com/lomifile/api/pkg/token"
+	"github.com/lomifile/api/pkg/tracing"
 	"go.uber.org/zap"
 )
 
-func Start(c *config.Config) {
+func Start(c *config.Config, loader *config.Loader) {
 	l := logger.New(logger.Config{
-		Env:   c.Environment,
-		Debug: true,
+		Service:  "api",
+		Env:      c.Environment,
+		Debug:    c.Environment == "development",
+		Level:    c.Level(),
+		Encoding: c.Log.Encoding,
+		Output:   c.Log.Output,
+		Rotation: logger.Rotation{
+			MaxSize:    c.Log.MaxSize,
+			MaxAge:     c.Log.MaxAge,
+			MaxBackups: c.Log.MaxBackups,
+			Compress:   c.Log.Compress,
+		},
+		Sampling: logger.Sampling{
+			Initial:    c.Log.SampleInitial,
+			Thereafter: c.Log.SampleThereafter,
+		},
 	})
+	logger.SetDefault(l)
 	defer func() {
 		if err := l.Sync(); err != nil {
 			panic(err)
 		}
 	}()
 
-	p, err := postgres.New(c.Database.Dsn)
+	tp, err := tracing.New(context.Background(), tracing.Config{
+		ServiceName: "api",
+		Environment: c.Environment,
+		Exporter:    tracing.Exporter(c.Tracing.Exporter),
+		Endpoint:    c.Tracing.Endpoint,
+		Insecure:    c.Tracing.Insecure,
+		SampleRatio: c.Tracing.SampleRatio,
+	})
+	if err != nil {
+		l.Error("Tracing error", zap.String("err", err.Error()))
+		panic(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tp.Shutdown(ctx); err != nil {
+			l.Error("Tracing shutdown error", zap.String("err", err.Error()))
+		}
+	}()
+
+	pgOpts, err := poolOptions(c.Database)
+	if err != nil {
+		l.Error("Postgres config error", zap.String("err", err.Error()))
+		panic(err)
+	}
+	// interrupt stops connect retries instead of waiting for attempts to run out
+	connectCtx, stopConnect := signal.NotifyContext(
+		context.Background(),
+		os.Interrupt,
+		syscall.SIGTERM,
+	)
+	p, err := postgres.New(connectCtx, c.Database.Dsn, l, pgOpts...)
+	stopConnect()
 	if err != nil {
 		l.Error("Postgres connection error", zap.String("err", err.Error()))
 		panic(err)
 	}
 
 	defer p.Close()
-	l.Info("Postgres connected successfully")
+	l.Info("Postgres connected successfully", zap.Int("replicas", len(p.Replicas)))
 
-	db, err := adapter.NewPostgresAdapter(p)
+	if c.Database.AutoMigrate {
+		n, err := migrate.New(p.Pool, migrations.FS, l).Up(context.Background())
+		if err != nil {
+			l.Error("Migration error", zap.String("err", err.Error()))
+			panic(err)
+		}
+		l.Info(fmt.Sprintf("Applied %d migration(s)", n))
+	}
+
+	// validated by config, database/sql pool mirrors pgxpool size
+	idleTime, _ := time.ParseDuration(c.Database.MaxIdleTime)
+	db, err := adapter.NewPostgresAdapter(p, adapter.WithSQLPool(
+		c.Database.MaxOpenConns,
+		c.Database.MaxIdleConns,
+		idleTime,
+	))
 	if err != nil {
 		l.Error("Postgres adapter error", zap.String("err", err.Error()))
 		panic(err)
 	}
 
-	s := server.New(server.Port(c.Port))
-	s.App.Use(middleware.LoggerMiddleware(l))
+	// jobs are enqueued through db.Conn so enqueue in request transaction commits with it
+	conn := func(ctx context.Context) sqlx.ExtContext { return db.Conn(ctx) }
+	jobStore := jobs.NewPostgresStore(db.DB, jobs.WithConn(conn))
+	worker := jobs.NewWorker(
+		jobStore,
+		l,
+		jobs.WithConcurrency(c.Jobs.Concurrency),
+		jobs.WithPollInterval(c.Jobs.PollInterval),
+		jobs.WithLockTimeout(c.Jobs.LockTimeout),
+	)
+
+	tm, err := token.New(
+		c.SecretKey,
+		token.WithIssuer(c.Auth.Issuer),
+		token.WithAudience(splitList(c.Auth.Audience)...),
+		token.WithAccessTTL(c.Auth.AccessTTL),
+		token.WithRefreshTTL(c.Auth.RefreshTTL),
+	)
+	if err != nil {
+		l.Error("Token manager error", zap.String("err", err.Error()))
+		panic(err)
+	}
+
+	m := metrics.New()
+	m.RegisterPool("primary", p.Pool)
+	for _, n := range p.Replicas {
+		m.RegisterPool(n.Name, n.Pool)
+	}
+
+	hr := health.New()
+	hr.Register("postgres", health.PingCheck(p.Pool))
+	// reads fall back to primary, so replica failure only degrades readiness
+	for _, n := range p.Replicas {
+		hr.Register("postgres_"+n.Name, health.PingCheck(n.Pool), health.Optional())
+	}
+
+	reloader := config.NewReloader(loader, c, l)
+	reloader.Subscribe("logger", func(old, next *config.Config) (func(), error) {
+		if old.Level() == next.Level() {
+			return nil, nil
+		}
+		return func() { _ = l.SetLevel(next.Level()) }, nil
+	})
+
+	// unsubscribe links need public API URL, without one emails go out without them
+	suppressions := email.NewPostgresSuppressions(db.DB)
+	var unsubscriber *email.Unsubscriber
+	if u := c.Email.UnsubscribeURL; u != "" {
+		unsubscriber = email.NewUnsubscriber(c.SecretKey, u, suppressions)
+	}
+
+	// nil mailer logs verification and reset links instead of sending them
+	var mailer service.Mailer
+	if c.Email.Enabled() {
+		opts := []email.Option{email.WithMetrics(m), email.WithSuppressions(suppressions)}
+		if unsubscriber != nil {
+			opts = append(opts, email.WithUnsubscribe(unsubscriber))
+		}
+		emailClient, err := email.NewEmailClient(l, c, opts...)
+		if err != nil {
+			l.Error("Email client error", zap.String("err", err.Error()))
+			panic(err)
+		}
+		if c.Email.Transport == "smtp" {
+			hr.Register("smtp", emailClient.Check, health.Optional())
+		}
+		reloader.Subscribe("email", emailClient.Reconfigure)
+		email.RegisterSender(worker, emailClient)
+		mailer = email.NewQueue(jobs.NewClient(jobStore))
+	} else {
+		l.Warn("EMAIL_SERVER not set, emails are disabled")
+		reloader.Subscribe("email", email.ReconfigureDisabled)
+	}
+
+	var (
+		limits   ratelimit.Store = ratelimit.NewMemoryStore()
+		pgLimits *ratelimit.PostgresStore
+	)
+	if c.Limiter.Store == "postgres" {
+		pgLimits = ratelimit.NewPostgresStore(p.Pool)
+		limits = pgLimits
+	}
+
+	// only leader runs scheduled tasks, so replicas don't repeat cleanup
+	leader := scheduler.NewPostgresLeader(p.Pool, l)
+	sched := scheduler.New(l, scheduler.WithLeader(leader))
+	err = addTasks(sched, c, db, jobStore, pgLimits)
+	if err != nil {
+		l.Error("Scheduler error", zap.String("err", err.Error()))
+		panic(err)
+	}
+
+	limiterMiddleware := middleware.NewSwappable(newLimiter(c.Limiter, limits, tm, l))
+	reloader.Subscribe("limiter", func(old, next *config.Config) (func(), error) {
+		if old.Limiter == next.Limiter {
+			return nil, nil
+		}
+		h := newLimiter(next.Limiter, limits, tm, l)
+		return func() { limiterMiddleware.Swap(h) }, nil
+	})
+
+	corsMiddleware := middleware.NewSwappable(newCORS(c.CORS))
+	reloader.Subscribe("cors", func(old, next *config.Config) (func(), error) {
+		if old.CORS == next.CORS {
+			return nil, nil
+		}
+		h := newCORS(next.CORS)
+		return func() { corsMiddleware.Swap(h) }, nil
+	})
+
+	s := server.New(
+		server.Port(c.Port),
+		server.ShutdownHook(hr.Drain),
+		server.DrainDelay(c.DrainDelay),
+		server.TrustedProxies(c.ProxyHeader, splitList(c.TrustedProxies)),
+		server.ErrorHandler(handler.NewErrorHandler(l)),
+	)
+	// probes and scrapes are registered before middleware so they skip rate limiting and logs
+	router.NewHealthRouter(s.App, hr)
+	router.NewMetricsRouter(s.App, m)
+	s.App.Use(middleware.MetricsMiddleware(m))
+	s.App.Use(middleware.TracingMiddleware())
 	s.App.Use(requestid.New())
+	s.App.Use(middleware.LoggerMiddleware(l))
 	s.App.Use(recover.New())
 	s.App.Use(helmet.New())
-	s.App.Use(limiter.New(limiter.Config{
-		Max:        100,
-		Expiration: 1 * time.Minute,
-	}))
-
-	s.App.Use(cors.New(cors.Config{
-		AllowOrigins:     "http://localhost:5173",
-		AllowMethods:     "GET,POST,PUT,DELETE",
-		AllowHeaders:     "Authorization,Content-Type",
-		AllowCredentials: true,
-	}))
+	// CORS runs first so preflights skip limits and 429 responses keep CORS headers
+	s.App.Use(corsMiddleware.Handler())
+	s.App.Use(limiterMiddleware.Handler())
 
 	s.App.Use(encryptcookie.New(encryptcookie.Config{
 		Key: c.CookieKey,
 	}))
-	router.NewRouter(s.App, db, l, c)
+
+	err = router.NewRouter(s.App, db, l, c, tm, mailer, limits)
+	if err != nil {
+		l.Error("Router error", zap.String("err", err.Error()))
+		panic(err)
+	}
+	if unsubscriber != nil {
+		router.NewEmailRouter(s.App, unsubscriber)
+	}
+	if c.AdminToken != "" {
+		router.NewAdminRouter(s.App, l, c.AdminToken, suppressions)
+	}
 	s.Start()
+	worker.Start()
+	leader.Start()
+	sched.Start()
+
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	go func() {
+		if err := reloader.Run(reloadCtx); err != nil {
+			l.Error("Config reload error", zap.String("err", err.Error()))
+		}
+	}()
 
 	l.Info(fmt.Sprintf("app started on port %s", c.Port))
 	osSignal := make(chan os.Signal, 1)
@@ -89,4 +301,124 @@ func Start(c *config.Config) {
 	if err != nil {
 		l.Error("Shutdown defect: ", zap.String("", err.Error()))
 	}
+
+	// worker stops last and claims once more, so jobs enqueued by the last requests and tasks
+	// run when it has free slots, others wait in the table for next start
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), c.Jobs.DrainTimeout)
+	defer cancelDrain()
+	if err := sched.Stop(drainCtx); err != nil {
+		l.Error("Scheduler stop error", zap.String("err", err.Error()))
+	}
+	leader.Close()
+	if err := worker.Shutdown(drainCtx); err != nil {
+		l.Error("Jobs drain error", zap.String("err", err.Error()))
+	}
+}
+
+// newLimiter builds global rate limiter counting authenticated users by subject, others by IP
+func newLimiter(
+	o config.LimiterOptions,
+	store ratelimit.Store,
+	tm *token.Manager,
+	l *logger.Logger,
+) fiber.Handler {
+	if !o.Enabled {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	return middleware.RateLimitMiddleware(store, middleware.RateLimitPolicy{
+		Name:  "global",
+		Limit: ratelimit.Limit{Rate: o.RPS, Burst: o.Burst},
+		Key: middleware.RateLimitFirst(
+			middleware.RateLimitBySubject(tm),
+			middleware.RateLimitByIP,
+		),
+	}, l)
+}
+
+// addTasks registers periodic cleanup tasks, limits is nil for memory rate limit store
+func addTasks(
+	s *scheduler.Scheduler,
+	c *config.Config,
+	db *adapter.PostgresAdapter,
+	jobStore *jobs.PostgresStore,
+	limits *ratelimit.PostgresStore,
+) error {
+	users := adapter.NewUserRepository(db)
+	err := s.Add("tokens.purge", scheduler.MustParse("@hourly"), func(ctx context.Context) error {
+		// keep a day of used and expired tokens for support requests
+		_, err := users.DeleteExpiredTokens(ctx, time.Now().Add(-24*time.Hour))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.Add("jobs.purge", scheduler.MustParse("@hourly"), func(ctx context.Context) error {
+		_, err := jobStore.Purge(ctx, time.Now().Add(-c.Jobs.Retention))
+		return err
+	})
+	if err != nil || limits == nil {
+		return err
+	}
+
+	// idle buckets are equal to new full buckets
+	purgeBuckets := func(ctx context.Context) error {
+		_, err := limits.Purge(ctx, time.Hour)
+		return err
+	}
+	return s.Add("ratelimit.purge", scheduler.Every(10*time.Minute), purgeBuckets)
+}
+
+func newCORS(o config.CORSOptions) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins:     o.AllowOrigins,
+		AllowMethods:     "GET,POST,PUT,DELETE",
+		AllowHeaders:     "Authorization,Content-Type",
+		AllowCredentials: true,
+	})
+}
+
+// poolOptions maps database settings to postgres options
+func poolOptions(c config.DatabaseOptions) ([]postgres.Option, error) {
+	balancer, err := postgres.ParseBalancer(c.ReplicaBalancer)
+	if err != nil {
+		return nil, err
+	}
+	execMode, err := postgres.ParseQueryExecMode(c.QueryExecMode)
+	if err != nil {
+		return nil, err
+	}
+	idleTime, err := time.ParseDuration(c.MaxIdleTime)
+	if err != nil {
+		return nil, fmt.Errorf("max idle time: %w", err)
+	}
+
+	return []postgres.Option{
+		postgres.WithQueryTracer(tracing.QueryTracer{}),
+		postgres.WithMaxConns(int32(c.MaxOpenConns)),
+		postgres.WithMinConns(int32(c.MinConns)),
+		postgres.WithMinIdleConns(int32(c.MinIdleConns)),
+		postgres.WithMaxConnLifetime(c.MaxConnLifetime, c.LifetimeJitter),
+		postgres.WithMaxConnIdleTime(idleTime),
+		postgres.WithHealthCheckPeriod(c.HealthCheckPeriod),
+		postgres.WithQueryExecMode(execMode),
+		postgres.WithConnTimeout(c.ConnectTimeout),
+		postgres.WithApplicationName(c.ApplicationName),
+		postgres.WithReplicas(splitList(c.ReplicaDsns)...),
+		postgres.WithBalancer(balancer),
+		postgres.WithMaxReplicaLag(c.MaxReplicaLag),
+	}, nil
+}
+
+// splitList splits comma separated setting and trims items, empty setting gives nil
+func splitList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	items := strings.Split(v, ",")
+	for i, item := range items {
+		items[i] = strings.TrimSpace(item)
+	}
+	return items
 }
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// User Registered account
+type User struct {
+	ID              int64      `db:"id"                json:"id"`
+	Email           string     `db:"email"             json:"email"`
+	Name            string     `db:"name"              json:"name"`
+	PasswordHash    string     `db:"password_hash"     json:"-"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at"`
+	CreatedAt       time.Time  `db:"created_at"        json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"        json:"updated_at"`
+}
+
+// IsVerified reports whether user confirmed email address
+func (u *User) IsVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// TokenPurpose What one time user token can be used for
+type TokenPurpose string
+
+const (
+	// TokenEmailVerification token sent after signup
+	TokenEmailVerification TokenPurpose = "email_verification"
+	// TokenPasswordReset token sent on forgotten password
+	TokenPasswordReset TokenPurpose = "password_reset"
+)
+
+// UserToken One time token, only sha256 hash of the token is stored
+type UserToken struct {
+	ID        int64        `db:"id"`
+	UserID    int64        `db:"user_id"`
+	Purpose   TokenPurpose `db:"purpose"`
+	TokenHash string       `db:"token_hash"`
+	ExpiresAt time.Time    `db:"expires_at"`
+	UsedAt    *time.Time   `db:"used_at"`
+	CreatedAt time.Time    `db:"created_at"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUser_IsVerified(t *testing.T) {
+	u := User{}
+	if u.IsVerified() {
+		t.Error("IsVerified() = true, want false")
+	}
+
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	if !u.IsVerified() {
+		t.Error("IsVerified() = false, want true")
+	}
+}
+
+func TestUser_JSONHidesPasswordHash(t *testing.T) {
+	u := User{ID: 1, Email: "a@example.com", PasswordHash: "$argon2id$secret"}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		t.Fatalf("Failed to marshal User: %v", err)
+	}
+	if strings.Contains(string(data), "argon2id") {
+		t.Errorf("User JSON leaks password hash: %s", data)
+	}
+}
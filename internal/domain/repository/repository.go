@@ -1,2 +1,38 @@
 // Package repository contains data access interfaces
 package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lomifile/api/pkg/pagination"
+	"github.com/lomifile/api/pkg/query"
+)
+
+var (
+	// ErrNotFound returned when requested row doesn't exist
+	ErrNotFound = errors.New("repository: not found")
+	// ErrDuplicate returned when unique constraint is violated
+	ErrDuplicate = errors.New("repository: duplicate")
+	// ErrConflict returned when row was changed since it was read
+	ErrConflict = errors.New("repository: version conflict")
+)
+
+// Repository Basic persistence of entity T identified by ID
+type Repository[T any, ID comparable] interface {
+	Get(ctx context.Context, id ID) (*T, error)
+	// List returns rows matching where, page may be nil to return every row
+	List(ctx context.Context, where query.Cond, page *pagination.Params) ([]T, error)
+	Count(ctx context.Context, where query.Cond) (int, error)
+	Exists(ctx context.Context, id ID) (bool, error)
+	// Create inserts entity and fills generated columns
+	Create(ctx context.Context, entity *T) error
+	// Update saves entity, returns ErrConflict when versioned row was changed meanwhile
+	Update(ctx context.Context, entity *T) error
+	Delete(ctx context.Context, id ID) error
+}
+
+// Transactor Runs fn atomically, repositories called with fn's ctx join the transaction
+type Transactor interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/lomifile/api/internal/domain/model"
+)
+
+// UserRepository Persists users and their one time tokens
+type UserRepository interface {
+	// Create inserts user and fills ID and timestamps
+	Create(ctx context.Context, u *model.User) error
+	GetByID(ctx context.Context, id int64) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	MarkEmailVerified(ctx context.Context, id int64) error
+	UpdatePassword(ctx context.Context, id int64, hash string) error
+
+	// CreateToken inserts one time token and fills ID and CreatedAt
+	CreateToken(ctx context.Context, t *model.UserToken) error
+	// ConsumeToken marks unused, unexpired token as used and returns it
+	ConsumeToken(
+		ctx context.Context,
+		purpose model.TokenPurpose,
+		tokenHash string,
+	) (*model.UserToken, error)
+}
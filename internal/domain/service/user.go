@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lomifile/api/internal/domain/model"
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/pkg/email"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/password"
+	"github.com/lomifile/api/pkg/token"
+	"github.com/lomifile/api/pkg/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultVerificationTTL = 24 * time.Hour
+	_defaultResetTTL        = time.Hour
+	_minPasswordLength      = 8
+	_tokenBytes             = 32
+)
+
+var (
+	// ErrInvalidEmail returned when email address can't be parsed
+	ErrInvalidEmail = errors.New("service: invalid email")
+	// ErrWeakPassword returned when password is shorter than 8 characters
+	ErrWeakPassword = errors.New("service: password must be at least 8 characters")
+	// ErrEmailTaken returned when account with the email already exists
+	ErrEmailTaken = errors.New("service: email already registered")
+	// ErrInvalidCredentials returned when email or password is wrong
+	ErrInvalidCredentials = errors.New("service: invalid credentials")
+	// ErrEmailNotVerified returned on login before email is verified
+	ErrEmailNotVerified = errors.New("service: email not verified")
+	// ErrInvalidToken returned when one time token is unknown, used or expired
+	ErrInvalidToken = errors.New("service: invalid or expired token")
+)
+
+// Mailer Sends HTML emails, implemented by email.Client
+type Mailer interface {
+	SendHTMLEmailContext(ctx context.Context, cfg *email.SendEmailConfig) error
+}
+
+// UserServiceConfig User service settings
+type UserServiceConfig struct {
+	// AppURL base URL used to build links in emails
+	AppURL          string
+	VerificationTTL time.Duration
+	ResetTTL        time.Duration
+	// Locale of emails, default is templates' default locale
+	Locale string
+	// Templates renders emails, default uses embedded templates
+	Templates *email.Renderer
+}
+
+// UserService Signup, login, email verification and password reset
+type UserService struct {
+	repo   repository.UserRepository
+	mailer Mailer
+	tm     *token.Manager
+	l      *logger.Logger
+	cfg    UserServiceConfig
+
+	// dummyHash is verified when user doesn't exist so login timing doesn't leak accounts
+	dummyHash string
+}
+
+// NewUserService creates user service. Mailer may be nil, links are logged instead
+func NewUserService(
+	repo repository.UserRepository,
+	mailer Mailer,
+	tm *token.Manager,
+	l *logger.Logger,
+	cfg UserServiceConfig,
+) (*UserService, error) {
+	if cfg.VerificationTTL == 0 {
+		cfg.VerificationTTL = _defaultVerificationTTL
+	}
+	if cfg.ResetTTL == 0 {
+		cfg.ResetTTL = _defaultResetTTL
+	}
+	if cfg.Templates == nil {
+		templates, err := email.NewRenderer()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Templates = templates
+	}
+
+	dummy, err := password.Hash(utils.RandomString(16))
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserService{
+		repo:      repo,
+		mailer:    mailer,
+		tm:        tm,
+		l:         l.Named("user_service"),
+		cfg:       cfg,
+		dummyHash: dummy,
+	}, nil
+}
+
+// Register creates unverified account and sends verification email
+func (s *UserService) Register(
+	ctx context.Context,
+	emailAddr, name, plain string,
+) (*model.User, error) {
+	addr, err := normalizeEmail(emailAddr)
+	if err != nil {
+		return nil, err
+	}
+	if len(plain) < _minPasswordLength {
+		return nil, ErrWeakPassword
+	}
+
+	hash, err := password.Hash(plain)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &model.User{Email: addr, Name: strings.TrimSpace(name), PasswordHash: hash}
+	if err := s.repo.Create(ctx, u); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+
+	if err := s.sendVerification(ctx, u); err != nil {
+		s.l.Ctx(ctx).Error("verification email failed", zap.Int64("user_id", u.ID), zap.Error(err))
+	}
+
+	return u, nil
+}
+
+// Login checks credentials and issues token pair
+func (s *UserService) Login(
+	ctx context.Context,
+	emailAddr, plain string,
+) (*model.User, *token.Pair, error) {
+	u, err := s.repo.GetByEmail(ctx, strings.TrimSpace(emailAddr))
+	if errors.Is(err, repository.ErrNotFound) {
+		_ = password.Verify(plain, s.dummyHash)
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := password.Verify(plain, u.PasswordHash); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if !u.IsVerified() {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	if password.NeedsRehash(u.PasswordHash) {
+		s.rehash(ctx, u.ID, plain)
+	}
+
+	pair, err := s.tm.IssuePair(strconv.FormatInt(u.ID, 10))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u, pair, nil
+}
+
+// VerifyEmail consumes verification token and marks email as verified
+func (s *UserService) VerifyEmail(ctx context.Context, rawToken string) error {
+	t, err := s.consume(ctx, model.TokenEmailVerification, rawToken)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MarkEmailVerified(ctx, t.UserID)
+}
+
+// ResendVerification sends new verification email to unverified account.
+// Unknown and already verified addresses are ignored so accounts can't be enumerated
+func (s *UserService) ResendVerification(ctx context.Context, emailAddr string) error {
+	u, err := s.repo.GetByEmail(ctx, strings.TrimSpace(emailAddr))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if u.IsVerified() {
+		return nil
+	}
+
+	return s.sendVerification(ctx, u)
+}
+
+// RequestPasswordReset sends password reset email.
+// Unknown addresses are ignored so accounts can't be enumerated
+func (s *UserService) RequestPasswordReset(ctx context.Context, emailAddr string) error {
+	u, err := s.repo.GetByEmail(ctx, strings.TrimSpace(emailAddr))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raw, err := s.issueToken(ctx, u.ID, model.TokenPasswordReset, s.cfg.ResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, u, email.ResetPasswordData{
+		Name:      u.Name,
+		Link:      s.link("/reset-password", raw),
+		ExpiresIn: s.cfg.ResetTTL,
+	})
+}
+
+// ResetPassword consumes reset token and sets new password
+func (s *UserService) ResetPassword(ctx context.Context, rawToken, plain string) error {
+	if len(plain) < _minPasswordLength {
+		return ErrWeakPassword
+	}
+
+	t, err := s.consume(ctx, model.TokenPasswordReset, rawToken)
+	if err != nil {
+		return err
+	}
+
+	hash, err := password.Hash(plain)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.UpdatePassword(ctx, t.UserID, hash)
+}
+
+// GetByID returns user by id
+func (s *UserService) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *UserService) sendVerification(ctx context.Context, u *model.User) error {
+	raw, err := s.issueToken(ctx, u.ID, model.TokenEmailVerification, s.cfg.VerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.send(ctx, u, email.VerifyEmailData{
+		Name: u.Name,
+		Link: s.link("/verify-email", raw),
+	})
+}
+
+func (s *UserService) issueToken(
+	ctx context.Context,
+	userID int64,
+	purpose model.TokenPurpose,
+	ttl time.Duration,
+) (string, error) {
+	raw, err := utils.RandToken(_tokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateToken(ctx, &model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(raw),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return raw, nil
+}
+
+func (s *UserService) consume(
+	ctx context.Context,
+	purpose model.TokenPurpose,
+	raw string,
+) (*model.UserToken, error) {
+	if raw == "" {
+		return nil, ErrInvalidToken
+	}
+
+	t, err := s.repo.ConsumeToken(ctx, purpose, hashToken(raw))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+
+	return t, err
+}
+
+func (s *UserService) rehash(ctx context.Context, id int64, plain string) {
+	hash, err := password.Hash(plain)
+	if err == nil {
+		err = s.repo.UpdatePassword(ctx, id, hash)
+	}
+	if err != nil {
+		s.l.Ctx(ctx).Warn("password rehash failed", zap.Int64("user_id", id), zap.Error(err))
+	}
+}
+
+func (s *UserService) link(path, raw string) string {
+	return strings.TrimRight(s.cfg.AppURL, "/") + path + "?token=" + url.QueryEscape(raw)
+}
+
+func (s *UserService) send(ctx context.Context, u *model.User, data email.TemplateData) error {
+	cfg, err := s.cfg.Templates.Render(s.cfg.Locale, data)
+	if err != nil {
+		return err
+	}
+	cfg.To = []string{u.Email}
+
+	if s.mailer == nil {
+		s.l.Ctx(ctx).Warn(
+			"email disabled, not sending",
+			zap.String("subject", cfg.Subject),
+			zap.Int64("user_id", u.ID),
+			zap.String("text", cfg.AlternativeString),
+		)
+		return nil
+	}
+
+	return s.mailer.SendHTMLEmailContext(ctx, cfg)
+}
+
+func normalizeEmail(addr string) (string, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(addr))
+	if err != nil || parsed.Name != "" {
+		return "", ErrInvalidEmail
+	}
+
+	return strings.ToLower(parsed.Address), nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lomifile/api/internal/domain/model"
+	"github.com/lomifile/api/internal/domain/repository"
+	"github.com/lomifile/api/pkg/email"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/token"
+)
+
+type memUserRepo struct {
+	mu     sync.Mutex
+	users  map[int64]*model.User
+	tokens []*model.UserToken
+	nextID int64
+}
+
+func newMemUserRepo() *memUserRepo {
+	return &memUserRepo{users: map[int64]*model.User{}}
+}
+
+func (r *memUserRepo) Create(_ context.Context, u *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, u.Email) {
+			return repository.ErrDuplicate
+		}
+	}
+	r.nextID++
+	u.ID = r.nextID
+	u.CreatedAt = time.Now()
+	u.UpdatedAt = u.CreatedAt
+	cp := *u
+	r.users[u.ID] = &cp
+
+	return nil
+}
+
+func (r *memUserRepo) GetByID(_ context.Context, id int64) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (r *memUserRepo) GetByEmail(_ context.Context, addr string) (*model.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, addr) {
+			cp := *u
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memUserRepo) MarkEmailVerified(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	now := time.Now()
+	u.EmailVerifiedAt = &now
+	return nil
+}
+
+func (r *memUserRepo) UpdatePassword(_ context.Context, id int64, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	u.PasswordHash = hash
+	return nil
+}
+
+func (r *memUserRepo) CreateToken(_ context.Context, t *model.UserToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t.ID = int64(len(r.tokens) + 1)
+	t.CreatedAt = time.Now()
+	cp := *t
+	r.tokens = append(r.tokens, &cp)
+	return nil
+}
+
+func (r *memUserRepo) ConsumeToken(
+	_ context.Context,
+	purpose model.TokenPurpose,
+	hash string,
+) (*model.UserToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.Purpose == purpose && t.TokenHash == hash && t.UsedAt == nil &&
+			t.ExpiresAt.After(time.Now()) {
+			now := time.Now()
+			t.UsedAt = &now
+			cp := *t
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+type memMailer struct {
+	sent []*email.SendEmailConfig
+}
+
+func (m *memMailer) SendHTMLEmailContext(_ context.Context, cfg *email.SendEmailConfig) error {
+	m.sent = append(m.sent, cfg)
+	return nil
+}
+
+var tokenRe = regexp.MustCompile(`token=([0-9a-f]+)`)
+
+func (m *memMailer) lastToken(t *testing.T) string {
+	t.Helper()
+
+	if len(m.sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	match := tokenRe.FindStringSubmatch(m.sent[len(m.sent)-1].AlternativeString)
+	if match == nil {
+		t.Fatal("email doesn't contain token link")
+	}
+	return match[1]
+}
+
+func newTestUserService(t *testing.T) (*UserService, *memUserRepo, *memMailer) {
+	t.Helper()
+
+	l := logger.New(logger.Config{Debug: true})
+	tm, err := token.New("0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("token.New() error = %v", err)
+	}
+
+	repo := newMemUserRepo()
+	mailer := &memMailer{}
+	s, err := NewUserService(repo, mailer, tm, l, UserServiceConfig{
+		AppURL: "https://app.example.com",
+	})
+	if err != nil {
+		t.Fatalf("NewUserService() error = %v", err)
+	}
+
+	return s, repo, mailer
+}
+
+func TestUserService_Register(t *testing.T) {
+	s, repo, mailer := newTestUserService(t)
+	ctx := context.Background()
+
+	u, err := s.Register(ctx, " Alice@Example.com ", "Alice", "password123")
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if u.Email != "alice@example.com" {
+		t.Errorf("Email = %v, want alice@example.com", u.Email)
+	}
+	if u.PasswordHash == "password123" || u.PasswordHash == "" {
+		t.Error("password should be hashed")
+	}
+	if len(repo.tokens) != 1 || repo.tokens[0].Purpose != model.TokenEmailVerification {
+		t.Errorf("expected one verification token, got %d", len(repo.tokens))
+	}
+	if len(mailer.sent) != 1 || !slices.Equal(mailer.sent[0].To, []string{"alice@example.com"}) {
+		t.Fatalf("expected verification email to alice@example.com")
+	}
+	if !strings.Contains(mailer.sent[0].HTML, "https://app.example.com/verify-email?token=") {
+		t.Errorf("HTML doesn't contain verification link: %s", mailer.sent[0].HTML)
+	}
+	if repo.tokens[0].TokenHash == mailer.lastToken(t) {
+		t.Error("raw token should not be stored")
+	}
+}
+
+func TestUserService_Register_Errors(t *testing.T) {
+	s, _, _ := newTestUserService(t)
+	ctx := context.Background()
+
+	if _, err := s.Register(ctx, "bob@example.com", "Bob", "password123"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		want     error
+	}{
+		{"invalid email", "not-an-email", "password123", ErrInvalidEmail},
+		{"display name", "Bob <bob@example.com>", "password123", ErrInvalidEmail},
+		{"weak password", "carol@example.com", "short", ErrWeakPassword},
+		{"duplicate", "BOB@example.com", "password123", ErrEmailTaken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Register(ctx, tt.email, "", tt.password); !errors.Is(err, tt.want) {
+				t.Errorf("Register() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserService_VerifyAndLogin(t *testing.T) {
+	s, _, mailer := newTestUserService(t)
+	ctx := context.Background()
+
+	if _, err := s.Register(ctx, "dave@example.com", "Dave", "password123"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if _, _, err := s.Login(ctx, "dave@example.com", "password123"); !errors.Is(
+		err,
+		ErrEmailNotVerified,
+	) {
+		t.Errorf("Login() before verification error = %v, want %v", err, ErrEmailNotVerified)
+	}
+
+	raw := mailer.lastToken(t)
+	if err := s.VerifyEmail(ctx, raw); err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if err := s.VerifyEmail(ctx, raw); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyEmail() reuse error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	u, pair, err := s.Login(ctx, "dave@example.com", "password123")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if pair.AccessToken == "" || pair.RefreshToken == "" {
+		t.Error("Login() should return token pair")
+	}
+	if !u.IsVerified() {
+		t.Error("user should be verified")
+	}
+
+	if _, _, err := s.Login(ctx, "dave@example.com", "wrong-password"); !errors.Is(
+		err,
+		ErrInvalidCredentials,
+	) {
+		t.Errorf("Login() wrong password error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, _, err := s.Login(ctx, "nobody@example.com", "password123"); !errors.Is(
+		err,
+		ErrInvalidCredentials,
+	) {
+		t.Errorf("Login() unknown user error = %v, want %v", err, ErrInvalidCredentials)
+	}
+}
+
+func TestUserService_PasswordReset(t *testing.T) {
+	s, repo, mailer := newTestUserService(t)
+	ctx := context.Background()
+
+	u, _ := s.Register(ctx, "erin@example.com", "Erin", "password123")
+	_ = repo.MarkEmailVerified(ctx, u.ID)
+
+	if err := s.RequestPasswordReset(ctx, "unknown@example.com"); err != nil {
+		t.Errorf("RequestPasswordReset(unknown) error = %v", err)
+	}
+	sent := len(mailer.sent)
+
+	if err := s.RequestPasswordReset(ctx, "erin@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+	if len(mailer.sent) != sent+1 {
+		t.Fatal("reset email not sent")
+	}
+
+	raw := mailer.lastToken(t)
+	if err := s.VerifyEmail(ctx, raw); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reset token used for verification error = %v, want %v", err, ErrInvalidToken)
+	}
+	if err := s.ResetPassword(ctx, raw, "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("ResetPassword() weak error = %v, want %v", err, ErrWeakPassword)
+	}
+	if err := s.ResetPassword(ctx, raw, "new-password"); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if err := s.ResetPassword(ctx, raw, "other-password"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ResetPassword() reuse error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	if _, _, err := s.Login(ctx, "erin@example.com", "new-password"); err != nil {
+		t.Errorf("Login() with new password error = %v", err)
+	}
+}
+
+func TestUserService_ExpiredToken(t *testing.T) {
+	s, repo, mailer := newTestUserService(t)
+	ctx := context.Background()
+
+	if _, err := s.Register(ctx, "frank@example.com", "Frank", "password123"); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	repo.tokens[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	if err := s.VerifyEmail(ctx, mailer.lastToken(t)); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("VerifyEmail() expired error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestUserService_NilMailer(t *testing.T) {
+	l := logger.New(logger.Config{Debug: true})
+	tm, _ := token.New("0123456789abcdef0123456789abcdef")
+	s, err := NewUserService(newMemUserRepo(), nil, tm, l, UserServiceConfig{})
+	if err != nil {
+		t.Fatalf("NewUserService() error = %v", err)
+	}
+
+	_, err = s.Register(context.Background(), "gina@example.com", "", "password123")
+	if err != nil {
+		t.Errorf("Register() without mailer error = %v", err)
+	}
+}
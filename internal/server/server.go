@@ -17,6 +17,36 @@ func Port(port string) Option {
 	}
 }
 
+// DrainDelay waits before closing listener so load balancers observe failing readiness
+func DrainDelay(d time.Duration) Option {
+	return func(s *Server) {
+		s.drainDelay = d
+	}
+}
+
+// ShutdownHook registers function called when Shutdown starts, before draining
+func ShutdownHook(fn func()) Option {
+	return func(s *Server) {
+		s.shutdownHooks = append(s.shutdownHooks, fn)
+	}
+}
+
+// TrustedProxies takes client IP from header when request comes from one of proxies, IPs or
+// CIDRs. Without proxies c.IP() is connection address, so clients can't set it.
+func TrustedProxies(header string, proxies []string) Option {
+	return func(s *Server) {
+		s.proxyHeader = header
+		s.trustedProxies = proxies
+	}
+}
+
+// ErrorHandler renders errors returned from handlers and middleware
+func ErrorHandler(h fiber.ErrorHandler) Option {
+	return func(s *Server) {
+		s.errorHandler = h
+	}
+}
+
 const (
 	_defaultAddr            = ":80"
 	_defaultReadTimeout     = 10 * time.Second
@@ -32,6 +62,11 @@ type Server struct {
 	readTimeout     time.Duration
 	writeTimeout    time.Duration
 	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+	shutdownHooks   []func()
+	errorHandler    fiber.ErrorHandler
+	proxyHeader     string
+	trustedProxies  []string
 }
 
 func New(opts ...Option) *Server {
@@ -48,16 +83,24 @@ func New(opts ...Option) *Server {
 		opt(s)
 	}
 
-	app := fiber.New(fiber.Config{
+	cfg := fiber.Config{
 		DisableStartupMessage: true,
 		BodyLimit:             32 << 20, // 32 MB
-		ProxyHeader:           fiber.HeaderXForwardedFor,
 		Prefork:               false,
 		ReadTimeout:           s.readTimeout,
 		WriteTimeout:          s.writeTimeout,
 		JSONDecoder:           json.Unmarshal,
 		JSONEncoder:           json.Marshal,
-	})
+		ErrorHandler:          s.errorHandler,
+	}
+	if len(s.trustedProxies) > 0 {
+		// header of other senders is ignored, invalid values fall back to connection address
+		cfg.ProxyHeader = s.proxyHeader
+		cfg.EnableTrustedProxyCheck = true
+		cfg.TrustedProxies = s.trustedProxies
+		cfg.EnableIPValidation = true
+	}
+	app := fiber.New(cfg)
 
 	s.App = app
 
@@ -76,5 +119,13 @@ func (s *Server) Notify() <-chan error {
 }
 
 func (s *Server) Shutdown() error {
+	for _, hook := range s.shutdownHooks {
+		hook()
+	}
+
+	if s.drainDelay > 0 {
+		time.Sleep(s.drainDelay)
+	}
+
 	return s.App.ShutdownWithTimeout(s.shutdownTimeout)
 }
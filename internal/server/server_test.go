@@ -32,6 +32,37 @@ func TestNew_Default(t *testing.T) {
 	}
 }
 
+func TestNew_TrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		want    string
+	}{
+		// test requests come from 0.0.0.0
+		{"no proxies", nil, "0.0.0.0"},
+		{"untrusted sender", []string{"10.0.0.1"}, "0.0.0.0"},
+		{"trusted sender", []string{"0.0.0.0/32"}, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(TrustedProxies(fiber.HeaderXForwardedFor, tt.proxies))
+			s.App.Get("/", func(c *fiber.Ctx) error { return c.SendString(c.IP()) })
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.7")
+			resp, err := s.App.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if string(body) != tt.want {
+				t.Errorf("c.IP() = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
 func TestNew_WithPort(t *testing.T) {
 	s := New(Port("8080"))
 
@@ -172,3 +203,46 @@ func TestServer_RouteHandling(t *testing.T) {
 		t.Errorf("GET /notfound status = %d, want 404", resp.StatusCode)
 	}
 }
+
+func TestServer_ShutdownHookAndDrainDelay(t *testing.T) {
+	var called bool
+	delay := 30 * time.Millisecond
+	s := New(Port("0"), ShutdownHook(func() { called = true }), DrainDelay(delay))
+
+	if s.drainDelay != delay {
+		t.Errorf("drainDelay = %v, want %v", s.drainDelay, delay)
+	}
+
+	s.Start()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if err := s.Shutdown(); err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+	if !called {
+		t.Error("shutdown hook was not called")
+	}
+	if time.Since(start) < delay {
+		t.Errorf("Shutdown() returned before drain delay of %v", delay)
+	}
+}
+
+func TestErrorHandler_Option(t *testing.T) {
+	s := New(ErrorHandler(func(c *fiber.Ctx, err error) error {
+		return c.Status(fiber.StatusTeapot).SendString(err.Error())
+	}))
+	s.App.Get("/fail", func(c *fiber.Ctx) error {
+		return fiber.ErrBadRequest
+	})
+
+	resp, err := s.App.Test(httptest.NewRequest("GET", "/fail", nil))
+	if err != nil {
+		t.Fatalf("App.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusTeapot {
+		t.Errorf("status = %d, want custom handler status 418", resp.StatusCode)
+	}
+}
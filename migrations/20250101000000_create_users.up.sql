@@ -0,0 +1,23 @@
+CREATE TABLE users (
+    id                BIGSERIAL PRIMARY KEY,
+    email             TEXT        NOT NULL,
+    name              TEXT        NOT NULL DEFAULT '',
+    password_hash     TEXT        NOT NULL,
+    email_verified_at TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX users_email_key ON users (lower(email));
+
+CREATE TABLE user_tokens (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    BIGINT      NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose    TEXT        NOT NULL,
+    token_hash TEXT        NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX user_tokens_user_id_idx ON user_tokens (user_id);
@@ -0,0 +1,9 @@
+-- unlogged: counters are cheap to lose on crash and skip WAL on every request
+CREATE UNLOGGED TABLE rate_limit_buckets (
+    key        TEXT             PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    allowed    BOOLEAN          NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL DEFAULT now()
+);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
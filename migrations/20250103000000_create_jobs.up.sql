@@ -0,0 +1,25 @@
+CREATE TABLE jobs (
+    id           BIGSERIAL PRIMARY KEY,
+    queue        TEXT        NOT NULL DEFAULT 'default',
+    kind         TEXT        NOT NULL,
+    args         JSONB       NOT NULL DEFAULT '{}',
+    -- pending, running, done or dead
+    state        TEXT        NOT NULL DEFAULT 'pending',
+    attempts     INT         NOT NULL DEFAULT 0,
+    max_attempts INT         NOT NULL,
+    unique_key   TEXT,
+    run_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at  TIMESTAMPTZ
+);
+
+CREATE INDEX jobs_pending_idx ON jobs (queue, run_at) WHERE state = 'pending';
+CREATE INDEX jobs_running_idx ON jobs (queue, locked_until) WHERE state = 'running';
+CREATE INDEX jobs_finished_at_idx ON jobs (finished_at) WHERE finished_at IS NOT NULL;
+
+-- only one unfinished job per key, finished jobs don't block new ones
+CREATE UNIQUE INDEX jobs_unique_key ON jobs (unique_key)
+    WHERE unique_key IS NOT NULL AND state IN ('pending', 'running');
@@ -0,0 +1,8 @@
+-- addresses email.Client doesn't send to, stored lowercase
+CREATE TABLE email_suppressions (
+    address    TEXT        PRIMARY KEY,
+    -- bounce, complaint or unsubscribe
+    reason     TEXT        NOT NULL,
+    detail     TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,9 @@
+// Package migrations embeds versioned SQL schema migrations
+package migrations
+
+import "embed"
+
+// FS Embedded *.up.sql and *.down.sql files
+//
+//go:embed *.sql
+var FS embed.FS
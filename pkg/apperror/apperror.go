@@ -0,0 +1,166 @@
+// Package apperror provides typed application errors mapped to HTTP status codes
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind Error category, decides HTTP status
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindBadRequest
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindRateLimited
+)
+
+// Generic codes used when error is created without specific code
+const (
+	CodeInternal     = "internal"
+	CodeBadRequest   = "bad_request"
+	CodeValidation   = "validation_failed"
+	CodeUnauthorized = "unauthorized"
+	CodeForbidden    = "forbidden"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeRateLimited  = "rate_limited"
+)
+
+var _kinds = map[Kind]struct {
+	status int
+	code   string
+}{
+	KindInternal:     {http.StatusInternalServerError, CodeInternal},
+	KindBadRequest:   {http.StatusBadRequest, CodeBadRequest},
+	KindValidation:   {http.StatusUnprocessableEntity, CodeValidation},
+	KindUnauthorized: {http.StatusUnauthorized, CodeUnauthorized},
+	KindForbidden:    {http.StatusForbidden, CodeForbidden},
+	KindNotFound:     {http.StatusNotFound, CodeNotFound},
+	KindConflict:     {http.StatusConflict, CodeConflict},
+	KindRateLimited:  {http.StatusTooManyRequests, CodeRateLimited},
+}
+
+// Status returns HTTP status of kind
+func (k Kind) Status() int {
+	if v, ok := _kinds[k]; ok {
+		return v.status
+	}
+	return http.StatusInternalServerError
+}
+
+// Code returns generic code of kind
+func (k Kind) Code() string {
+	if v, ok := _kinds[k]; ok {
+		return v.code
+	}
+	return CodeInternal
+}
+
+// FieldError Problem with single request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error Application error, Message is safe to show to clients, Err is only logged
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns HTTP status of error kind
+func (e *Error) Status() int {
+	return e.Kind.Status()
+}
+
+// WithCode returns copy of e with specific code instead of generic one, e is unchanged so
+// shared errors can be specialized
+func (e *Error) WithCode(code string) *Error {
+	cp := *e
+	cp.Code = code
+	return &cp
+}
+
+// Wrap returns copy of e with cause attached, it is logged but never sent to clients
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// New creates error of kind with generic code
+func New(kind Kind, message string) *Error {
+	return &Error{Kind: kind, Code: kind.Code(), Message: message}
+}
+
+// BadRequest request can't be processed, e.g. malformed body
+func BadRequest(message string) *Error {
+	return New(KindBadRequest, message)
+}
+
+// Validation request is well formed but fields are invalid
+func Validation(message string, fields ...FieldError) *Error {
+	e := New(KindValidation, message)
+	e.Fields = fields
+	return e
+}
+
+// Unauthorized caller isn't authenticated
+func Unauthorized(message string) *Error {
+	return New(KindUnauthorized, message)
+}
+
+// Forbidden caller is authenticated but not allowed
+func Forbidden(message string) *Error {
+	return New(KindForbidden, message)
+}
+
+// NotFound resource doesn't exist
+func NotFound(message string) *Error {
+	return New(KindNotFound, message)
+}
+
+// Conflict resource state prevents request, e.g. duplicate key
+func Conflict(message string) *Error {
+	return New(KindConflict, message)
+}
+
+// RateLimited caller sent too many requests
+func RateLimited(message string) *Error {
+	return New(KindRateLimited, message)
+}
+
+// Internal unexpected failure, err is logged and hidden from clients
+func Internal(err error) *Error {
+	return New(KindInternal, "Internal server error").Wrap(err)
+}
+
+// As returns application error in err chain
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
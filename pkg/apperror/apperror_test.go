@@ -0,0 +1,91 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestKind_Status(t *testing.T) {
+	tests := []struct {
+		err        *Error
+		wantStatus int
+		wantCode   string
+	}{
+		{BadRequest("bad"), http.StatusBadRequest, CodeBadRequest},
+		{Validation("invalid"), http.StatusUnprocessableEntity, CodeValidation},
+		{Unauthorized("who"), http.StatusUnauthorized, CodeUnauthorized},
+		{Forbidden("no"), http.StatusForbidden, CodeForbidden},
+		{NotFound("gone"), http.StatusNotFound, CodeNotFound},
+		{Conflict("taken"), http.StatusConflict, CodeConflict},
+		{RateLimited("slow down"), http.StatusTooManyRequests, CodeRateLimited},
+		{Internal(errors.New("boom")), http.StatusInternalServerError, CodeInternal},
+		{New(Kind(100), "unknown"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Status(); got != tt.wantStatus {
+			t.Errorf("%q Status() = %d, want %d", tt.err.Message, got, tt.wantStatus)
+		}
+		if tt.err.Code != tt.wantCode {
+			t.Errorf("%q Code = %v, want %v", tt.err.Message, tt.err.Code, tt.wantCode)
+		}
+	}
+}
+
+func TestError_WithCode(t *testing.T) {
+	err := Conflict("Email already registered").WithCode("email_taken")
+
+	if err.Code != "email_taken" || err.Status() != http.StatusConflict {
+		t.Errorf("Code, Status = %v, %v", err.Code, err.Status())
+	}
+}
+
+func TestError_CopiesShared(t *testing.T) {
+	shared := NotFound("User not found")
+	cause := errors.New("no rows")
+
+	coded := shared.WithCode("user_not_found")
+	wrapped := shared.Wrap(cause)
+
+	if shared.Code != KindNotFound.Code() || shared.Err != nil {
+		t.Errorf("shared error changed: Code = %v, Err = %v", shared.Code, shared.Err)
+	}
+	if coded.Code != "user_not_found" || !errors.Is(wrapped, cause) {
+		t.Errorf("copies = %v, %v", coded.Code, wrapped.Err)
+	}
+}
+
+func TestError_Unwrap(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("load user: %w", Internal(cause))
+
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is() should find cause")
+	}
+	if err.Error() != "load user: Internal server error: connection refused" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	ae, ok := As(err)
+	if !ok || ae.Kind != KindInternal {
+		t.Errorf("As() = %v, %v, want internal error", ae, ok)
+	}
+	if _, ok := As(cause); ok {
+		t.Error("As() should not match plain error")
+	}
+}
+
+func TestValidation_Fields(t *testing.T) {
+	err := Validation("Invalid user",
+		FieldError{Field: "email", Code: "invalid_email", Message: "Email address is invalid"},
+	)
+
+	if len(err.Fields) != 1 || err.Fields[0].Field != "email" {
+		t.Errorf("Fields = %+v", err.Fields)
+	}
+	if err.Error() != "Invalid user" {
+		t.Errorf("Error() = %q, want message", err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+// Package binding decodes request values into structs and validates them with struct tags
+package binding
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+)
+
+// Struct tags naming request values, same as used by fiber parsers
+const (
+	TagParams = "params"
+	TagQuery  = "query"
+	TagHeader = "reqHeader"
+	TagForm   = "form"
+	TagJSON   = "json"
+)
+
+// Bind fills dst from path params, query, headers and body, then validates it.
+// Sources are read only when dst has fields tagged for them, body is read when present.
+func Bind(c *fiber.Ctx, dst any) error {
+	tags := sourceTags(dst)
+
+	if tags[TagParams] {
+		if err := c.ParamsParser(dst); err != nil {
+			return decodeError("Invalid path parameters", "invalid_params", err)
+		}
+	}
+	if tags[TagQuery] {
+		if err := c.QueryParser(dst); err != nil {
+			return decodeError("Invalid query string", "invalid_query", err)
+		}
+	}
+	if tags[TagHeader] {
+		if err := c.ReqHeaderParser(dst); err != nil {
+			return decodeError("Invalid request headers", "invalid_headers", err)
+		}
+	}
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, dst); err != nil {
+			return err
+		}
+	}
+
+	return Validate(dst)
+}
+
+// Body decodes JSON, XML or form body into dst and validates it
+func Body(c *fiber.Ctx, dst any) error {
+	if err := parseBody(c, dst); err != nil {
+		return err
+	}
+	return Validate(dst)
+}
+
+// Query decodes query string into dst and validates it
+func Query(c *fiber.Ctx, dst any) error {
+	if err := c.QueryParser(dst); err != nil {
+		return decodeError("Invalid query string", "invalid_query", err)
+	}
+	return Validate(dst)
+}
+
+// Params decodes path parameters into dst and validates it
+func Params(c *fiber.Ctx, dst any) error {
+	if err := c.ParamsParser(dst); err != nil {
+		return decodeError("Invalid path parameters", "invalid_params", err)
+	}
+	return Validate(dst)
+}
+
+// Headers decodes request headers into dst and validates it
+func Headers(c *fiber.Ctx, dst any) error {
+	if err := c.ReqHeaderParser(dst); err != nil {
+		return decodeError("Invalid request headers", "invalid_headers", err)
+	}
+	return Validate(dst)
+}
+
+func parseBody(c *fiber.Ctx, dst any) error {
+	err := c.BodyParser(dst)
+	if errors.Is(err, fiber.ErrUnprocessableEntity) {
+		// fiber reports unknown content type as 422, it is unsupported media type
+		return fiber.ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return decodeError("Invalid request body", "invalid_body", err)
+	}
+	return nil
+}
+
+func decodeError(message, code string, err error) error {
+	return apperror.BadRequest(message).WithCode(code).Wrap(err)
+}
+
+// sourceTags returns request source tags used by top level fields of dst
+func sourceTags(dst any) map[string]bool {
+	t := reflect.TypeOf(dst)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	tags := map[string]bool{}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		for _, tag := range []string{TagParams, TagQuery, TagHeader} {
+			if _, ok := f.Tag.Lookup(tag); ok {
+				tags[tag] = true
+			}
+		}
+	}
+	return tags
+}
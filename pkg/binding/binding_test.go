@@ -0,0 +1,155 @@
+package binding
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+)
+
+type updateUser struct {
+	ID      int    `params:"id"       validate:"required"`
+	Notify  bool   `query:"notify"`
+	Tenant  string `reqHeader:"X-Tenant" validate:"required"`
+	Name    string `json:"name"       form:"name" validate:"required,max=10"`
+	Comment string `json:"comment"`
+}
+
+func bindApp(t *testing.T, bind func(*fiber.Ctx, any) error) (*fiber.App, *updateUser, *error) {
+	t.Helper()
+
+	var got updateUser
+	var bindErr error
+	app := fiber.New()
+	app.Put("/users/:id", func(c *fiber.Ctx) error {
+		got = updateUser{}
+		bindErr = bind(c, &got)
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	return app, &got, &bindErr
+}
+
+func put(t *testing.T, app *fiber.App, path, ctype, body string, headers ...string) {
+	t.Helper()
+
+	req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+	if ctype != "" {
+		req.Header.Set(fiber.HeaderContentType, ctype)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestBind_AllSources(t *testing.T) {
+	app, got, bindErr := bindApp(t, Bind)
+
+	put(t, app, "/users/7?notify=true", fiber.MIMEApplicationJSON,
+		`{"name":"Ana","comment":"hi"}`, "X-Tenant", "acme")
+
+	if *bindErr != nil {
+		t.Fatalf("Bind() error = %v", *bindErr)
+	}
+	want := updateUser{ID: 7, Notify: true, Tenant: "acme", Name: "Ana", Comment: "hi"}
+	if *got != want {
+		t.Errorf("Bind() = %+v, want %+v", *got, want)
+	}
+}
+
+func TestBind_Form(t *testing.T) {
+	app, got, bindErr := bindApp(t, Bind)
+
+	put(t, app, "/users/7", fiber.MIMEApplicationForm, "name=Ana", "X-Tenant", "acme")
+
+	if *bindErr != nil || got.Name != "Ana" {
+		t.Errorf("Bind() = %+v, %v", *got, *bindErr)
+	}
+}
+
+func TestBind_ValidationErrors(t *testing.T) {
+	app, _, bindErr := bindApp(t, Bind)
+
+	put(t, app, "/users/0", fiber.MIMEApplicationJSON, `{"name":"much too long name"}`)
+
+	got := fieldErrors(t, *bindErr)
+	want := map[string]string{"id": "required", "X-Tenant": "required", "name": "max"}
+	for field, code := range want {
+		if got[field] != code {
+			t.Errorf("field errors = %v, want %s %s", got, field, code)
+		}
+	}
+}
+
+func TestBind_DecodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		ctype    string
+		body     string
+		wantCode string
+	}{
+		{"malformed json", "/users/1", fiber.MIMEApplicationJSON, `{`, "invalid_body"},
+		{"bad param", "/users/abc", fiber.MIMEApplicationJSON, `{}`, "invalid_params"},
+		{"bad query", "/users/1?notify=maybe", fiber.MIMEApplicationJSON, `{}`, "invalid_query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app, _, bindErr := bindApp(t, Bind)
+			put(t, app, tt.path, tt.ctype, tt.body, "X-Tenant", "acme")
+
+			ae, ok := apperror.As(*bindErr)
+			if !ok || ae.Kind != apperror.KindBadRequest || ae.Code != tt.wantCode {
+				t.Errorf("Bind() error = %v, want bad request %s", *bindErr, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestBody_UnsupportedMediaType(t *testing.T) {
+	app, _, bindErr := bindApp(t, Body)
+
+	put(t, app, "/users/1", "text/plain", "name=Ana")
+
+	if !errors.Is(*bindErr, fiber.ErrUnsupportedMediaType) {
+		t.Errorf("Body() error = %v, want unsupported media type", *bindErr)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	type filter struct {
+		Status string `query:"status" validate:"oneof=active archived"`
+		Limit  int    `query:"limit"  validate:"max=100"`
+	}
+
+	var got filter
+	var bindErr error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		got = filter{}
+		bindErr = Query(c, &got)
+		return nil
+	})
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/?status=active&limit=20", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if bindErr != nil || got.Status != "active" || got.Limit != 20 {
+		t.Errorf("Query() = %+v, %v", got, bindErr)
+	}
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/?status=gone&limit=500", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if fields := fieldErrors(t, bindErr); fields["status"] != "oneof" || fields["limit"] != "max" {
+		t.Errorf("Query() field errors = %v", fields)
+	}
+}
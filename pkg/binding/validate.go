@@ -0,0 +1,332 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/lomifile/api/pkg/apperror"
+)
+
+// TagValidate Struct tag listing rules, e.g. `validate:"required,min=8,max=72"`
+const TagValidate = "validate"
+
+// Rule Checks field value, param is text after "=" in the tag. Returned error message is
+// sent to clients, rules other than required are skipped for zero values.
+type Rule func(v reflect.Value, param string) error
+
+// Validator Validates structs against rules registered by name
+type Validator struct {
+	mu    sync.RWMutex
+	rules map[string]Rule
+	// params checks tag params of built-in rules
+	params map[string]func(param string) error
+	// checked caches tag errors of struct types, reflect.Type to error or nil
+	checked sync.Map
+}
+
+var _default = NewValidator()
+
+// NewValidator creates validator with built-in rules: required, min, max, email, uuid, oneof
+func NewValidator() *Validator {
+	return &Validator{rules: map[string]Rule{
+		"min":   ruleMin,
+		"max":   ruleMax,
+		"email": ruleEmail,
+		"uuid":  ruleUUID,
+		"oneof": ruleOneOf,
+	}, params: map[string]func(string) error{
+		"min": checkLimit,
+		"max": checkLimit,
+	}}
+}
+
+// Register adds custom rule or replaces existing one, struct types are checked again
+func (v *Validator) Register(name string, rule Rule) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules[name] = rule
+	delete(v.params, name)
+	v.checked.Clear()
+}
+
+// RegisterRule adds custom rule to default validator used by Bind and Validate
+func RegisterRule(name string, rule Rule) {
+	_default.Register(name, rule)
+}
+
+// Validate checks dst with default validator
+func Validate(dst any) error {
+	return _default.Validate(dst)
+}
+
+// Validate checks every field of struct dst and returns all problems as single
+// validation error. Field names come from json, query, params, reqHeader or form tag.
+// Tags are checked once per struct type, unknown rule or bad param is returned as plain
+// error, it is bug in dst type and not in request.
+func (v *Validator) Validate(dst any) error {
+	rv := reflect.Indirect(reflect.ValueOf(dst))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if err := v.checkType(rv.Type()); err != nil {
+		return err
+	}
+
+	var fields []apperror.FieldError
+	v.validateStruct(rv, "", &fields)
+	if len(fields) == 0 {
+		return nil
+	}
+	return apperror.Validation("Request validation failed", fields...)
+}
+
+func (v *Validator) validateStruct(rv reflect.Value, prefix string, out *[]apperror.FieldError) {
+	t := rv.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name := fieldName(sf)
+		if name == "-" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if sf.Anonymous && name == sf.Name {
+			// embedded struct fields are promoted, they keep parent prefix
+			if ev := reflect.Indirect(fv); ev.Kind() == reflect.Struct {
+				v.validateStruct(ev, prefix, out)
+			}
+			continue
+		}
+
+		path := prefix + name
+		if tag := sf.Tag.Get(TagValidate); tag != "" && tag != "-" {
+			if !v.validateField(fv, path, tag, out) {
+				continue
+			}
+		}
+		v.validateNested(fv, path, out)
+	}
+}
+
+// checkType returns tag error of struct t and structs nested in it, result is cached
+func (v *Validator) checkType(t reflect.Type) error {
+	if err, ok := v.checked.Load(t); ok {
+		err, _ := err.(error)
+		return err
+	}
+	err := v.checkStruct(t, map[reflect.Type]bool{})
+	v.checked.Store(t, err)
+	return err
+}
+
+// checkStruct walks fields the same way as validateStruct, seen stops recursive types
+func (v *Validator) checkStruct(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() || fieldName(sf) == "-" {
+			continue
+		}
+		if tag := sf.Tag.Get(TagValidate); tag != "" && tag != "-" {
+			if err := v.checkTag(tag); err != nil {
+				return fmt.Errorf("binding: %s.%s: %w", t, sf.Name, err)
+			}
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			if ft = ft.Elem(); ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+		}
+		if ft.Kind() == reflect.Struct {
+			if err := v.checkStruct(ft, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkTag reports unknown rules and params built-in rules can't use
+func (v *Validator) checkTag(tag string) error {
+	for rule := range strings.SplitSeq(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "required" {
+			continue
+		}
+		if _, ok := v.rules[name]; !ok {
+			return fmt.Errorf("unknown validation rule %q", name)
+		}
+		if check, ok := v.params[name]; ok {
+			if err := check(param); err != nil {
+				return fmt.Errorf("rule %q: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateField applies rules in tag order, reports first failing rule
+func (v *Validator) validateField(
+	fv reflect.Value,
+	path string,
+	tag string,
+	out *[]apperror.FieldError,
+) bool {
+	for rule := range strings.SplitSeq(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		if name == "required" {
+			if fv.IsZero() {
+				*out = append(*out, apperror.FieldError{
+					Field:   path,
+					Code:    "required",
+					Message: "is required",
+				})
+				return false
+			}
+			continue
+		}
+		if fv.IsZero() {
+			continue
+		}
+
+		// checkType made sure rule exists
+		if err := v.rules[name](reflect.Indirect(fv), param); err != nil {
+			*out = append(*out, apperror.FieldError{
+				Field:   path,
+				Code:    name,
+				Message: err.Error(),
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// validateNested walks into structs, pointers to structs and slices of structs
+func (v *Validator) validateNested(fv reflect.Value, path string, out *[]apperror.FieldError) {
+	fv = reflect.Indirect(fv)
+	switch fv.Kind() {
+	case reflect.Struct:
+		v.validateStruct(fv, path+".", out)
+	case reflect.Slice, reflect.Array:
+		for i := range fv.Len() {
+			ev := reflect.Indirect(fv.Index(i))
+			if ev.Kind() == reflect.Struct {
+				v.validateStruct(ev, fmt.Sprintf("%s[%d].", path, i), out)
+			}
+		}
+	}
+}
+
+// fieldName returns name client used for field
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{TagJSON, TagQuery, TagParams, TagHeader, TagForm} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(tag), ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// size returns length of strings and collections, numeric value otherwise
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func sizeUnit(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+	return ""
+}
+
+// checkLimit reports min and max params that are not numbers
+func checkLimit(param string) error {
+	if _, err := strconv.ParseFloat(param, 64); err != nil {
+		return fmt.Errorf("limit %q is not a number", param)
+	}
+	return nil
+}
+
+func ruleMin(v reflect.Value, param string) error {
+	limit, _ := strconv.ParseFloat(param, 64)
+	if n, ok := size(v); ok && n < limit {
+		return fmt.Errorf("must be at least %s%s", param, sizeUnit(v))
+	}
+	return nil
+}
+
+func ruleMax(v reflect.Value, param string) error {
+	limit, _ := strconv.ParseFloat(param, 64)
+	if n, ok := size(v); ok && n > limit {
+		return fmt.Errorf("must be at most %s%s", param, sizeUnit(v))
+	}
+	return nil
+}
+
+func ruleEmail(v reflect.Value, _ string) error {
+	addr, err := mail.ParseAddress(v.String())
+	if err != nil || addr.Address != v.String() {
+		return errors.New("must be a valid email address")
+	}
+	return nil
+}
+
+var _uuid = regexp.MustCompile(`^[0-9a-fA-F]{8}-([0-9a-fA-F]{4}-){3}[0-9a-fA-F]{12}$`)
+
+func ruleUUID(v reflect.Value, _ string) error {
+	if !_uuid.MatchString(v.String()) {
+		return errors.New("must be a valid UUID")
+	}
+	return nil
+}
+
+func ruleOneOf(v reflect.Value, param string) error {
+	options := strings.Fields(param)
+	value := fmt.Sprint(v.Interface())
+	for _, o := range options {
+		if o == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of: %s", strings.Join(options, ", "))
+}
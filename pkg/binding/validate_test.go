@@ -0,0 +1,189 @@
+package binding
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lomifile/api/pkg/apperror"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type Base struct {
+	ID string `json:"id" validate:"omitted,uuid"`
+}
+
+type signup struct {
+	Base
+	Email    string    `json:"email"    validate:"required,email"`
+	Password string    `json:"password" validate:"required,min=8,max=72"`
+	Role     string    `json:"role"     validate:"oneof=admin member"`
+	Age      int       `json:"age"      validate:"min=18"`
+	Tags     []string  `json:"tags"     validate:"max=2"`
+	Address  *address  `json:"address"`
+	Previous []address `json:"previous"`
+	internal string    `validate:"required"`
+}
+
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	ae, ok := apperror.As(err)
+	if !ok || ae.Kind != apperror.KindValidation {
+		t.Fatalf("error = %v, want validation error", err)
+	}
+	got := map[string]string{}
+	for _, f := range ae.Fields {
+		got[f.Field] = f.Code
+	}
+	return got
+}
+
+func newTestValidator() *Validator {
+	v := NewValidator()
+	// omitted accepts anything, used to check rules after it still run
+	v.Register("omitted", func(reflect.Value, string) error { return nil })
+	return v
+}
+
+func TestValidator_Valid(t *testing.T) {
+	s := signup{
+		Base:     Base{ID: "0b7e4a52-1c1b-4a4e-9a6f-2f8c3b7d9e10"},
+		Email:    "a@example.com",
+		Password: "password123",
+		Role:     "admin",
+		Age:      30,
+		Address:  &address{City: "Zagreb"},
+	}
+
+	if err := newTestValidator().Validate(&s); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidator_AllErrors(t *testing.T) {
+	s := signup{
+		Base:     Base{ID: "not-a-uuid"},
+		Email:    "Alice <a@example.com>",
+		Password: "short",
+		Role:     "owner",
+		Age:      12,
+		Tags:     []string{"a", "b", "c"},
+		Address:  &address{},
+		Previous: []address{{City: "Split"}, {}},
+	}
+
+	got := fieldErrors(t, newTestValidator().Validate(s))
+	want := map[string]string{
+		"id":               "uuid",
+		"email":            "email",
+		"password":         "min",
+		"role":             "oneof",
+		"age":              "min",
+		"tags":             "max",
+		"address.city":     "required",
+		"previous[1].city": "required",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("field errors = %v, want %v", got, want)
+	}
+}
+
+func TestValidator_RequiredSkipsOtherRules(t *testing.T) {
+	got := fieldErrors(t, newTestValidator().Validate(&signup{}))
+
+	if got["email"] != "required" || got["password"] != "required" {
+		t.Errorf("field errors = %v, want required email and password", got)
+	}
+	for _, field := range []string{"role", "age", "id", "address.city"} {
+		if _, ok := got[field]; ok {
+			t.Errorf("empty optional %s should not be validated", field)
+		}
+	}
+}
+
+func TestValidator_CustomRule(t *testing.T) {
+	type slug struct {
+		Name string `query:"name" validate:"lowercase"`
+	}
+
+	v := NewValidator()
+	v.Register("lowercase", func(rv reflect.Value, _ string) error {
+		if rv.String() != strings.ToLower(rv.String()) {
+			return errors.New("must be lowercase")
+		}
+		return nil
+	})
+
+	err := v.Validate(slug{Name: "Hello"})
+	ae, _ := apperror.As(err)
+	if ae == nil || len(ae.Fields) != 1 {
+		t.Fatalf("Validate() = %v, want one field error", err)
+	}
+	f := ae.Fields[0]
+	if f.Field != "name" || f.Code != "lowercase" || f.Message != "must be lowercase" {
+		t.Errorf("field error = %+v", f)
+	}
+}
+
+func TestValidator_InvalidTags(t *testing.T) {
+	type unknown struct {
+		Name string `validate:"nope"`
+	}
+	type badLimit struct {
+		Name string `validate:"min=eight"`
+	}
+	type nested struct {
+		Items []*badLimit
+	}
+
+	tests := []struct {
+		name string
+		dst  any
+		want string
+	}{
+		{"unknown rule", unknown{}, `unknown validation rule "nope"`},
+		{"bad limit", &badLimit{Name: "x"}, `rule "min": limit "eight" is not a number`},
+		{"nested", nested{}, "badLimit.Name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator()
+			// checked even when values would skip the rule, error is cached
+			for range 2 {
+				err := v.Validate(tt.dst)
+				if _, ok := apperror.As(err); ok || err == nil {
+					t.Fatalf("Validate() = %v, want plain error", err)
+				}
+				if !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("Validate() = %v, want it to contain %q", err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidator_RegisterRechecks(t *testing.T) {
+	type slug struct {
+		Name string `validate:"slug"`
+	}
+
+	v := NewValidator()
+	if err := v.Validate(slug{}); err == nil {
+		t.Fatal("Validate() with unknown rule = nil, want error")
+	}
+	v.Register("slug", func(reflect.Value, string) error { return nil })
+	if err := v.Validate(slug{Name: "a"}); err != nil {
+		t.Errorf("Validate() after Register = %v, want nil", err)
+	}
+}
+
+func TestValidator_NotStruct(t *testing.T) {
+	if err := Validate("text"); err != nil {
+		t.Errorf("Validate() of string = %v, want nil", err)
+	}
+}
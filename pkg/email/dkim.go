@@ -0,0 +1,204 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	gmail "github.com/wneessen/go-mail"
+)
+
+// _dkimHeaders are signed when message has them, RFC 8058 requires List-Unsubscribe ones
+var _dkimHeaders = []string{
+	"From", "Reply-To", "Subject", "Date", "To", "Cc", "Message-ID", "MIME-Version",
+	"Content-Type", "List-Unsubscribe", "List-Unsubscribe-Post",
+}
+
+var _wsp = regexp.MustCompile(`[ \t]+`)
+
+// DKIMSigner Signs messages with DKIM (RFC 6376) using relaxed/relaxed canonicalization
+type DKIMSigner struct {
+	domain   string
+	selector string
+	key      crypto.Signer
+	algo     string
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// NewDKIMSigner creates signer publishing its key as selector._domainkey.domain, key is
+// PEM encoded RSA (PKCS #1 or #8) or Ed25519 (PKCS #8) private key
+func NewDKIMSigner(domain, selector string, key []byte) (*DKIMSigner, error) {
+	if domain == "" || selector == "" {
+		return nil, errors.New("email: dkim domain and selector are required")
+	}
+
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("email: dkim key is not PEM encoded")
+	}
+	var (
+		parsed any
+		err    error
+	)
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("email: dkim key: %w", err)
+	}
+
+	s := &DKIMSigner{domain: domain, selector: selector, now: time.Now}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		s.key, s.algo = k, "rsa-sha256"
+	case ed25519.PrivateKey:
+		s.key, s.algo = k, "ed25519-sha256"
+	default:
+		return nil, fmt.Errorf("email: dkim key type %T is not supported", parsed)
+	}
+
+	return s, nil
+}
+
+// LoadDKIMSigner creates signer with key read from file at path
+func LoadDKIMSigner(domain, selector, path string) (*DKIMSigner, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("email: dkim key: %w", err)
+	}
+	return NewDKIMSigner(domain, selector, key)
+}
+
+// signMsg adds DKIM-Signature header to msg. Multipart boundaries are generated on first
+// write and reused, so msg is written again with the same body.
+func (s *DKIMSigner) signMsg(msg *gmail.Msg) error {
+	var buf bytes.Buffer
+	if _, err := msg.WriteTo(&buf); err != nil {
+		return fmt.Errorf("email: dkim: %w", err)
+	}
+
+	sig, err := s.sign(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	msg.SetGenHeaderPreformatted("DKIM-Signature", sig)
+	return nil
+}
+
+// sign returns DKIM-Signature header value of raw message
+func (s *DKIMSigner) sign(raw []byte) (string, error) {
+	header, body, ok := bytes.Cut(raw, []byte("\r\n\r\n"))
+	if !ok {
+		return "", errors.New("email: dkim: message has no body")
+	}
+	fields := parseHeader(string(header) + "\r\n")
+
+	bh := sha256.Sum256(relaxedBody(body))
+
+	h := sha256.New()
+	var signed []string
+	for _, name := range _dkimHeaders {
+		// bottom-most instance is signed, RFC 6376 section 5.4.2
+		f, ok := fields[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		h.Write([]byte(relaxedHeader(f[len(f)-1]) + "\r\n"))
+		signed = append(signed, strings.ToLower(name))
+	}
+
+	value := fmt.Sprintf(
+		"v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d;\r\n h=%s;\r\n bh=%s;\r\n b=",
+		s.algo,
+		s.domain,
+		s.selector,
+		s.now().Unix(),
+		strings.Join(signed, ":"),
+		base64.StdEncoding.EncodeToString(bh[:]),
+	)
+	h.Write([]byte(relaxedHeader("DKIM-Signature: " + value)))
+
+	var (
+		sig []byte
+		err error
+	)
+	if s.algo == "ed25519-sha256" {
+		// RFC 8463 signs the SHA-256 hash with PureEdDSA
+		sig, err = s.key.Sign(rand.Reader, h.Sum(nil), crypto.Hash(0))
+	} else {
+		sig, err = s.key.Sign(rand.Reader, h.Sum(nil), crypto.SHA256)
+	}
+	if err != nil {
+		return "", fmt.Errorf("email: dkim: %w", err)
+	}
+
+	return value + base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// parseHeader splits header block into fields keyed by lowercase name, continuation
+// lines stay in their field
+func parseHeader(header string) map[string][]string {
+	fields := make(map[string][]string)
+	var name, field string
+	flush := func() {
+		if name != "" {
+			fields[name] = append(fields[name], field)
+		}
+	}
+
+	for _, line := range strings.SplitAfter(header, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			field += line
+			continue
+		}
+		flush()
+		n, _, _ := strings.Cut(line, ":")
+		name = strings.ToLower(strings.TrimRight(n, " \t"))
+		field = line
+	}
+	flush()
+
+	return fields
+}
+
+// relaxedHeader canonicalizes header field, RFC 6376 section 3.4.2
+func relaxedHeader(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.NewReplacer("\r\n", "", "\n", "").Replace(value)
+	value = strings.TrimSpace(_wsp.ReplaceAllString(value, " "))
+	return strings.ToLower(strings.TrimRight(name, " \t")) + ":" + value
+}
+
+// relaxedBody canonicalizes body, RFC 6376 section 3.4.4
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(_wsp.ReplaceAllString(line, " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
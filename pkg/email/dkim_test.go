@@ -0,0 +1,161 @@
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+func TestRelaxedCanonicalization(t *testing.T) {
+	// example from RFC 6376 section 3.4.5
+	fields := parseHeader("A: X\r\nB : Y\t\r\n\tZ  \r\n")
+	got := relaxedHeader(fields["a"][0]) + "\r\n" + relaxedHeader(fields["b"][0]) + "\r\n"
+	if got != "a:X\r\nb:Y Z\r\n" {
+		t.Errorf("relaxed header = %q", got)
+	}
+
+	body := relaxedBody([]byte(" C \r\nD \t E\r\n\r\n\r\n"))
+	if string(body) != " C\r\nD E\r\n" {
+		t.Errorf("relaxed body = %q", body)
+	}
+	if body := relaxedBody([]byte("\r\n\r\n")); body != nil {
+		t.Errorf("relaxed empty body = %q, want empty", body)
+	}
+}
+
+func TestNewDKIMSigner(t *testing.T) {
+	_, rsaPEM := rsaKey(t)
+	if _, err := NewDKIMSigner("example.com", "mail", rsaPEM); err != nil {
+		t.Errorf("NewDKIMSigner() PKCS #1 error = %v", err)
+	}
+
+	invalid := map[string][]byte{
+		"not pem":     []byte("key"),
+		"bad der":     pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("x")}),
+		"no selector": rsaPEM,
+	}
+	for name, key := range invalid {
+		selector := "mail"
+		if name == "no selector" {
+			selector = ""
+		}
+		if _, err := NewDKIMSigner("example.com", selector, key); err == nil {
+			t.Errorf("NewDKIMSigner() with %s should fail", name)
+		}
+	}
+}
+
+func TestDKIMSigner_Sign(t *testing.T) {
+	rsaPriv, rsaPEM := rsaKey(t)
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edPriv)
+	edPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER})
+
+	keys := map[string]struct {
+		pem []byte
+		pub crypto.PublicKey
+	}{
+		"rsa-sha256":     {rsaPEM, &rsaPriv.PublicKey},
+		"ed25519-sha256": {edPEM, edPriv.Public()},
+	}
+	for algo, key := range keys {
+		s, err := NewDKIMSigner("example.com", "mail", key.pem)
+		if err != nil {
+			t.Fatalf("NewDKIMSigner(%s) error = %v", algo, err)
+		}
+		s.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+		m := &Message{
+			From:        "app@example.com",
+			To:          []string{"ana@example.com"},
+			Headers:     map[string]string{"List-Unsubscribe-Post": "List-Unsubscribe=One-Click"},
+			Subject:     "Hello",
+			Text:        "plain  body \r\n\r\n",
+			HTML:        "<p>html</p>",
+			Attachments: []Attachment{{Name: "a.txt", Data: []byte("data")}},
+			DKIM:        s,
+		}
+		msg, err := m.build()
+		if err != nil {
+			t.Fatalf("build() error = %v", err)
+		}
+		var buf bytes.Buffer
+		if _, err := msg.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		raw := buf.Bytes()
+
+		for _, want := range []string{"a=" + algo, "d=example.com", "s=mail", "t=1700000000"} {
+			if !bytes.Contains(raw, []byte(want)) {
+				t.Errorf("%s signature doesn't contain %q", algo, want)
+			}
+		}
+		if !bytes.Contains(raw, []byte("list-unsubscribe-post")) {
+			t.Errorf("%s signature doesn't cover List-Unsubscribe-Post", algo)
+		}
+		if err := verifyDKIM(raw, key.pub); err != nil {
+			t.Errorf("%s signature invalid: %v\n%s", algo, err, raw)
+		}
+
+		tampered := bytes.Replace(raw, []byte("Subject: Hello"), []byte("Subject: Hi"), 1)
+		if err := verifyDKIM(tampered, key.pub); err == nil {
+			t.Errorf("%s signature of tampered message should be invalid", algo)
+		}
+	}
+}
+
+func rsaKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := x509.MarshalPKCS1PrivateKey(k)
+	return k, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: der})
+}
+
+// verifyDKIM checks signature of raw message with independent verifier, so canonicalization
+// bugs of signer aren't repeated by the check
+func verifyDKIM(raw []byte, pub crypto.PublicKey) error {
+	var record string
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			return err
+		}
+		record = "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)
+	case ed25519.PublicKey:
+		record = "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(k)
+	}
+
+	verifications, err := dkim.VerifyWithOptions(bytes.NewReader(raw), &dkim.VerifyOptions{
+		LookupTXT: func(domain string) ([]string, error) {
+			if domain != "mail._domainkey.example.com" {
+				return nil, fmt.Errorf("unexpected lookup of %s", domain)
+			}
+			return []string{record}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if len(verifications) != 1 {
+		return fmt.Errorf("%d DKIM signatures, want 1", len(verifications))
+	}
+	return verifications[0].Err
+}
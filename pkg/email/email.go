@@ -2,66 +2,420 @@
 package email
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"sync"
+	"time"
+
 	"github.com/lomifile/api/config"
 	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/metrics"
+	"github.com/lomifile/api/pkg/retry"
+	"github.com/lomifile/api/pkg/tracing"
 	gmail "github.com/wneessen/go-mail"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
 )
 
 // SendEmailConfig Config what needs to be passed to send HTML email
 type SendEmailConfig struct {
-	To                string
+	To                []string
+	Cc                []string          `json:",omitempty"`
+	Bcc               []string          `json:",omitempty"`
+	ReplyTo           string            `json:",omitempty"`
+	Headers           map[string]string `json:",omitempty"`
 	Subject           string
 	AlternativeString string
 	HTML              string
+	Attachments       []Attachment `json:",omitempty"`
+	// Transactional email was requested by recipient, e.g. password reset, it skips
+	// suppression list and unsubscribe headers
+	Transactional bool `json:",omitempty"`
+}
+
+// message converts cfg to Message sent from address from
+func (cfg *SendEmailConfig) message(from string) *Message {
+	return &Message{
+		From:        from,
+		To:          cfg.To,
+		Cc:          cfg.Cc,
+		Bcc:         cfg.Bcc,
+		ReplyTo:     cfg.ReplyTo,
+		Headers:     cfg.Headers,
+		Subject:     cfg.Subject,
+		Text:        cfg.AlternativeString,
+		HTML:        cfg.HTML,
+		Attachments: cfg.Attachments,
+	}
 }
 
 // Client Main email client wiht logger and sender
 type Client struct {
-	e    *gmail.Client
-	l    *logger.Logger
-	from string
-}
-
-// NewEmailClient Creates new instace of email client
-func NewEmailClient(l *logger.Logger, c *config.Config) *Client {
-	client, err := gmail.NewClient(
-		c.Email.Host,
-		gmail.WithPort(587),
-		gmail.WithUsername(c.Email.Username),
-		gmail.WithPassword(c.Email.Password),
-		gmail.WithSMTPAuth(gmail.SMTPAuthPlain),
-		gmail.WithTLSPolicy(gmail.TLSMandatory),
-	)
+	// mu guards sender settings replaced by Reconfigure
+	mu     sync.RWMutex
+	sender Sender
+	from   string
+	dkim   *DKIMSigner
+
+	l            *logger.Logger
+	m            *metrics.Metrics
+	retry        []retry.Option
+	templates    *Renderer
+	suppressions Suppressions
+	unsubscribe  *Unsubscriber
+}
+
+// Option Provides function to email client options
+type Option func(*Client)
+
+// WithMetrics counts sent and failed emails
+func WithMetrics(m *metrics.Metrics) Option { return func(em *Client) { em.m = m } }
+
+// WithRetry tunes retries of temporary delivery failures, e.g. retry.WithMaxAttempts(1)
+// disables them
+func WithRetry(opts ...retry.Option) Option {
+	return func(em *Client) { em.retry = append(em.retry, opts...) }
+}
+
+// WithDKIM signs every email with s
+func WithDKIM(s *DKIMSigner) Option { return func(em *Client) { em.dkim = s } }
+
+// WithSuppressions skips recipients on list, recipients rejecting email permanently are added
+// to it as bounces
+func WithSuppressions(list Suppressions) Option {
+	return func(em *Client) { em.suppressions = list }
+}
+
+// WithUnsubscribe adds one-click List-Unsubscribe headers of u to emails with one recipient
+func WithUnsubscribe(u *Unsubscriber) Option { return func(em *Client) { em.unsubscribe = u } }
+
+// WithRenderer renders SendTemplateContext emails with r instead of embedded templates
+func WithRenderer(r *Renderer) Option { return func(em *Client) { em.templates = r } }
+
+const (
+	_defaultRetryAttempts = 3
+	_defaultRetryInitial  = time.Second
+	_defaultRetryMax      = 10 * time.Second
+	_defaultRetryElapsed  = 30 * time.Second
+)
+
+// NewEmailClient Creates email client with sender selected by c.Email.Transport, signing
+// emails when DKIM is configured
+func NewEmailClient(l *logger.Logger, c *config.Config, opts ...Option) (*Client, error) {
+	sender, err := NewSender(c.Email)
+	if err != nil {
+		return nil, err
+	}
+	dkim, err := newDKIM(c.Email)
 	if err != nil {
-		l.Fatal(err.Error())
+		return nil, err
 	}
 
-	l.Info("Email client ready")
-	return &Client{e: client, l: l, from: c.Email.Username}
+	em, err := New(sender, c.Email.Sender(), l, append([]Option{WithDKIM(dkim)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	l.Info("Email client ready", zap.String("transport", c.Email.Transport))
+
+	return em, nil
+}
+
+// New Creates email client sending from address from through sender
+func New(sender Sender, from string, l *logger.Logger, opts ...Option) (*Client, error) {
+	em := &Client{sender: sender, from: from, l: l}
+	for _, opt := range opts {
+		opt(em)
+	}
+	if em.templates == nil {
+		templates, err := NewRenderer()
+		if err != nil {
+			return nil, err
+		}
+		em.templates = templates
+	}
+
+	return em, nil
+}
+
+// Reconfigure prepares sender for new email settings, implements config.Subscriber.
+// Sends already in progress finish with old settings.
+func (em *Client) Reconfigure(old, next *config.Config) (func(), error) {
+	if old.Email == next.Email {
+		return nil, nil
+	}
+
+	sender, err := NewSender(next.Email)
+	if err != nil {
+		return nil, err
+	}
+	dkim, err := newDKIM(next.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		em.mu.Lock()
+		defer em.mu.Unlock()
+
+		em.sender = sender
+		em.from = next.Email.Sender()
+		em.dkim = dkim
+	}, nil
+}
+
+// ReconfigureDisabled is reload subscriber of app started without email. Client is created
+// only at startup, so reload enabling email is rejected instead of silently ignored.
+func ReconfigureDisabled(_, next *config.Config) (func(), error) {
+	if next.Email.Enabled() {
+		return nil, errors.New("email: enabling email needs restart")
+	}
+	return nil, nil
+}
+
+// newDKIM creates signer of c, nil when DKIM isn't configured
+func newDKIM(c config.Email) (*DKIMSigner, error) {
+	if c.DKIMDomain == "" {
+		return nil, nil
+	}
+	return LoadDKIMSigner(c.DKIMDomain, c.DKIMSelector, c.DKIMKeyFile)
 }
 
 // SendHTMLEmail Sends HTML type email
 func (em *Client) SendHTMLEmail(cfg *SendEmailConfig) error {
-	msg := gmail.NewMsg()
+	return em.SendHTMLEmailContext(context.Background(), cfg)
+}
 
-	err := msg.From(em.from)
+// SendTemplateContext Renders data's template in locale and sends it to address to
+func (em *Client) SendTemplateContext(
+	ctx context.Context,
+	to, locale string,
+	data TemplateData,
+) error {
+	cfg, err := em.templates.Render(locale, data)
 	if err != nil {
 		return err
 	}
+	cfg.To = []string{to}
+
+	return em.SendHTMLEmailContext(ctx, cfg)
+}
 
-	err = msg.To(cfg.To)
+// SendHTMLEmailContext Sends HTML type email as child span of ctx, cancelled with ctx
+func (em *Client) SendHTMLEmailContext(ctx context.Context, cfg *SendEmailConfig) error {
+	return em.send(ctx, cfg)
+}
+
+// send sends cfg, opts are applied over client's retry options
+func (em *Client) send(ctx context.Context, cfg *SendEmailConfig, opts ...retry.Option) error {
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		"email.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("email.subject", cfg.Subject)),
+	)
+	defer span.End()
+
+	err := em.sendHTMLEmail(ctx, cfg, opts)
 	if err != nil {
-		return err
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if em.m != nil {
+		em.m.ObserveEmail(err)
 	}
 
-	msg.Subject(cfg.Subject)
-	msg.AddAlternativeString(gmail.TypeTextPlain, cfg.AlternativeString)
-	msg.SetBodyString(gmail.TypeTextHTML, cfg.HTML)
+	return err
+}
 
-	err = em.e.DialAndSend(msg)
-	if err != nil {
+func (em *Client) sendHTMLEmail(
+	ctx context.Context,
+	cfg *SendEmailConfig,
+	opts []retry.Option,
+) error {
+	em.mu.RLock()
+	sender, from, dkim := em.sender, em.from, em.dkim
+	em.mu.RUnlock()
+
+	return retry.Do(ctx, func(ctx context.Context) error {
+		msg, err := em.message(ctx, cfg, from, dkim)
+		if err == nil {
+			err = sender.Send(ctx, msg)
+			em.bounced(ctx, msg, err)
+		}
+		if err != nil && !temporary(err) {
+			return retry.Permanent(err)
+		}
 		return err
+	}, append(em.retryOptions(ctx), opts...)...)
+}
+
+// SendBulkContext Sends cfgs reusing one connection where sender supports it, errs[i] is
+// nil when cfgs[i] was sent. Messages failing temporarily are retried together.
+func (em *Client) SendBulkContext(ctx context.Context, cfgs []*SendEmailConfig) []error {
+	ctx, span := tracing.Tracer().Start(
+		ctx,
+		"email.send_bulk",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("email.count", len(cfgs))),
+	)
+	defer span.End()
+
+	em.mu.RLock()
+	sender, from, dkim := em.sender, em.from, em.dkim
+	em.mu.RUnlock()
+
+	errs := make([]error, len(cfgs))
+	pending := make([]int, len(cfgs))
+	for i := range cfgs {
+		pending[i] = i
+	}
+
+	// errs keeps last error of every message, so error of Do adds nothing
+	_ = retry.Do(ctx, func(ctx context.Context) error {
+		var (
+			next []int
+			msgs []*Message
+			sent []int
+		)
+		for _, i := range pending {
+			msg, err := em.message(ctx, cfgs[i], from, dkim)
+			if err != nil {
+				errs[i] = err
+				if temporary(err) {
+					next = append(next, i)
+				}
+				continue
+			}
+			msgs = append(msgs, msg)
+			sent = append(sent, i)
+		}
+
+		for j, err := range sendBatch(ctx, sender, msgs) {
+			errs[sent[j]] = err
+			em.bounced(ctx, msgs[j], err)
+			if err != nil && temporary(err) {
+				next = append(next, sent[j])
+			}
+		}
+		pending = next
+		if len(pending) > 0 {
+			return fmt.Errorf("%d of %d emails failed temporarily", len(pending), len(cfgs))
+		}
+		return nil
+	}, em.retryOptions(ctx)...)
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+		if em.m != nil {
+			em.m.ObserveEmail(err)
+		}
+	}
+	if failed > 0 {
+		span.SetStatus(codes.Error, fmt.Sprintf("%d of %d emails failed", failed, len(cfgs)))
 	}
 
+	return errs
+}
+
+// message converts cfg to Message from address from signed by dkim. Unless cfg is
+// transactional, suppressed recipients are removed and unsubscribe headers added.
+func (em *Client) message(
+	ctx context.Context,
+	cfg *SendEmailConfig,
+	from string,
+	dkim *DKIMSigner,
+) (*Message, error) {
+	msg := cfg.message(from)
+	msg.DKIM = dkim
+	if cfg.Transactional {
+		return msg, nil
+	}
+
+	if em.suppressions != nil {
+		suppressed, err := suppress(ctx, em.suppressions, msg)
+		if len(suppressed) > 0 {
+			em.l.Ctx(ctx).Warn(
+				"email recipients suppressed",
+				zap.String("subject", cfg.Subject),
+				zap.Any("suppressed", suppressed),
+			)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if rcpts := msg.Recipients(); em.unsubscribe != nil && len(rcpts) == 1 {
+		headers := em.unsubscribe.Headers(rcpts[0])
+		maps.Copy(headers, msg.Headers)
+		msg.Headers = headers
+	}
+
+	return msg, nil
+}
+
+// bounced suppresses recipient of msg when server rejected it permanently. Rejection of
+// message with more recipients doesn't tell which one failed, so they are kept.
+func (em *Client) bounced(ctx context.Context, msg *Message, err error) {
+	var se *gmail.SendError
+	if em.suppressions == nil || !errors.As(err, &se) || se.Reason != gmail.ErrSMTPRcptTo ||
+		se.IsTemp() || len(msg.Recipients()) != 1 {
+		return
+	}
+
+	addr := msg.Recipients()[0]
+	if serr := em.suppressions.Suppress(ctx, addr, ReasonBounce, err.Error()); serr != nil {
+		em.l.Ctx(ctx).Warn("email bounce not suppressed", zap.String("to", addr), zap.Error(serr))
+	}
+}
+
+// retryOptions returns client's retry policy, retries are logged with ctx logger
+func (em *Client) retryOptions(ctx context.Context) []retry.Option {
+	return append([]retry.Option{
+		retry.WithMaxAttempts(_defaultRetryAttempts),
+		retry.WithInitial(_defaultRetryInitial),
+		retry.WithMax(_defaultRetryMax),
+		retry.WithMaxElapsed(_defaultRetryElapsed),
+		retry.WithOnRetry(func(attempt int, delay time.Duration, err error) {
+			em.l.Ctx(ctx).Warn(
+				"email send failed, retrying",
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+		}),
+	}, em.retry...)
+}
+
+// temporary reports whether delivery can succeed later, 4xx SMTP replies, network errors
+// and failed suppression lookups are temporary, rejected messages and auth failures are not
+func temporary(err error) bool {
+	if errors.Is(err, errLookup) {
+		return true
+	}
+	var se *gmail.SendError
+	if errors.As(err, &se) {
+		return se.IsTemp()
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// Check Runs sender's health probe, senders without one are always healthy
+func (em *Client) Check(ctx context.Context) error {
+	em.mu.RLock()
+	sender := em.sender
+	em.mu.RUnlock()
+
+	if c, ok := sender.(Checker); ok {
+		return c.Check(ctx)
+	}
 	return nil
 }
@@ -1,18 +1,33 @@
 package email
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/lomifile/api/config"
+	"github.com/lomifile/api/pkg/jobs"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/metrics"
+	"github.com/lomifile/api/pkg/retry"
+	"github.com/prometheus/common/expfmt"
+	gmail "github.com/wneessen/go-mail"
 )
 
 func TestSendEmailConfig(t *testing.T) {
 	cfg := SendEmailConfig{
-		To:                "test@example.com",
+		To:                []string{"test@example.com"},
 		Subject:           "Test Subject",
 		AlternativeString: "Plain text content",
 		HTML:              "<h1>HTML content</h1>",
 	}
 
-	if cfg.To != "test@example.com" {
+	if len(cfg.To) != 1 || cfg.To[0] != "test@example.com" {
 		t.Errorf("To = %v, want test@example.com", cfg.To)
 	}
 	if cfg.Subject != "Test Subject" {
@@ -40,7 +55,7 @@ func TestClient_Struct(t *testing.T) {
 func TestSendEmailConfig_EmptyFields(t *testing.T) {
 	cfg := SendEmailConfig{}
 
-	if cfg.To != "" {
+	if len(cfg.To) != 0 {
 		t.Errorf("To should be empty, got %v", cfg.To)
 	}
 	if cfg.Subject != "" {
@@ -53,3 +68,297 @@ func TestSendEmailConfig_EmptyFields(t *testing.T) {
 		t.Errorf("HTML should be empty, got %v", cfg.HTML)
 	}
 }
+
+func TestClient_Check(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	addr := ln.Addr().String()
+
+	client := &Client{sender: &SMTPSender{addr: addr}}
+	if err := client.Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+
+	_ = ln.Close()
+	if err := client.Check(context.Background()); err == nil {
+		t.Error("Check() should fail when server is down")
+	}
+
+	client = &Client{sender: NewMemorySender()}
+	if err := client.Check(context.Background()); err != nil {
+		t.Errorf("Check() of sender without probe = %v", err)
+	}
+}
+
+func TestWithMetrics(t *testing.T) {
+	m := metrics.New()
+	client := &Client{}
+	WithMetrics(m)(client)
+
+	if client.m != m {
+		t.Error("WithMetrics() did not set metrics")
+	}
+}
+
+func TestSendHTMLEmail_CountsFailure(t *testing.T) {
+	m := metrics.New()
+	client := &Client{m: m, from: "not an address", sender: NewMemorySender()}
+
+	if err := client.SendHTMLEmail(&SendEmailConfig{To: []string{"to@example.com"}}); err == nil {
+		t.Fatal("SendHTMLEmail() with invalid sender should fail")
+	}
+
+	body := gather(t, m)
+	if !strings.Contains(body, `api_email_sent_total{result="failure"} 1`) {
+		t.Errorf("failure not counted:\n%s", body)
+	}
+}
+
+func TestClient_Reconfigure(t *testing.T) {
+	client := &Client{from: "old@example.com", sender: NewMemorySender()}
+	old := &config.Config{Email: config.Email{Host: "old.example.com", Username: "old@example.com"}}
+
+	apply, err := client.Reconfigure(old, old)
+	if err != nil || apply != nil {
+		t.Errorf("Reconfigure() unchanged = %v, %v, want nil apply", apply != nil, err)
+	}
+
+	next := &config.Config{
+		Email: config.Email{
+			Transport: "smtp",
+			Host:      "new.example.com",
+			Port:      465,
+			TLSPolicy: "ssl",
+			Username:  "user",
+			From:      "new@example.com",
+		},
+	}
+	apply, err = client.Reconfigure(old, next)
+	if err != nil {
+		t.Fatalf("Reconfigure() error = %v", err)
+	}
+	if client.from != "old@example.com" {
+		t.Error("Reconfigure() should not change client before apply")
+	}
+
+	apply()
+	smtp, ok := client.sender.(*SMTPSender)
+	if client.from != "new@example.com" || !ok || smtp.addr != "new.example.com:465" {
+		t.Errorf("after apply from, sender = %v, %#v", client.from, client.sender)
+	}
+
+	if _, err := client.Reconfigure(next, &config.Config{}); err == nil {
+		t.Error("Reconfigure() without host should fail")
+	}
+}
+
+func TestReconfigureDisabled(t *testing.T) {
+	disabled := &config.Config{Email: config.Email{Transport: "smtp"}}
+
+	if apply, err := ReconfigureDisabled(disabled, disabled); err != nil || apply != nil {
+		t.Errorf("ReconfigureDisabled() still disabled = %v, %v", apply != nil, err)
+	}
+
+	enabled := &config.Config{Email: config.Email{Transport: "smtp", Host: "smtp.example.com"}}
+	if _, err := ReconfigureDisabled(disabled, enabled); err == nil {
+		t.Error("ReconfigureDisabled() enabling email should fail")
+	}
+}
+
+func gather(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	families, err := m.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	var sb strings.Builder
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(&sb, mf); err != nil {
+			t.Fatalf("MetricFamilyToText() error = %v", err)
+		}
+	}
+	return sb.String()
+}
+
+func TestTemporary(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"network", &net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{"rejected", &gmail.SendError{Reason: gmail.ErrSMTPMailFrom}, false},
+		{"other", errors.New("auth failed"), false},
+	}
+	for _, tt := range tests {
+		if got := temporary(tt.err); got != tt.want {
+			t.Errorf("temporary(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// unreachableClient returns client sending to port nobody listens on, retries counts its retries
+func unreachableClient(t *testing.T, retries *int) *Client {
+	t.Helper()
+
+	// closed listener gives port nobody listens on
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	smtp, err := gmail.NewClient(
+		"127.0.0.1",
+		gmail.WithPort(port),
+		gmail.WithTLSPolicy(gmail.NoTLS),
+		gmail.WithTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &Client{
+		sender: &SMTPSender{client: smtp},
+		from:   "from@example.com",
+		l:      logger.New(logger.Config{}),
+	}
+	WithRetry(
+		retry.WithMaxAttempts(3),
+		retry.WithInitial(time.Millisecond),
+		retry.WithOnRetry(func(int, time.Duration, error) { *retries++ }),
+	)(client)
+	return client
+}
+
+func TestSendHTMLEmail_RetriesNetworkErrors(t *testing.T) {
+	retries := 0
+	client := unreachableClient(t, &retries)
+
+	cfg := &SendEmailConfig{To: []string{"to@example.com"}}
+	err := client.SendHTMLEmailContext(context.Background(), cfg)
+	if err == nil || retries != 2 {
+		t.Errorf("SendHTMLEmailContext() = %v after %d retries, want error after 2", err, retries)
+	}
+}
+
+type memJobStore struct {
+	jobs.Store
+	rows    []*jobs.Row
+	claimed bool
+	retried chan string
+}
+
+func (s *memJobStore) Enqueue(_ context.Context, r *jobs.Row) (int64, error) {
+	s.rows = append(s.rows, r)
+	return int64(len(s.rows)), nil
+}
+
+// Claim returns first enqueued job once
+func (s *memJobStore) Claim(context.Context, string, int, time.Duration) ([]jobs.Row, error) {
+	if s.claimed || len(s.rows) == 0 {
+		return nil, nil
+	}
+	s.claimed = true
+	r := *s.rows[0]
+	r.ID, r.Attempts = 1, 1
+	return []jobs.Row{r}, nil
+}
+
+func (s *memJobStore) Retry(_ context.Context, _ int64, _ int, _ time.Time, msg string) error {
+	s.retried <- msg
+	return nil
+}
+
+func TestRegisterSender_JobRetriesInsteadOfClient(t *testing.T) {
+	retries := 0
+	client := unreachableClient(t, &retries)
+
+	store := &memJobStore{retried: make(chan string, 1)}
+	q := NewQueue(jobs.NewClient(store))
+	if err := q.SendHTMLEmailContext(
+		context.Background(),
+		&SendEmailConfig{To: []string{"to@example.com"}},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	w := jobs.NewWorker(store, logger.New(logger.Config{}), jobs.WithPollInterval(time.Hour))
+	RegisterSender(w, client)
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	select {
+	case <-store.retried:
+	case <-time.After(5 * time.Second):
+		t.Fatal("temporarily failed email job wasn't retried")
+	}
+	if retries != 0 {
+		t.Errorf("client retried %d times inside job, want 0", retries)
+	}
+}
+
+func TestQueue_SendHTMLEmailContext(t *testing.T) {
+	store := &memJobStore{}
+	q := NewQueue(jobs.NewClient(store), jobs.OnQueue("email"))
+
+	cfg := &SendEmailConfig{
+		To:          []string{"to@example.com"},
+		Subject:     "Verify",
+		HTML:        "<p>hi</p>",
+		Attachments: []Attachment{{Name: "a.txt", Data: []byte("data")}},
+	}
+	if err := q.SendHTMLEmailContext(context.Background(), cfg); err != nil {
+		t.Fatalf("SendHTMLEmailContext() error = %v", err)
+	}
+
+	if len(store.rows) != 1 {
+		t.Fatalf("enqueued %d jobs, want 1", len(store.rows))
+	}
+	r := store.rows[0]
+	var args SendArgs
+	if err := json.Unmarshal(r.Args, &args); err != nil {
+		t.Fatal(err)
+	}
+	same := reflect.DeepEqual(args.SendEmailConfig, *cfg)
+	if r.Kind != "email.send" || r.Queue != "email" || !same {
+		t.Errorf("enqueued %s on %s with %+v", r.Kind, r.Queue, args)
+	}
+}
+
+func TestSendArgs_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"list", `{"To":["a@example.com","b@example.com"],"Subject":"Hi"}`,
+			[]string{"a@example.com", "b@example.com"}},
+		// payload enqueued before To became list
+		{"single address", `{"To":"a@example.com","Subject":"Hi"}`,
+			[]string{"a@example.com"}},
+		{"empty address", `{"To":"","Subject":"Hi"}`, nil},
+		{"missing", `{"Subject":"Hi"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args SendArgs
+			if err := json.Unmarshal([]byte(tt.data), &args); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(args.To, tt.want) || args.Subject != "Hi" {
+				t.Errorf("args = %+v, want To %v", args, tt.want)
+			}
+		})
+	}
+
+	var args SendArgs
+	if err := json.Unmarshal([]byte(`{"To":42}`), &args); err == nil {
+		t.Error("Unmarshal() of number To should fail")
+	}
+}
@@ -0,0 +1,272 @@
+package email
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// cssRule Rule of simple selector: optional tag, classes and id, no combinators
+type cssRule struct {
+	tag     string
+	id      string
+	classes []string
+	decls   []cssDecl
+	// specificity and source order decide which declaration wins
+	specificity [3]int
+	order       int
+}
+
+type cssDecl struct {
+	prop, value string
+}
+
+// inlineCSS copies rules of <style> elements into style attributes, many email clients drop
+// <style>. Rules that can't be inlined, like @media, stay in <style>. Existing style
+// attributes win over rules.
+func inlineCSS(doc string) (string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", err
+	}
+
+	var (
+		rules  []cssRule
+		styles []*html.Node
+	)
+	walk(root, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Data != "style" || n.FirstChild == nil {
+			return
+		}
+		parsed, rest := parseCSS(n.FirstChild.Data, len(rules))
+		rules = append(rules, parsed...)
+		n.FirstChild.Data = rest
+		styles = append(styles, n)
+	})
+	for _, n := range styles {
+		if strings.TrimSpace(n.FirstChild.Data) == "" {
+			n.Parent.RemoveChild(n)
+		}
+	}
+	if len(rules) == 0 {
+		return doc, nil
+	}
+
+	walk(root, func(n *html.Node) {
+		if n.Type != html.ElementNode {
+			return
+		}
+		var matched []cssRule
+		for _, r := range rules {
+			if r.matches(n) {
+				matched = append(matched, r)
+			}
+		}
+		if len(matched) == 0 {
+			return
+		}
+		sort.SliceStable(matched, func(i, j int) bool {
+			a, b := matched[i], matched[j]
+			if a.specificity != b.specificity {
+				return less(a.specificity, b.specificity)
+			}
+			return a.order < b.order
+		})
+
+		var decls []cssDecl
+		for _, r := range matched {
+			decls = append(decls, r.decls...)
+		}
+		decls = append(decls, parseDecls(attr(n, "style"))...)
+		setAttr(n, "style", formatDecls(decls))
+	})
+
+	var b bytes.Buffer
+	if err := html.Render(&b, root); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// parseCSS returns inlinable rules and remaining CSS, order numbers rules from start
+func parseCSS(css string, start int) ([]cssRule, string) {
+	var (
+		rules []cssRule
+		rest  strings.Builder
+	)
+	for {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+		selectors := strings.TrimSpace(css[:open])
+
+		// at-rules nest blocks, keep them whole
+		if strings.HasPrefix(selectors, "@") {
+			end := matchingBrace(css, open)
+			rest.WriteString(strings.TrimSpace(css[:end]) + "\n")
+			css = css[end:]
+			continue
+		}
+
+		end := strings.IndexByte(css[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+		body := css[open+1 : end]
+		css = css[end+1:]
+
+		var kept []string
+		for _, sel := range strings.Split(selectors, ",") {
+			r, ok := parseSelector(strings.TrimSpace(sel))
+			if !ok {
+				kept = append(kept, strings.TrimSpace(sel))
+				continue
+			}
+			r.decls = parseDecls(body)
+			r.order = start + len(rules)
+			rules = append(rules, r)
+		}
+		if len(kept) > 0 {
+			rest.WriteString(strings.Join(kept, ", ") + " {" + body + "}\n")
+		}
+	}
+
+	return rules, rest.String()
+}
+
+// matchingBrace returns index after brace closing the one at open
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+func parseSelector(sel string) (cssRule, bool) {
+	if sel == "" || strings.ContainsAny(sel, " >+~:[*") {
+		return cssRule{}, false
+	}
+
+	var r cssRule
+	// split before every . and # keeping the marker
+	parts := strings.FieldsFunc(strings.NewReplacer(".", " .", "#", " #").Replace(sel),
+		func(c rune) bool { return c == ' ' })
+	for i, p := range parts {
+		switch {
+		case strings.HasPrefix(p, "#") && len(p) > 1:
+			r.id = p[1:]
+			r.specificity[0]++
+		case strings.HasPrefix(p, ".") && len(p) > 1:
+			r.classes = append(r.classes, p[1:])
+			r.specificity[1]++
+		case i == 0:
+			r.tag = strings.ToLower(p)
+			r.specificity[2]++
+		default:
+			return cssRule{}, false
+		}
+	}
+	return r, true
+}
+
+func (r cssRule) matches(n *html.Node) bool {
+	if r.tag != "" && r.tag != n.Data {
+		return false
+	}
+	if r.id != "" && r.id != attr(n, "id") {
+		return false
+	}
+	classes := strings.Fields(attr(n, "class"))
+	for _, c := range r.classes {
+		found := false
+		for _, have := range classes {
+			if have == c {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDecls(s string) []cssDecl {
+	var decls []cssDecl
+	for _, d := range strings.Split(s, ";") {
+		prop, value, ok := strings.Cut(d, ":")
+		prop, value = strings.TrimSpace(prop), strings.TrimSpace(value)
+		if ok && prop != "" && value != "" {
+			decls = append(decls, cssDecl{prop: strings.ToLower(prop), value: value})
+		}
+	}
+	return decls
+}
+
+// formatDecls joins declarations keeping the last value of every property
+func formatDecls(decls []cssDecl) string {
+	last := make(map[string]int, len(decls))
+	for i, d := range decls {
+		last[d.prop] = i
+	}
+
+	var parts []string
+	for i, d := range decls {
+		if last[d.prop] == i {
+			parts = append(parts, d.prop+": "+d.value)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func less(a, b [3]int) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	for c := n.FirstChild; c != nil; {
+		// fn may remove c
+		next := c.NextSibling
+		fn(c)
+		walk(c, fn)
+		c = next
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
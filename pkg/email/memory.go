@@ -0,0 +1,104 @@
+package email
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// TB Subset of testing.TB used by MemorySender assertions
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// MemorySender Keeps sent messages in memory for tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send implements Sender, messages are validated like SMTP would
+func (s *MemorySender) Send(_ context.Context, m *Message) error {
+	if _, err := m.build(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cp := *m
+	cp.To = slices.Clone(m.To)
+	cp.Cc = slices.Clone(m.Cc)
+	cp.Bcc = slices.Clone(m.Bcc)
+	cp.Headers = maps.Clone(m.Headers)
+	cp.Attachments = slices.Clone(m.Attachments)
+	s.messages = append(s.messages, cp)
+	return nil
+}
+
+// Messages returns sent messages, oldest first
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Last returns most recently sent message
+func (s *MemorySender) Last() (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.messages) == 0 {
+		return Message{}, false
+	}
+	return s.messages[len(s.messages)-1], true
+}
+
+// Reset forgets sent messages
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// AssertCount reports error unless n messages were sent
+func (s *MemorySender) AssertCount(t TB, n int) {
+	t.Helper()
+
+	if got := len(s.Messages()); got != n {
+		t.Errorf("email: sent %d messages, want %d", got, n)
+	}
+}
+
+// AssertSent reports error unless message with subject was sent to address to as To, Cc or
+// Bcc recipient, returns the latest such message
+func (s *MemorySender) AssertSent(t TB, to, subject string) Message {
+	t.Helper()
+
+	messages := s.Messages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		m := messages[i]
+		if m.Subject != subject {
+			continue
+		}
+		for _, addr := range m.Recipients() {
+			if strings.EqualFold(addr, to) {
+				return m
+			}
+		}
+	}
+
+	sent := make([]string, len(messages))
+	for i, m := range messages {
+		sent[i] = strings.Join(m.Recipients(), ",") + ": " + m.Subject
+	}
+	t.Errorf("email: no %q message to %s, sent: %v", subject, to, sent)
+	return Message{}
+}
@@ -0,0 +1,140 @@
+package email
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gmail "github.com/wneessen/go-mail"
+)
+
+// Attachment File attached to message, or embedded inline when ContentID is set
+type Attachment struct {
+	Name string `json:"name"`
+	// ContentType is detected from Name's extension when empty
+	ContentType string `json:"content_type,omitempty"`
+	Data        []byte `json:"data"`
+	// ContentID embeds file inline, HTML references it as cid:<ContentID>
+	ContentID string `json:"content_id,omitempty"`
+}
+
+// AttachFile reads file at path into attachment named after it
+func AttachFile(path string) (Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("email: attach: %w", err)
+	}
+
+	return Attachment{Name: filepath.Base(path), Data: data}, nil
+}
+
+// Message Email handed to Sender
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	// Headers extra headers, e.g. X-Entity-Ref-ID
+	Headers map[string]string
+	Subject string
+	// Text plain text body, alternative of HTML when both are set
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	// DKIM signs built message when set
+	DKIM *DKIMSigner
+}
+
+// Recipients returns To, Cc and Bcc addresses
+func (m *Message) Recipients() []string {
+	rcpts := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	rcpts = append(rcpts, m.To...)
+	rcpts = append(rcpts, m.Cc...)
+	return append(rcpts, m.Bcc...)
+}
+
+// build converts m to go-mail message, validating addresses
+func (m *Message) build() (*gmail.Msg, error) {
+	msg := gmail.NewMsg()
+	if err := msg.From(m.From); err != nil {
+		return nil, err
+	}
+	if len(m.Recipients()) == 0 {
+		return nil, errors.New("email: no recipients")
+	}
+	if err := setAddrs(msg.To, m.To); err != nil {
+		return nil, err
+	}
+	if err := setAddrs(msg.Cc, m.Cc); err != nil {
+		return nil, err
+	}
+	if err := setAddrs(msg.Bcc, m.Bcc); err != nil {
+		return nil, err
+	}
+	if m.ReplyTo != "" {
+		if err := msg.ReplyTo(m.ReplyTo); err != nil {
+			return nil, err
+		}
+	}
+	for name, value := range m.Headers {
+		msg.SetGenHeader(gmail.Header(name), value)
+	}
+	msg.Subject(m.Subject)
+
+	// clients show the last alternative they support, so HTML goes after text
+	switch {
+	case m.HTML == "":
+		msg.SetBodyString(gmail.TypeTextPlain, m.Text)
+	case m.Text == "":
+		msg.SetBodyString(gmail.TypeTextHTML, m.HTML)
+	default:
+		msg.SetBodyString(gmail.TypeTextPlain, m.Text)
+		msg.AddAlternativeString(gmail.TypeTextHTML, m.HTML)
+	}
+
+	for _, a := range m.Attachments {
+		if a.Name == "" {
+			return nil, errors.New("email: attachment without name")
+		}
+		opts := []gmail.FileOption{gmail.WithFileContentType(a.contentType())}
+		if a.ContentID != "" {
+			opts = append(opts, gmail.WithFileContentID("<"+a.ContentID+">"))
+			msg.EmbedReadSeeker(a.Name, bytes.NewReader(a.Data), opts...)
+			continue
+		}
+		msg.AttachReadSeeker(a.Name, bytes.NewReader(a.Data), opts...)
+	}
+	msg.SetMessageID()
+	msg.SetDate()
+
+	if m.DKIM != nil {
+		if err := m.DKIM.signMsg(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+func (a Attachment) contentType() gmail.ContentType {
+	if a.ContentType != "" {
+		return gmail.ContentType(a.ContentType)
+	}
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(a.Name))); t != "" {
+		return gmail.ContentType(t)
+	}
+	return gmail.TypeAppOctetStream
+}
+
+// setAddrs sets address header with set, skipped when addrs is empty
+func setAddrs(set func(...string) error, addrs []string) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+	return set(addrs...)
+}
@@ -0,0 +1,79 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/lomifile/api/pkg/jobs"
+	"github.com/lomifile/api/pkg/retry"
+)
+
+// SendArgs Job sending one HTML email
+type SendArgs struct {
+	SendEmailConfig
+}
+
+// Kind implements jobs.Args
+func (SendArgs) Kind() string { return "email.send" }
+
+// UnmarshalJSON also accepts To as single address, jobs enqueued before it became list
+// still hold it that way
+func (a *SendArgs) UnmarshalJSON(data []byte) error {
+	type config SendEmailConfig
+	var v struct {
+		config
+		To json.RawMessage
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	a.SendEmailConfig = SendEmailConfig(v.config)
+	a.To = nil
+
+	var to string
+	switch {
+	case len(v.To) == 0 || string(v.To) == "null":
+	case json.Unmarshal(v.To, &to) == nil:
+		if to != "" {
+			a.To = []string{to}
+		}
+	default:
+		return json.Unmarshal(v.To, &a.To)
+	}
+	return nil
+}
+
+// Queue Sends emails from background jobs, enqueued in ctx transaction so email goes out
+// only when it commits
+type Queue struct {
+	jobs *jobs.Client
+	opts []jobs.EnqueueOption
+}
+
+// NewQueue creates queued mailer, opts apply to every enqueued email
+func NewQueue(c *jobs.Client, opts ...jobs.EnqueueOption) *Queue {
+	return &Queue{jobs: c, opts: opts}
+}
+
+// SendHTMLEmailContext enqueues email, it is sent by worker with RegisterSender
+func (q *Queue) SendHTMLEmailContext(ctx context.Context, cfg *SendEmailConfig) error {
+	_, err := q.jobs.Enqueue(ctx, SendArgs{SendEmailConfig: *cfg}, q.opts...)
+	return err
+}
+
+// RegisterSender sends queued emails with em on w, rejected emails are not retried and
+// emails to suppressed recipients are dropped, Client logs them
+func RegisterSender(w *jobs.Worker, em *Client) {
+	jobs.Register(w, func(ctx context.Context, job *jobs.Job[SendArgs]) error {
+		// job retries temporary failures with its own backoff, client retries would multiply it
+		err := em.send(ctx, &job.Args.SendEmailConfig, retry.WithMaxAttempts(1))
+		if errors.Is(err, ErrSuppressed) {
+			return nil
+		}
+		if err != nil && !temporary(err) {
+			return retry.Permanent(err)
+		}
+		return err
+	})
+}
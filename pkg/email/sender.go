@@ -0,0 +1,239 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/lomifile/api/config"
+	"github.com/lomifile/api/pkg/utils"
+	gmail "github.com/wneessen/go-mail"
+)
+
+// Sender Delivers messages, implemented by SMTPSender, FileSender and MemorySender
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// Checker Sender with health probe
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// BatchSender Sender delivering many messages in one session, errs[i] is nil when ms[i]
+// was sent
+type BatchSender interface {
+	SendBatch(ctx context.Context, ms []*Message) (errs []error)
+}
+
+// sendBatch sends ms with s's batch API when it has one, one by one otherwise
+func sendBatch(ctx context.Context, s Sender, ms []*Message) []error {
+	if b, ok := s.(BatchSender); ok {
+		return b.SendBatch(ctx, ms)
+	}
+
+	errs := make([]error, len(ms))
+	for i, m := range ms {
+		errs[i] = s.Send(ctx, m)
+	}
+	return errs
+}
+
+// NewSender creates sender selected by c.Transport
+func NewSender(c config.Email) (Sender, error) {
+	switch c.Transport {
+	case "smtp", "":
+		return NewSMTPSender(c)
+	case "file":
+		return NewFileSender(c.Dir)
+	case "memory":
+		return NewMemorySender(), nil
+	default:
+		return nil, fmt.Errorf("email: unknown transport %q", c.Transport)
+	}
+}
+
+var _tlsPolicies = map[string]gmail.TLSPolicy{
+	"mandatory":     gmail.TLSMandatory,
+	"opportunistic": gmail.TLSOpportunistic,
+	"none":          gmail.NoTLS,
+	// ssl connects with implicit TLS, policy only matters for STARTTLS
+	"ssl": gmail.TLSMandatory,
+}
+
+var _authTypes = map[string]gmail.SMTPAuthType{
+	"plain":         gmail.SMTPAuthPlain,
+	"login":         gmail.SMTPAuthLogin,
+	"cram-md5":      gmail.SMTPAuthCramMD5,
+	"scram-sha-256": gmail.SMTPAuthSCRAMSHA256,
+}
+
+const (
+	_defaultPort = 587
+	_smtpTimeout = 15 * time.Second
+)
+
+// SMTPSender Sends through SMTP server
+type SMTPSender struct {
+	client *gmail.Client
+	addr   string
+}
+
+// NewSMTPSender creates sender for c's host, port, TLS policy and auth mechanism,
+// empty settings use port 587, mandatory STARTTLS and PLAIN auth
+func NewSMTPSender(c config.Email) (*SMTPSender, error) {
+	if c.Host == "" {
+		return nil, errors.New("email: host is required")
+	}
+
+	port := c.Port
+	if port == 0 {
+		port = _defaultPort
+	}
+	policyName, authName := c.TLSPolicy, c.Auth
+	if policyName == "" {
+		policyName = "mandatory"
+	}
+	if authName == "" {
+		authName = "plain"
+	}
+	policy, ok := _tlsPolicies[policyName]
+	if !ok {
+		return nil, fmt.Errorf("email: unknown tls policy %q", c.TLSPolicy)
+	}
+
+	opts := []gmail.Option{
+		gmail.WithPort(port),
+		gmail.WithTLSPolicy(policy),
+		gmail.WithTimeout(_smtpTimeout),
+	}
+	if policyName == "ssl" {
+		opts = append(opts, gmail.WithSSL())
+	}
+	if authName != "none" {
+		auth, ok := _authTypes[authName]
+		if !ok {
+			return nil, fmt.Errorf("email: unknown auth %q", c.Auth)
+		}
+		opts = append(
+			opts,
+			gmail.WithSMTPAuth(auth),
+			gmail.WithUsername(c.Username),
+			gmail.WithPassword(c.Password),
+		)
+	}
+
+	client, err := gmail.NewClient(c.Host, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("email: smtp client: %w", err)
+	}
+
+	return &SMTPSender{client: client, addr: net.JoinHostPort(c.Host, strconv.Itoa(port))}, nil
+}
+
+// Send implements Sender
+func (s *SMTPSender) Send(ctx context.Context, m *Message) error {
+	msg, err := m.build()
+	if err != nil {
+		return err
+	}
+	return s.client.DialAndSendWithContext(ctx, msg)
+}
+
+// SendBatch implements BatchSender, messages share one SMTP connection. Dial failure is
+// reported for every message.
+func (s *SMTPSender) SendBatch(ctx context.Context, ms []*Message) []error {
+	errs := make([]error, len(ms))
+	msgs := make([]*gmail.Msg, 0, len(ms))
+	built := make([]int, 0, len(ms))
+	for i, m := range ms {
+		msg, err := m.build()
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		msgs = append(msgs, msg)
+		built = append(built, i)
+	}
+	if len(msgs) == 0 {
+		return errs
+	}
+
+	client, err := s.client.DialToSMTPClientWithContext(ctx)
+	if err == nil {
+		defer func() { _ = s.client.CloseWithSMTPClient(client) }()
+		err = s.client.SendWithSMTPClient(client, msgs...)
+	}
+	if err == nil {
+		return errs
+	}
+
+	// connection failures aren't recorded on messages
+	failed := false
+	for j, msg := range msgs {
+		if msg.HasSendError() {
+			errs[built[j]] = msg.SendError()
+			failed = true
+		}
+	}
+	if !failed {
+		for _, i := range built {
+			errs[i] = err
+		}
+	}
+	return errs
+}
+
+// Check Verifies SMTP server accepts TCP connections, used as health probe
+func (s *SMTPSender) Check(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// FileSender Writes every message as .eml file into maildir's new directory, for local
+// development without SMTP server
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender creates dir with maildir's tmp, new and cur subdirectories
+func NewFileSender(dir string) (*FileSender, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("email: maildir: %w", err)
+		}
+	}
+
+	return &FileSender{dir: dir}, nil
+}
+
+// Send implements Sender, message is written to tmp and renamed so readers never see
+// partial files
+func (s *FileSender) Send(_ context.Context, m *Message) error {
+	msg, err := m.build()
+	if err != nil {
+		return err
+	}
+
+	suffix, err := utils.RandToken(4)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d.%s.eml", time.Now().UnixNano(), suffix)
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err := msg.WriteToFile(tmp); err != nil {
+		return fmt.Errorf("email: write %s: %w", tmp, err)
+	}
+
+	return os.Rename(tmp, filepath.Join(s.dir, "new", name))
+}
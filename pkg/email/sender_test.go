@@ -0,0 +1,385 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lomifile/api/config"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/retry"
+)
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		email config.Email
+		want  string
+	}{
+		{config.Email{Transport: "smtp", Host: "smtp.example.com"}, "*email.SMTPSender"},
+		{config.Email{Transport: "file", Dir: t.TempDir()}, "*email.FileSender"},
+		{config.Email{Transport: "memory"}, "*email.MemorySender"},
+	}
+	for _, tt := range tests {
+		s, err := NewSender(tt.email)
+		if err != nil {
+			t.Errorf("NewSender(%s) error = %v", tt.email.Transport, err)
+			continue
+		}
+		if got := fmt.Sprintf("%T", s); got != tt.want {
+			t.Errorf("NewSender(%s) = %s, want %s", tt.email.Transport, got, tt.want)
+		}
+	}
+
+	if _, err := NewSender(config.Email{Transport: "ses"}); err == nil {
+		t.Error("NewSender() with unknown transport should fail")
+	}
+}
+
+func TestNewSMTPSender(t *testing.T) {
+	s, err := NewSMTPSender(config.Email{Host: "smtp.example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTPSender() error = %v", err)
+	}
+	if s.addr != "smtp.example.com:587" {
+		t.Errorf("default addr = %s", s.addr)
+	}
+
+	invalid := []config.Email{
+		{},
+		{Host: "smtp.example.com", TLSPolicy: "starttls"},
+		{Host: "smtp.example.com", Auth: "ntlm"},
+	}
+	for _, c := range invalid {
+		if _, err := NewSMTPSender(c); err == nil {
+			t.Errorf("NewSMTPSender(%+v) should fail", c)
+		}
+	}
+
+	for _, auth := range []string{"plain", "login", "cram-md5", "scram-sha-256", "none"} {
+		c := config.Email{Host: "smtp.example.com", Port: 465, TLSPolicy: "ssl", Auth: auth}
+		if _, err := NewSMTPSender(c); err != nil {
+			t.Errorf("NewSMTPSender() with %s auth error = %v", auth, err)
+		}
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	s, err := NewFileSender(dir)
+	if err != nil {
+		t.Fatalf("NewFileSender() error = %v", err)
+	}
+
+	err = s.Send(context.Background(), &Message{
+		From:    "app@example.com",
+		To:      []string{"to@example.com"},
+		Subject: "Hello",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "new", "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("new/ has %d .eml files, want 1", len(files))
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Subject: Hello",
+		"To: <to@example.com>",
+		"plain body",
+		"html body",
+	} {
+		if !strings.Contains(string(raw), want) {
+			t.Errorf(".eml doesn't contain %q:\n%s", want, raw)
+		}
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Errorf("tmp/ has %d leftover files", len(tmp))
+	}
+
+	if err := s.Send(context.Background(), &Message{From: "bad", To: []string{"x"}}); err == nil {
+		t.Error("Send() with invalid address should fail")
+	}
+}
+
+// fakeTB records assertion failures
+type fakeTB struct{ errors []string }
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMemorySender(t *testing.T) {
+	s := NewMemorySender()
+	client, err := New(s, "app@example.com", logger.New(logger.Config{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.SendTemplateContext(context.Background(), "ana@example.com", "en", VerifyEmailData{
+		Name: "Ana",
+		Link: "https://app.example.com/verify-email?token=abc",
+	})
+	if err != nil {
+		t.Fatalf("SendTemplateContext() error = %v", err)
+	}
+
+	s.AssertCount(t, 1)
+	m := s.AssertSent(t, "ANA@example.com", "Verify your email")
+	if m.From != "app@example.com" || !strings.Contains(m.Text, "token=abc") {
+		t.Errorf("sent message = %+v", m)
+	}
+
+	tb := &fakeTB{}
+	s.AssertCount(tb, 2)
+	s.AssertSent(tb, "ivo@example.com", "Verify your email")
+	if len(tb.errors) != 2 || !strings.Contains(tb.errors[1], "ana@example.com: Verify") {
+		t.Errorf("assertion failures = %q", tb.errors)
+	}
+
+	if err := s.Send(context.Background(), &Message{From: "bad"}); err == nil {
+		t.Error("Send() with invalid sender should fail")
+	}
+	s.Reset()
+	if _, ok := s.Last(); ok {
+		t.Error("Last() after Reset() should be empty")
+	}
+}
+
+func TestMessage_Build(t *testing.T) {
+	m := &Message{
+		From:    "app@example.com",
+		To:      []string{"a@example.com", "b@example.com"},
+		Cc:      []string{"c@example.com"},
+		Bcc:     []string{"hidden@example.com"},
+		ReplyTo: "support@example.com",
+		Headers: map[string]string{"X-Campaign": "spring"},
+		Subject: "Report",
+		HTML:    `<img src="cid:logo">`,
+		Attachments: []Attachment{
+			{Name: "report.csv", Data: []byte("a,b\n1,2\n")},
+			{Name: "logo.png", Data: []byte("png"), ContentID: "logo"},
+		},
+	}
+	msg, err := m.build()
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+
+	var sb strings.Builder
+	if _, err := msg.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	raw := sb.String()
+	for _, want := range []string{
+		"To: <a@example.com>, <b@example.com>",
+		"Cc: <c@example.com>",
+		"Reply-To: <support@example.com>",
+		"X-Campaign: spring",
+		`Content-Type: text/csv; charset=utf-8; name="report.csv"`,
+		`Content-Disposition: attachment; filename="report.csv"`,
+		"Content-Id: <logo>",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("message doesn't contain %q:\n%s", want, raw)
+		}
+	}
+	if strings.Contains(raw, "hidden@example.com") {
+		t.Error("Bcc recipient leaked into headers")
+	}
+	if got := len(m.Recipients()); got != 4 {
+		t.Errorf("Recipients() = %d addresses, want 4", got)
+	}
+
+	invalid := []*Message{
+		{From: "app@example.com"},
+		{From: "app@example.com", To: []string{"a@example.com"}, Cc: []string{"bad"}},
+		{From: "app@example.com", To: []string{"a@example.com"}, Attachments: []Attachment{{}}},
+	}
+	for _, m := range invalid {
+		if _, err := m.build(); err == nil {
+			t.Errorf("build(%+v) should fail", m)
+		}
+	}
+}
+
+func TestAttachFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invoice.pdf")
+	if err := os.WriteFile(path, []byte("%PDF"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := AttachFile(path)
+	if err != nil || a.Name != "invoice.pdf" || string(a.Data) != "%PDF" {
+		t.Errorf("AttachFile() = %+v, %v", a, err)
+	}
+	if a.contentType() != "application/pdf" {
+		t.Errorf("contentType() = %s", a.contentType())
+	}
+	if _, err := AttachFile(path + ".missing"); err == nil {
+		t.Error("AttachFile() of missing file should fail")
+	}
+}
+
+// fakeSMTP accepts every message except recipients listed in reject, replying with their code
+type fakeSMTP struct {
+	ln     net.Listener
+	reject map[string]string
+
+	mu    sync.Mutex
+	conns int
+	sent  int
+}
+
+func newFakeSMTP(t *testing.T, reject map[string]string) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln, reject: reject}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 localhost ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			code := "250 ok"
+			for addr, c := range s.reject {
+				if strings.Contains(cmd, strings.ToUpper(addr)) {
+					code = c + " rejected"
+				}
+			}
+			reply(code)
+		case cmd == "DATA":
+			reply("354 go ahead")
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+			}
+			s.mu.Lock()
+			s.sent++
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) stats() (conns, sent int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns, s.sent
+}
+
+func TestClient_SendBulkContext(t *testing.T) {
+	srv := newFakeSMTP(t, map[string]string{
+		"gone@example.com": "550",
+		"busy@example.com": "451",
+	})
+	addr := srv.ln.Addr().(*net.TCPAddr)
+	sender, err := NewSMTPSender(config.Email{
+		Host:      addr.IP.String(),
+		Port:      addr.Port,
+		TLSPolicy: "none",
+		Auth:      "none",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := New(
+		sender,
+		"app@example.com",
+		logger.New(logger.Config{}),
+		WithRetry(retry.WithMaxAttempts(2), retry.WithInitial(time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to := []string{"a@example.com", "gone@example.com", "busy@example.com", "b@example.com", "bad"}
+	cfgs := make([]*SendEmailConfig, len(to))
+	for i, addr := range to {
+		cfgs[i] = &SendEmailConfig{To: []string{addr}, Subject: "News", AlternativeString: "hi"}
+	}
+
+	errs := client.SendBulkContext(context.Background(), cfgs)
+	for i, wantErr := range []bool{false, true, true, false, true} {
+		if (errs[i] != nil) != wantErr {
+			t.Errorf("errs[%d] (%s) = %v, want error %v", i, to[i], errs[i], wantErr)
+		}
+	}
+	if temporary(errs[1]) || !temporary(errs[2]) {
+		t.Errorf("550 temporary = %v, 451 temporary = %v", temporary(errs[1]), temporary(errs[2]))
+	}
+
+	// first batch shares one connection, retry of busy@ opens second one
+	if conns, sent := srv.stats(); conns != 2 || sent != 2 {
+		t.Errorf("server saw %d connections and %d messages, want 2 and 2", conns, sent)
+	}
+}
+
+func TestSendBatch_Fallback(t *testing.T) {
+	s := NewMemorySender()
+	errs := sendBatch(context.Background(), s, []*Message{
+		{From: "app@example.com", To: []string{"a@example.com"}},
+		{From: "app@example.com"},
+		{From: "app@example.com", Bcc: []string{"b@example.com"}},
+	})
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Errorf("sendBatch() = %v", errs)
+	}
+	s.AssertSent(t, "b@example.com", "")
+	s.AssertCount(t, 2)
+}
@@ -0,0 +1,145 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrSuppressed Every recipient of email is on suppression list
+var ErrSuppressed = errors.New("email: all recipients are suppressed")
+
+// errLookup suppression list couldn't be read, sending can succeed later
+var errLookup = errors.New("email: suppression lookup")
+
+// Reason Why address is suppressed
+type Reason string
+
+const (
+	ReasonBounce      Reason = "bounce"
+	ReasonComplaint   Reason = "complaint"
+	ReasonUnsubscribe Reason = "unsubscribe"
+)
+
+// Suppressions Addresses emails aren't sent to, compared case-insensitively
+type Suppressions interface {
+	// Suppressed returns reasons of suppressed addrs keyed by addrKey
+	Suppressed(ctx context.Context, addrs []string) (map[string]Reason, error)
+	Suppress(ctx context.Context, addr string, reason Reason, detail string) error
+	Remove(ctx context.Context, addr string) error
+}
+
+// PostgresSuppressions Keeps suppressions in email_suppressions table, created by migrations
+type PostgresSuppressions struct {
+	db *sqlx.DB
+}
+
+func NewPostgresSuppressions(db *sqlx.DB) *PostgresSuppressions {
+	return &PostgresSuppressions{db: db}
+}
+
+var _ Suppressions = (*PostgresSuppressions)(nil)
+
+// Suppressed implements Suppressions
+func (s *PostgresSuppressions) Suppressed(
+	ctx context.Context,
+	addrs []string,
+) (map[string]Reason, error) {
+	keys := make([]string, len(addrs))
+	for i, addr := range addrs {
+		keys[i] = addrKey(addr)
+	}
+
+	var rows []struct {
+		Address string `db:"address"`
+		Reason  Reason `db:"reason"`
+	}
+	err := s.db.SelectContext(
+		ctx,
+		&rows,
+		`SELECT address, reason FROM email_suppressions WHERE address = ANY($1)`,
+		keys,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("email: suppressed: %w", err)
+	}
+
+	reasons := make(map[string]Reason, len(rows))
+	for _, r := range rows {
+		reasons[r.Address] = r.Reason
+	}
+	return reasons, nil
+}
+
+// Suppress implements Suppressions, suppressed address gets the new reason
+func (s *PostgresSuppressions) Suppress(
+	ctx context.Context,
+	addr string,
+	reason Reason,
+	detail string,
+) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO email_suppressions (address, reason, detail) VALUES ($1, $2, $3)
+ON CONFLICT (address) DO UPDATE SET
+	reason = EXCLUDED.reason, detail = EXCLUDED.detail, created_at = now()`,
+		addrKey(addr), reason, detail,
+	)
+	if err != nil {
+		return fmt.Errorf("email: suppress: %w", err)
+	}
+	return nil
+}
+
+// Remove implements Suppressions
+func (s *PostgresSuppressions) Remove(ctx context.Context, addr string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`DELETE FROM email_suppressions WHERE address = $1`,
+		addrKey(addr),
+	)
+	if err != nil {
+		return fmt.Errorf("email: remove suppression: %w", err)
+	}
+	return nil
+}
+
+// suppress removes suppressed recipients from m and returns their reasons, ErrSuppressed
+// when none is left
+func suppress(ctx context.Context, list Suppressions, m *Message) (map[string]Reason, error) {
+	reasons, err := list.Suppressed(ctx, m.Recipients())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errLookup, err)
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	keep := func(addrs []string) []string {
+		var kept []string
+		for _, addr := range addrs {
+			if _, ok := reasons[addrKey(addr)]; !ok {
+				kept = append(kept, addr)
+			}
+		}
+		return kept
+	}
+	m.To, m.Cc, m.Bcc = keep(m.To), keep(m.Cc), keep(m.Bcc)
+
+	if len(m.Recipients()) == 0 {
+		return reasons, ErrSuppressed
+	}
+	return reasons, nil
+}
+
+// addrKey returns lowercase address without display name, "Ana <Ana@Example.com>" becomes
+// ana@example.com
+func addrKey(addr string) string {
+	if a, err := mail.ParseAddress(addr); err == nil {
+		addr = a.Address
+	}
+	return strings.ToLower(addr)
+}
@@ -0,0 +1,270 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/lomifile/api/config"
+	"github.com/lomifile/api/migrations"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/retry"
+)
+
+// memSuppressions Suppressions kept in map, err fails lookups
+type memSuppressions struct {
+	mu      sync.Mutex
+	reasons map[string]Reason
+	err     error
+}
+
+func (s *memSuppressions) Suppressed(_ context.Context, addrs []string) (map[string]Reason, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	found := map[string]Reason{}
+	for _, addr := range addrs {
+		if r, ok := s.reasons[addrKey(addr)]; ok {
+			found[addrKey(addr)] = r
+		}
+	}
+	return found, nil
+}
+
+func (s *memSuppressions) Suppress(_ context.Context, addr string, r Reason, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.reasons == nil {
+		s.reasons = map[string]Reason{}
+	}
+	s.reasons[addrKey(addr)] = r
+	return nil
+}
+
+func (s *memSuppressions) Remove(_ context.Context, addr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.reasons, addrKey(addr))
+	return nil
+}
+
+func (s *memSuppressions) reason(addr string) Reason {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reasons[addr]
+}
+
+func TestClient_Suppressions(t *testing.T) {
+	list := &memSuppressions{reasons: map[string]Reason{"gone@example.com": ReasonBounce}}
+	sender := NewMemorySender()
+	client, err := New(
+		sender,
+		"app@example.com",
+		logger.New(logger.Config{}),
+		WithSuppressions(list),
+		WithRetry(retry.WithMaxAttempts(2), retry.WithInitial(time.Millisecond)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cfg := &SendEmailConfig{
+		To:      []string{"ana@example.com"},
+		Cc:      []string{"Gone <GONE@example.com>"},
+		Subject: "Hello",
+	}
+	if err := client.SendHTMLEmailContext(ctx, cfg); err != nil {
+		t.Fatalf("SendHTMLEmailContext() error = %v", err)
+	}
+	if m, _ := sender.Last(); len(m.Cc) != 0 || len(cfg.Cc) != 1 {
+		t.Errorf("suppressed Cc sent = %v, config Cc = %v", m.Cc, cfg.Cc)
+	}
+
+	cfg = &SendEmailConfig{To: []string{"gone@example.com"}, Subject: "Hello"}
+	if err := client.SendHTMLEmailContext(ctx, cfg); !errors.Is(err, ErrSuppressed) {
+		t.Errorf("SendHTMLEmailContext() to suppressed = %v, want ErrSuppressed", err)
+	}
+	sender.AssertCount(t, 1)
+
+	renderer, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = renderer.Render("en", ResetPasswordData{Name: "Gone", Link: "https://x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.To = []string{"gone@example.com"}
+	if err := client.SendHTMLEmailContext(ctx, cfg); err != nil {
+		t.Errorf("transactional email to suppressed = %v, want sent", err)
+	}
+	sender.AssertCount(t, 2)
+
+	list.err = errors.New("connection refused")
+	err = client.SendHTMLEmailContext(ctx, &SendEmailConfig{To: []string{"ana@example.com"}})
+	if !temporary(err) {
+		t.Errorf("failed lookup = %v, want temporary error", err)
+	}
+}
+
+func TestClient_Unsubscribe(t *testing.T) {
+	u := NewUnsubscriber("secret", "https://api.example.com/email/unsubscribe", nil)
+	sender := NewMemorySender()
+	client, err := New(sender, "app@example.com", logger.New(logger.Config{}), WithUnsubscribe(u))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	cfg := &SendEmailConfig{
+		To:      []string{"ana@example.com"},
+		Headers: map[string]string{"X-Campaign": "spring"},
+	}
+	if err := client.SendHTMLEmailContext(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	m, _ := sender.Last()
+	link := m.Headers["List-Unsubscribe"]
+	if !strings.HasPrefix(link, "<https://api.example.com/email/unsubscribe?token=") ||
+		m.Headers["List-Unsubscribe-Post"] != "List-Unsubscribe=One-Click" ||
+		m.Headers["X-Campaign"] != "spring" {
+		t.Errorf("headers = %v", m.Headers)
+	}
+	if len(cfg.Headers) != 1 {
+		t.Errorf("config headers changed: %v", cfg.Headers)
+	}
+
+	cfg = &SendEmailConfig{To: []string{"ana@example.com", "ivo@example.com"}}
+	if err := client.SendHTMLEmailContext(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := sender.Last(); m.Headers["List-Unsubscribe"] != "" {
+		t.Error("email with more recipients shouldn't get List-Unsubscribe")
+	}
+
+	cfg = &SendEmailConfig{To: []string{"ana@example.com"}, Transactional: true}
+	if err := client.SendHTMLEmailContext(ctx, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if m, _ := sender.Last(); m.Headers["List-Unsubscribe"] != "" {
+		t.Error("transactional email shouldn't get List-Unsubscribe")
+	}
+}
+
+func TestClient_SuppressesBounces(t *testing.T) {
+	srv := newFakeSMTP(t, map[string]string{
+		"gone@example.com": "550",
+		"busy@example.com": "451",
+	})
+	addr := srv.ln.Addr().(*net.TCPAddr)
+	sender, err := NewSMTPSender(config.Email{
+		Host:      addr.IP.String(),
+		Port:      addr.Port,
+		TLSPolicy: "none",
+		Auth:      "none",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := &memSuppressions{}
+	client, err := New(
+		sender,
+		"app@example.com",
+		logger.New(logger.Config{}),
+		WithSuppressions(list),
+		WithRetry(retry.WithMaxAttempts(1)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, to := range []string{"gone@example.com", "busy@example.com"} {
+		cfg := &SendEmailConfig{To: []string{to}, AlternativeString: "hi"}
+		if err := client.SendHTMLEmailContext(context.Background(), cfg); err == nil {
+			t.Errorf("SendHTMLEmailContext() to %s should fail", to)
+		}
+	}
+	if list.reason("gone@example.com") != ReasonBounce {
+		t.Error("permanently rejected recipient should be suppressed as bounce")
+	}
+	if list.reason("busy@example.com") != "" {
+		t.Error("temporarily rejected recipient shouldn't be suppressed")
+	}
+}
+
+func TestAddrKey(t *testing.T) {
+	for in, want := range map[string]string{
+		"Ana@Example.com":         "ana@example.com",
+		"Ana <Ana@Example.com>":   "ana@example.com",
+		"not an address":          "not an address",
+		`"Kovač, Ivo" <ivo@x.hr>`: "ivo@x.hr",
+	} {
+		if got := addrKey(in); got != want {
+			t.Errorf("addrKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestPostgresSuppressions runs against real database when TEST_DATABASE_URL is set
+func TestPostgresSuppressions(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	db, err := sqlx.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	// temp tables are per connection
+	db.SetMaxOpenConns(1)
+
+	schema, err := migrations.FS.ReadFile("20250104000000_create_email_suppressions.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddl := strings.Replace(
+		string(schema),
+		"CREATE TABLE email_suppressions",
+		"CREATE TEMP TABLE email_suppressions",
+		1,
+	)
+	if _, err := db.ExecContext(ctx, ddl); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewPostgresSuppressions(db)
+	if err := s.Suppress(ctx, "Ana <ANA@example.com>", ReasonBounce, "550"); err != nil {
+		t.Fatalf("Suppress() error = %v", err)
+	}
+	if err := s.Suppress(ctx, "ana@example.com", ReasonComplaint, ""); err != nil {
+		t.Fatalf("Suppress() again error = %v", err)
+	}
+
+	got, err := s.Suppressed(ctx, []string{"Ana@Example.com", "ivo@example.com"})
+	if err != nil || len(got) != 1 || got["ana@example.com"] != ReasonComplaint {
+		t.Errorf("Suppressed() = %v, %v, want ana as complaint", got, err)
+	}
+
+	if err := s.Remove(ctx, "ANA@example.com"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got, _ := s.Suppressed(ctx, []string{"ana@example.com"}); len(got) != 0 {
+		t.Errorf("Suppressed() after Remove() = %v", got)
+	}
+}
@@ -0,0 +1,267 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const _defaultLocale = "en"
+
+//go:embed templates
+var _templates embed.FS
+
+// TemplateData Typed data of one template, TemplateName names directory of its locale
+// variants, e.g. templates/verify_email/en.html
+type TemplateData interface {
+	TemplateName() string
+}
+
+// TransactionalData Data of template recipient asked for, its emails are sent even to
+// suppressed addresses and have no unsubscribe headers
+type TransactionalData interface {
+	TemplateData
+	Transactional() bool
+}
+
+// VerifyEmailData Data of email address verification email
+type VerifyEmailData struct {
+	Name string
+	Link string
+}
+
+// TemplateName implements TemplateData
+func (VerifyEmailData) TemplateName() string { return "verify_email" }
+
+// Transactional implements TransactionalData
+func (VerifyEmailData) Transactional() bool { return true }
+
+// ResetPasswordData Data of password reset email
+type ResetPasswordData struct {
+	Name      string
+	Link      string
+	ExpiresIn time.Duration
+}
+
+// TemplateName implements TemplateData
+func (ResetPasswordData) TemplateName() string { return "reset_password" }
+
+// Transactional implements TransactionalData
+func (ResetPasswordData) Transactional() bool { return true }
+
+// RendererOption Provides function to renderer options
+type RendererOption func(*Renderer)
+
+// WithTemplates replaces embedded templates, fsys must have the same layout:
+// layouts/*.html, partials/*.html and <template>/<locale>.html
+func WithTemplates(fsys fs.FS) RendererOption { return func(r *Renderer) { r.fsys = fsys } }
+
+// WithDefaultLocale sets locale used when requested one has no variant, default "en"
+func WithDefaultLocale(locale string) RendererOption {
+	return func(r *Renderer) { r.defaultLocale = locale }
+}
+
+// Renderer Renders email templates to subject, HTML with inlined CSS and plain text.
+// Every locale variant defines "subject" and "content", rendered inside "layout", and may
+// define "text" to replace plain text generated from HTML.
+type Renderer struct {
+	fsys          fs.FS
+	defaultLocale string
+	// templates by template name and locale
+	templates map[string]map[string]*template.Template
+}
+
+// NewRenderer parses templates, embedded ones unless WithTemplates is used
+func NewRenderer(opts ...RendererOption) (*Renderer, error) {
+	sub, err := fs.Sub(_templates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	r := &Renderer{
+		fsys:          sub,
+		defaultLocale: _defaultLocale,
+		templates:     make(map[string]map[string]*template.Template),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if err := r.parse(); err != nil {
+		return nil, fmt.Errorf("email: templates: %w", err)
+	}
+	return r, nil
+}
+
+func (r *Renderer) parse() error {
+	shared, err := fs.Glob(r.fsys, "layouts/*.html")
+	if err != nil {
+		return err
+	}
+	partials, err := fs.Glob(r.fsys, "partials/*.html")
+	if err != nil {
+		return err
+	}
+	shared = append(shared, partials...)
+
+	variants, err := fs.Glob(r.fsys, "*/*.html")
+	if err != nil {
+		return err
+	}
+	for _, file := range variants {
+		name := path.Dir(file)
+		if name == "layouts" || name == "partials" {
+			continue
+		}
+		locale := strings.TrimSuffix(path.Base(file), ".html")
+
+		// locale variant is parsed last so its definitions override shared ones
+		t, err := template.New(file).
+			Funcs(templateFuncs(locale)).
+			ParseFS(r.fsys, append(shared, file)...)
+		if err != nil {
+			return err
+		}
+		for _, required := range []string{"layout", "subject", "content"} {
+			if t.Lookup(required) == nil {
+				return fmt.Errorf("%s: %q is not defined", file, required)
+			}
+		}
+
+		if r.templates[name] == nil {
+			r.templates[name] = make(map[string]*template.Template)
+		}
+		r.templates[name][locale] = t
+	}
+
+	for name, locales := range r.templates {
+		if locales[r.defaultLocale] == nil {
+			return fmt.Errorf("%s has no %s variant", name, r.defaultLocale)
+		}
+	}
+	return nil
+}
+
+func templateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"locale": func() string { return locale },
+		"dict": func(pairs ...any) (map[string]any, error) {
+			if len(pairs)%2 != 0 {
+				return nil, errors.New("dict needs key value pairs")
+			}
+			m := make(map[string]any, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				key, ok := pairs[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("dict key %v is not string", pairs[i])
+				}
+				m[key] = pairs[i+1]
+			}
+			return m, nil
+		},
+		"duration": formatDuration,
+	}
+}
+
+// formatDuration prints d without zero units, e.g. 1h or 1h30m
+func formatDuration(d time.Duration) string {
+	s := d.Round(time.Minute).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// Templates returns template names
+func (r *Renderer) Templates() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales returns locales name is available in
+func (r *Renderer) Locales(name string) []string {
+	locales := make([]string, 0, len(r.templates[name]))
+	for locale := range r.templates[name] {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render renders data's template in locale, returned config has no recipient. Locale falls
+// back from region to language and then to default, so "hr-HR" uses "hr" variant.
+func (r *Renderer) Render(locale string, data TemplateData) (*SendEmailConfig, error) {
+	t, err := r.lookup(data.TemplateName(), locale)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, body bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("email: render subject: %w", err)
+	}
+	if err := t.ExecuteTemplate(&body, "layout", data); err != nil {
+		return nil, fmt.Errorf("email: render %s: %w", data.TemplateName(), err)
+	}
+
+	htmlBody, err := inlineCSS(body.String())
+	if err != nil {
+		return nil, fmt.Errorf("email: inline css: %w", err)
+	}
+
+	text, err := htmlToText(htmlBody)
+	if err != nil {
+		return nil, fmt.Errorf("email: plain text: %w", err)
+	}
+	if t.Lookup("text") != nil {
+		var b bytes.Buffer
+		if err := t.ExecuteTemplate(&b, "text", data); err != nil {
+			return nil, fmt.Errorf("email: render text: %w", err)
+		}
+		text = html.UnescapeString(strings.TrimSpace(b.String())) + "\n"
+	}
+
+	cfg := &SendEmailConfig{
+		Subject:           html.UnescapeString(strings.TrimSpace(subject.String())),
+		HTML:              htmlBody,
+		AlternativeString: text,
+	}
+	if td, ok := data.(TransactionalData); ok {
+		cfg.Transactional = td.Transactional()
+	}
+	return cfg, nil
+}
+
+func (r *Renderer) lookup(name, locale string) (*template.Template, error) {
+	locales, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("email: unknown template %q", name)
+	}
+
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	for locale != "" {
+		if t, ok := locales[locale]; ok {
+			return t, nil
+		}
+		i := strings.LastIndex(locale, "-")
+		if i < 0 {
+			break
+		}
+		locale = locale[:i]
+	}
+	return locales[r.defaultLocale], nil
+}
@@ -0,0 +1,223 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	msg, err := r.Render("en", VerifyEmailData{
+		Name: "Ana & Ivo",
+		Link: "https://app.example.com/verify-email?token=abc",
+	})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	if msg.Subject != "Verify your email" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	for _, want := range []string{
+		"Hi Ana &amp; Ivo,",
+		`href="https://app.example.com/verify-email?token=abc"`,
+		`class="button" href="https://app.example.com/verify-email?token=abc" ` +
+			`style="display: inline-block;`,
+		`<html lang="en">`,
+		"@media (max-width: 600px)",
+	} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("HTML doesn't contain %q:\n%s", want, msg.HTML)
+		}
+	}
+	for _, want := range []string{
+		"Hi Ana & Ivo,\n\nConfirm your email address",
+		"Verify email: https://app.example.com/verify-email?token=abc",
+	} {
+		if !strings.Contains(msg.AlternativeString, want) {
+			t.Errorf("text doesn't contain %q:\n%s", want, msg.AlternativeString)
+		}
+	}
+	if strings.Contains(msg.AlternativeString, "font-family") {
+		t.Errorf("text contains CSS:\n%s", msg.AlternativeString)
+	}
+}
+
+func TestRenderer_Locale(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := ResetPasswordData{Name: "Ana", Link: "https://x", ExpiresIn: time.Hour}
+
+	tests := []struct {
+		locale, subject string
+	}{
+		{"hr", "Promjena lozinke"},
+		{"hr-HR", "Promjena lozinke"},
+		{"hr_HR", "Promjena lozinke"},
+		{"de", "Reset your password"},
+		{"", "Reset your password"},
+	}
+	for _, tt := range tests {
+		msg, err := r.Render(tt.locale, data)
+		if err != nil {
+			t.Fatalf("Render(%q) error = %v", tt.locale, err)
+		}
+		if msg.Subject != tt.subject {
+			t.Errorf("Render(%q) subject = %q, want %q", tt.locale, msg.Subject, tt.subject)
+		}
+	}
+
+	msg, _ := r.Render("hr", data)
+	if !strings.Contains(msg.HTML, "vrijedi 1h.") || !strings.Contains(msg.HTML, "vašem računu") {
+		t.Errorf("hr variant should render duration and override footer:\n%s", msg.HTML)
+	}
+	if got := r.Locales("reset_password"); strings.Join(got, ",") != "en,hr" {
+		t.Errorf("Locales() = %v", got)
+	}
+}
+
+func TestRenderer_CustomTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`{{define "layout"}}<html><body>` +
+			`{{template "content" .}}</body></html>{{end}}`)},
+		"verify_email/en.html": {Data: []byte(`{{define "subject"}}Welcome{{end}}` +
+			`{{define "content"}}<p>{{.Name}}</p>{{end}}` +
+			`{{define "text"}}Plain {{.Name}}{{end}}`)},
+	}
+	r, err := NewRenderer(WithTemplates(fsys))
+	if err != nil {
+		t.Fatalf("NewRenderer() error = %v", err)
+	}
+
+	msg, err := r.Render("en", VerifyEmailData{Name: "O'Brien"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.AlternativeString != "Plain O'Brien\n" {
+		t.Errorf("text template not used: %q", msg.AlternativeString)
+	}
+
+	if _, err := r.Render("en", ResetPasswordData{}); err == nil {
+		t.Error("Render() of unknown template should fail")
+	}
+}
+
+func TestNewRenderer_Invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing subject": {
+			"layouts/base.html":    {Data: []byte(`{{define "layout"}}{{end}}`)},
+			"verify_email/en.html": {Data: []byte(`{{define "content"}}{{end}}`)},
+		},
+		"missing default locale": {
+			"layouts/base.html": {Data: []byte(`{{define "layout"}}{{end}}`)},
+			"verify_email/hr.html": {Data: []byte(
+				`{{define "subject"}}{{end}}{{define "content"}}{{end}}`,
+			)},
+		},
+		"syntax": {
+			"layouts/base.html": {Data: []byte(`{{define "layout"}}{{end`)},
+			"verify_email/en.html": {Data: []byte(
+				`{{define "subject"}}{{end}}{{define "content"}}{{end}}`,
+			)},
+		},
+	}
+	for name, fsys := range tests {
+		if _, err := NewRenderer(WithTemplates(fsys)); err == nil {
+			t.Errorf("NewRenderer() with %s should fail", name)
+		}
+	}
+}
+
+func TestInlineCSS(t *testing.T) {
+	doc := `<html><head><style>
+p { color: red; margin: 0 }
+.note { color: blue }
+p.note, #main { font-weight: bold }
+div p { color: green }
+@media (max-width: 600px) { p { margin: 4px } }
+</style></head><body>
+<p>plain</p><p class="note" style="margin: 8px">note</p><div id="main"></div>
+</body></html>`
+
+	got, err := inlineCSS(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<p style="color: red; margin: 0">plain</p>`,
+		`<p class="note" style="color: blue; font-weight: bold; margin: 8px">note</p>`,
+		`<div id="main" style="font-weight: bold"></div>`,
+		"div p { color: green }",
+		"@media (max-width: 600px) { p { margin: 4px } }",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("inlineCSS() doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	doc := `<html><head><title>T</title><style>p{}</style></head><body>
+<h1>Title</h1><p>Hello   <b>there</b>,<br>second line</p>
+<ul><li>one</li><li>two</li></ul>
+<p><a href="https://x.example/a">Open</a> or
+<a href="https://x.example/b">https://x.example/b</a></p>
+</body></html>`
+
+	got, err := htmlToText(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Title\n\nHello there,\nsecond line\n\n- one\n- two\n\n" +
+		"Open: https://x.example/a or https://x.example/b\n"
+	if got != want {
+		t.Errorf("htmlToText() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		time.Hour:                    "1h",
+		24 * time.Hour:               "24h",
+		90 * time.Minute:             "1h30m",
+		15 * time.Minute:             "15m",
+		15*time.Minute + time.Second: "15m",
+	}
+	for d, want := range tests {
+		if got := formatDuration(d); got != want {
+			t.Errorf("formatDuration(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+type unknownData struct{}
+
+func (unknownData) TemplateName() string { return "unknown" }
+
+func TestClient_SendTemplateContext(t *testing.T) {
+	r, err := NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{templates: r, from: "not an address", sender: NewMemorySender()}
+	ctx := context.Background()
+
+	err = client.SendTemplateContext(ctx, "to@example.com", "en", unknownData{})
+	if err == nil || !strings.Contains(err.Error(), "unknown template") {
+		t.Errorf("SendTemplateContext() unknown template error = %v", err)
+	}
+	// rendered email reaches sending, which rejects the sender
+	err = client.SendTemplateContext(ctx, "to@example.com", "en", VerifyEmailData{})
+	if err == nil || strings.Contains(err.Error(), "template") {
+		t.Errorf("SendTemplateContext() error = %v, want send error", err)
+	}
+}
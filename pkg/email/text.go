@@ -0,0 +1,93 @@
+package email
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	_blockTags = map[string]bool{
+		"p": true, "div": true, "table": true, "tr": true, "ul": true, "ol": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+		"blockquote": true, "hr": true,
+	}
+	_skipTags = map[string]bool{"head": true, "style": true, "script": true, "title": true}
+
+	_whitespace = regexp.MustCompile(`\s+`)
+	_spaces     = regexp.MustCompile(`[ \t\r\f\v]+`)
+	_blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText renders plain text alternative of HTML email: paragraphs separated by blank
+// lines, list items prefixed with "- " and link targets written after link text
+func htmlToText(doc string) (string, error) {
+	root, err := html.Parse(strings.NewReader(doc))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	writeText(&b, root)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(_spaces.ReplaceAllString(line, " "))
+	}
+	text := _blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+
+	return strings.TrimSpace(text) + "\n", nil
+}
+
+func writeText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		// lines are trimmed later, so collapsed space at line start doesn't matter
+		b.WriteString(_whitespace.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+		if _skipTags[n.Data] {
+			return
+		}
+		switch {
+		case n.Data == "br":
+			b.WriteByte('\n')
+			return
+		case n.Data == "li":
+			b.WriteString("\n- ")
+		case _blockTags[n.Data]:
+			b.WriteString("\n\n")
+		}
+	}
+
+	if n.Type == html.ElementNode && n.Data == "a" {
+		writeLink(b, n)
+	} else {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeText(b, c)
+		}
+	}
+
+	if n.Type == html.ElementNode && _blockTags[n.Data] {
+		b.WriteString("\n\n")
+	}
+}
+
+func writeLink(b *strings.Builder, n *html.Node) {
+	var inner strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(&inner, c)
+	}
+	text := strings.TrimSpace(inner.String())
+	href := attr(n, "href")
+
+	switch {
+	case href == "" || href == text || strings.HasPrefix(href, "#"):
+		b.WriteString(text)
+	case text == "":
+		b.WriteString(href)
+	default:
+		b.WriteString(text + ": " + href)
+	}
+}
@@ -0,0 +1,88 @@
+package email
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidToken Unsubscribe token is malformed or wasn't signed with our secret
+var ErrInvalidToken = errors.New("email: invalid unsubscribe token")
+
+// Unsubscriber Creates one-click unsubscribe links (RFC 8058) and suppresses addresses
+// whose link was used
+type Unsubscriber struct {
+	key  []byte
+	url  string
+	list Suppressions
+}
+
+// NewUnsubscriber creates unsubscriber for endpoint at url, links are signed with key
+// derived from secret
+func NewUnsubscriber(secret, url string, list Suppressions) *Unsubscriber {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("email-unsubscribe"))
+
+	return &Unsubscriber{key: mac.Sum(nil), url: url, list: list}
+}
+
+// Token returns token identifying addr, it doesn't expire
+func (u *Unsubscriber) Token(addr string) string {
+	addr = addrKey(addr)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(addr)) + "." + enc.EncodeToString(u.sign(addr))
+}
+
+// Link returns unsubscribe link of addr
+func (u *Unsubscriber) Link(addr string) string {
+	return u.url + "?" + url.Values{"token": {u.Token(addr)}}.Encode()
+}
+
+// Headers returns List-Unsubscribe headers of email sent to addr
+func (u *Unsubscriber) Headers(addr string) map[string]string {
+	return map[string]string{
+		"List-Unsubscribe":      "<" + u.Link(addr) + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+}
+
+// Address returns address token was created for
+func (u *Unsubscriber) Address(token string) (string, error) {
+	rawAddr, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	addr, err := base64.RawURLEncoding.DecodeString(rawAddr)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(rawSig)
+	if err != nil || !hmac.Equal(sig, u.sign(string(addr))) {
+		return "", ErrInvalidToken
+	}
+
+	return string(addr), nil
+}
+
+// Unsubscribe suppresses address token was created for and returns it
+func (u *Unsubscriber) Unsubscribe(ctx context.Context, token string) (string, error) {
+	addr, err := u.Address(token)
+	if err != nil {
+		return "", err
+	}
+	if err := u.list.Suppress(ctx, addr, ReasonUnsubscribe, "one-click"); err != nil {
+		return "", err
+	}
+
+	return addr, nil
+}
+
+func (u *Unsubscriber) sign(addr string) []byte {
+	mac := hmac.New(sha256.New, u.key)
+	mac.Write([]byte(addr))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,44 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestUnsubscriber(t *testing.T) {
+	list := &memSuppressions{}
+	u := NewUnsubscriber("secret", "https://api.example.com/email/unsubscribe", list)
+
+	token := u.Token("Ana <Ana@Example.com>")
+	if addr, err := u.Address(token); err != nil || addr != "ana@example.com" {
+		t.Errorf("Address() = %q, %v", addr, err)
+	}
+
+	link, err := url.Parse(u.Link("ana@example.com"))
+	if err != nil || link.Query().Get("token") != token {
+		t.Errorf("Link() = %v, %v", link, err)
+	}
+
+	other := NewUnsubscriber("other", "", list)
+	forged := strings.Replace(token, token[:4], "aXZv", 1)
+	for name, bad := range map[string]string{
+		"empty":          "",
+		"no signature":   strings.Split(token, ".")[0],
+		"bad encoding":   "!!!." + strings.Split(token, ".")[1],
+		"changed":        forged,
+		"other secret":   other.Token("ana@example.com"),
+		"signature only": "." + strings.Split(token, ".")[1],
+	} {
+		if _, err := u.Address(bad); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Address(%s) = %v, want ErrInvalidToken", name, err)
+		}
+	}
+
+	addr, err := u.Unsubscribe(context.Background(), token)
+	if err != nil || list.reason(addr) != ReasonUnsubscribe {
+		t.Errorf("Unsubscribe() = %q, %v, suppressed as %q", addr, err, list.reason(addr))
+	}
+}
@@ -0,0 +1,224 @@
+// Package health provides registry of dependency health checks
+package health
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const _defaultTimeout = 2 * time.Second
+
+// ErrDraining returned by readiness while server is shutting down
+var ErrDraining = errors.New("health: server is draining")
+
+// Status Result of single check or whole report
+type Status string
+
+const (
+	// StatusUp check passed
+	StatusUp Status = "up"
+	// StatusDown check failed
+	StatusDown Status = "down"
+	// StatusDegraded optional check failed, overall status is still healthy
+	StatusDegraded Status = "degraded"
+)
+
+// Scope Which endpoint runs the check
+type Scope int
+
+const (
+	// Readiness checks dependencies needed to serve traffic, default scope
+	Readiness Scope = iota
+	// Liveness checks process can make progress, failure means restart
+	Liveness
+)
+
+// Check Probe function, nil error means healthy
+type Check func(ctx context.Context) error
+
+// Pinger Anything with Ping, e.g. *pgxpool.Pool
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck wraps Pinger into Check
+func PingCheck(p Pinger) Check {
+	return p.Ping
+}
+
+// Result Outcome of single check
+type Result struct {
+	Status    Status `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report Aggregated outcome of checks
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Healthy reports whether report should be served with 200
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+type entry struct {
+	name     string
+	check    Check
+	timeout  time.Duration
+	scope    Scope
+	optional bool
+}
+
+// CheckOption Provides function to check options
+type CheckOption func(*entry)
+
+// WithTimeout overrides default 2s check timeout
+func WithTimeout(d time.Duration) CheckOption { return func(e *entry) { e.timeout = d } }
+
+// WithScope sets which endpoint runs the check
+func WithScope(s Scope) CheckOption { return func(e *entry) { e.scope = s } }
+
+// Optional marks check as non critical, failure reports degraded instead of down
+func Optional() CheckOption { return func(e *entry) { e.optional = true } }
+
+// Registry Holds registered checks
+type Registry struct {
+	mu       sync.RWMutex
+	entries  []entry
+	draining atomic.Bool
+}
+
+// New creates empty registry
+func New() *Registry {
+	return &Registry{}
+}
+
+// Register adds named check, registering the same name twice replaces the check
+func (r *Registry) Register(name string, check Check, opts ...CheckOption) {
+	e := entry{name: name, check: check, timeout: _defaultTimeout, scope: Readiness}
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.entries {
+		if r.entries[i].name == name {
+			r.entries[i] = e
+			return
+		}
+	}
+	r.entries = append(r.entries, e)
+}
+
+// Drain makes readiness fail, called when server starts shutting down
+func (r *Registry) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain was called
+func (r *Registry) Draining() bool {
+	return r.draining.Load()
+}
+
+// Health runs every registered check
+func (r *Registry) Health(ctx context.Context) Report {
+	return r.run(ctx, func(entry) bool { return true }, false)
+}
+
+// Ready runs readiness checks and fails while draining
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.run(ctx, func(e entry) bool { return e.scope == Readiness }, true)
+}
+
+// Live runs liveness checks, with none registered process is considered alive
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, func(e entry) bool { return e.scope == Liveness }, false)
+}
+
+func (r *Registry) run(ctx context.Context, filter func(entry) bool, withDrain bool) Report {
+	r.mu.RLock()
+	selected := make([]entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if filter(e) {
+			selected = append(selected, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(selected, func(i, j int) bool { return selected[i].name < selected[j].name })
+
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, e := range selected {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runOne(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(selected)+1)}
+	for i, e := range selected {
+		report.Checks[e.name] = results[i]
+		report.Status = worst(report.Status, results[i].Status)
+	}
+
+	if withDrain && r.Draining() {
+		report.Checks["shutdown"] = Result{Status: StatusDown, Error: ErrDraining.Error()}
+		report.Status = StatusDown
+	}
+
+	return report
+}
+
+func runOne(ctx context.Context, e entry) Result {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				errCh <- errors.New("health: check panicked")
+			}
+		}()
+		errCh <- e.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{Status: StatusUp, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		res.Error = err.Error()
+		res.Status = StatusDown
+		if e.optional {
+			res.Status = StatusDegraded
+		}
+	}
+
+	return res
+}
+
+func worst(a, b Status) Status {
+	rank := map[Status]int{StatusUp: 0, StatusDegraded: 1, StatusDown: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("boom") }
+
+func TestRegistry_Empty(t *testing.T) {
+	r := New()
+
+	for name, report := range map[string]Report{
+		"health": r.Health(context.Background()),
+		"ready":  r.Ready(context.Background()),
+		"live":   r.Live(context.Background()),
+	} {
+		if report.Status != StatusUp || !report.Healthy() {
+			t.Errorf("%s status = %v, want up", name, report.Status)
+		}
+	}
+}
+
+func TestRegistry_Statuses(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *Registry)
+		want  Status
+	}{
+		{"all up", func(r *Registry) {
+			r.Register("db", ok)
+			r.Register("smtp", ok)
+		}, StatusUp},
+		{"critical down", func(r *Registry) {
+			r.Register("db", fail)
+			r.Register("smtp", ok)
+		}, StatusDown},
+		{"optional down", func(r *Registry) {
+			r.Register("db", ok)
+			r.Register("smtp", fail, Optional())
+		}, StatusDegraded},
+		{"optional and critical down", func(r *Registry) {
+			r.Register("db", fail)
+			r.Register("smtp", fail, Optional())
+		}, StatusDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New()
+			tt.setup(r)
+
+			report := r.Health(context.Background())
+			if report.Status != tt.want {
+				t.Errorf("Status = %v, want %v", report.Status, tt.want)
+			}
+			if len(report.Checks) != 2 {
+				t.Errorf("len(Checks) = %d, want 2", len(report.Checks))
+			}
+		})
+	}
+}
+
+func TestRegistry_ResultDetails(t *testing.T) {
+	r := New()
+	r.Register("db", fail)
+
+	res := r.Health(context.Background()).Checks["db"]
+	if res.Status != StatusDown {
+		t.Errorf("Status = %v, want down", res.Status)
+	}
+	if res.Error != "boom" {
+		t.Errorf("Error = %v, want boom", res.Error)
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := New()
+	r.Register("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, WithTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	report := r.Health(context.Background())
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("check timeout not enforced")
+	}
+	if report.Checks["slow"].Status != StatusDown {
+		t.Errorf("slow status = %v, want down", report.Checks["slow"].Status)
+	}
+}
+
+func TestRegistry_Panic(t *testing.T) {
+	r := New()
+	r.Register("panics", func(context.Context) error { panic("oops") })
+
+	if got := r.Health(context.Background()).Status; got != StatusDown {
+		t.Errorf("Status = %v, want down", got)
+	}
+}
+
+func TestRegistry_Scopes(t *testing.T) {
+	r := New()
+	r.Register("db", fail)
+	r.Register("loop", ok, WithScope(Liveness))
+
+	if got := r.Live(context.Background()); got.Status != StatusUp || len(got.Checks) != 1 {
+		t.Errorf("Live() = %+v, want only loop and up", got)
+	}
+	if got := r.Ready(context.Background()); got.Status != StatusDown || len(got.Checks) != 1 {
+		t.Errorf("Ready() = %+v, want only db and down", got)
+	}
+	if got := r.Health(context.Background()); len(got.Checks) != 2 {
+		t.Errorf("Health() checks = %d, want 2", len(got.Checks))
+	}
+}
+
+func TestRegistry_Drain(t *testing.T) {
+	r := New()
+	r.Register("db", ok)
+
+	if r.Draining() {
+		t.Error("Draining() = true before Drain")
+	}
+
+	r.Drain()
+
+	ready := r.Ready(context.Background())
+	if ready.Status != StatusDown {
+		t.Errorf("Ready() while draining = %v, want down", ready.Status)
+	}
+	if _, ok := ready.Checks["shutdown"]; !ok {
+		t.Error("Ready() should report shutdown check")
+	}
+	if live := r.Live(context.Background()); live.Status != StatusUp {
+		t.Errorf("Live() while draining = %v, want up", live.Status)
+	}
+}
+
+func TestRegistry_ReplaceCheck(t *testing.T) {
+	r := New()
+	r.Register("db", fail)
+	r.Register("db", ok)
+
+	report := r.Health(context.Background())
+	if report.Status != StatusUp || len(report.Checks) != 1 {
+		t.Errorf("Health() = %+v, want single up check", report)
+	}
+}
+
+type pinger struct{ err error }
+
+func (p pinger) Ping(context.Context) error { return p.err }
+
+func TestPingCheck(t *testing.T) {
+	if err := PingCheck(pinger{})(context.Background()); err != nil {
+		t.Errorf("PingCheck() error = %v", err)
+	}
+	if err := PingCheck(pinger{err: errors.New("down")})(context.Background()); err == nil {
+		t.Error("PingCheck() should propagate error")
+	}
+}
@@ -0,0 +1,136 @@
+// Package jobs runs background work queued in Postgres. Jobs are enqueued through
+// connection of ctx, so enqueue inside transaction commits or rolls back with it, and
+// workers claim them with SELECT ... FOR UPDATE SKIP LOCKED.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	_defaultQueue       = "default"
+	_defaultMaxAttempts = 10
+)
+
+// ErrDuplicate returned by Enqueue when unfinished job with same unique key exists
+var ErrDuplicate = errors.New("jobs: duplicate unique key")
+
+// ErrLockLost returned by state updates when job is no longer running the given attempt,
+// its lock expired and another worker claimed it
+var ErrLockLost = errors.New("jobs: lock lost")
+
+// State Job lifecycle state
+type State string
+
+const (
+	// StatePending waits for run_at
+	StatePending State = "pending"
+	// StateRunning is claimed by worker
+	StateRunning State = "running"
+	// StateDone finished successfully
+	StateDone State = "done"
+	// StateDead failed every attempt or permanently, kept for inspection
+	StateDead State = "dead"
+)
+
+// Args Job payload, marshalled to JSON. Kind names handler and must be stable,
+// e.g. "email.send".
+type Args interface {
+	Kind() string
+}
+
+// Job Claimed job passed to typed handler
+type Job[T Args] struct {
+	ID int64
+	// Attempt starts at 1
+	Attempt     int
+	MaxAttempts int
+	Args        T
+}
+
+// Row Stored job
+type Row struct {
+	ID          int64           `db:"id"`
+	Queue       string          `db:"queue"`
+	Kind        string          `db:"kind"`
+	Args        json.RawMessage `db:"args"`
+	Attempts    int             `db:"attempts"`
+	MaxAttempts int             `db:"max_attempts"`
+	UniqueKey   *string         `db:"unique_key"`
+	RunAt       time.Time       `db:"run_at"`
+}
+
+// Store Persists jobs, PostgresStore is the implementation
+type Store interface {
+	// Enqueue inserts job and returns its id, ErrDuplicate when unique key is taken
+	Enqueue(ctx context.Context, r *Row) (int64, error)
+	// Claim locks up to limit due jobs of queue for lock, incrementing their attempts.
+	// Running jobs whose lock expired are claimed again.
+	Claim(ctx context.Context, queue string, limit int, lock time.Duration) ([]Row, error)
+	// Complete, Retry and DeadLetter update job running claimed attempt, ErrLockLost when
+	// it was claimed again since
+	Complete(ctx context.Context, id int64, attempt int) error
+	// Retry returns job to pending state to run at runAt
+	Retry(ctx context.Context, id int64, attempt int, runAt time.Time, msg string) error
+	// DeadLetter marks job dead, it won't run again
+	DeadLetter(ctx context.Context, id int64, attempt int, msg string) error
+	// Purge deletes jobs finished before t
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EnqueueOption Configures enqueued job
+type EnqueueOption func(*Row)
+
+// OnQueue puts job on named queue, default is "default"
+func OnQueue(name string) EnqueueOption { return func(r *Row) { r.Queue = name } }
+
+// RunAt delays job until t
+func RunAt(t time.Time) EnqueueOption { return func(r *Row) { r.RunAt = t } }
+
+// RunIn delays job by d
+func RunIn(d time.Duration) EnqueueOption {
+	return func(r *Row) { r.RunAt = time.Now().Add(d) }
+}
+
+// MaxAttempts sets how many times job runs before dead letter, default 10
+func MaxAttempts(n int) EnqueueOption { return func(r *Row) { r.MaxAttempts = n } }
+
+// UniqueKey skips enqueue with ErrDuplicate while unfinished job with key exists
+func UniqueKey(key string) EnqueueOption { return func(r *Row) { r.UniqueKey = &key } }
+
+// Client Enqueues typed jobs
+type Client struct {
+	store Store
+}
+
+func NewClient(store Store) *Client {
+	return &Client{store: store}
+}
+
+// Enqueue stores job for args, run with ctx carrying transaction to enqueue atomically
+// with other writes
+func (c *Client) Enqueue(ctx context.Context, args Args, opts ...EnqueueOption) (int64, error) {
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return 0, fmt.Errorf("jobs: marshal %s: %w", args.Kind(), err)
+	}
+
+	r := &Row{
+		Queue:       _defaultQueue,
+		Kind:        args.Kind(),
+		Args:        payload,
+		MaxAttempts: _defaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.MaxAttempts < 1 {
+		return 0, fmt.Errorf("jobs: max attempts must be positive, got %d", r.MaxAttempts)
+	}
+
+	return c.store.Enqueue(ctx, r)
+}
@@ -0,0 +1,194 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testArgs struct {
+	To string `json:"to"`
+}
+
+func (testArgs) Kind() string { return "test.send" }
+
+// memStore Store keeping jobs in memory, claims every due pending job
+type memStore struct {
+	mu   sync.Mutex
+	rows map[int64]*memRow
+	next int64
+}
+
+type memRow struct {
+	Row
+	state State
+	err   string
+}
+
+func newMemStore() *memStore {
+	return &memStore{rows: make(map[int64]*memRow)}
+}
+
+func (s *memStore) Enqueue(_ context.Context, r *Row) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.UniqueKey != nil {
+		for _, m := range s.rows {
+			unfinished := m.state == StatePending || m.state == StateRunning
+			if m.UniqueKey != nil && *m.UniqueKey == *r.UniqueKey && unfinished {
+				return 0, ErrDuplicate
+			}
+		}
+	}
+	s.next++
+	row := *r
+	row.ID = s.next
+	if row.RunAt.IsZero() {
+		row.RunAt = time.Now()
+	}
+	s.rows[row.ID] = &memRow{Row: row, state: StatePending}
+
+	return row.ID, nil
+}
+
+func (s *memStore) Claim(
+	_ context.Context,
+	queue string,
+	limit int,
+	_ time.Duration,
+) ([]Row, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []Row
+	for _, m := range s.rows {
+		if len(rows) == limit {
+			break
+		}
+		if m.Queue == queue && m.state == StatePending && !m.RunAt.After(time.Now()) {
+			m.state = StateRunning
+			m.Attempts++
+			rows = append(rows, m.Row)
+		}
+	}
+
+	return rows, nil
+}
+
+func (s *memStore) Complete(_ context.Context, id int64, attempt int) error {
+	return s.set(id, attempt, StateDone, "", time.Time{})
+}
+
+func (s *memStore) Retry(
+	_ context.Context,
+	id int64,
+	attempt int,
+	runAt time.Time,
+	msg string,
+) error {
+	return s.set(id, attempt, StatePending, msg, runAt)
+}
+
+func (s *memStore) DeadLetter(_ context.Context, id int64, attempt int, msg string) error {
+	return s.set(id, attempt, StateDead, msg, time.Time{})
+}
+
+func (s *memStore) Purge(context.Context, time.Time) (int64, error) { return 0, nil }
+
+// set updates job running attempt like PostgresStore
+func (s *memStore) set(id int64, attempt int, state State, msg string, runAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := s.rows[id]
+	if m.state != StateRunning || m.Attempts != attempt {
+		return ErrLockLost
+	}
+	m.state = state
+	m.err = msg
+	if !runAt.IsZero() {
+		m.RunAt = runAt
+	}
+	return nil
+}
+
+func (s *memStore) get(id int64) memRow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.rows[id]
+}
+
+func TestClient_Enqueue(t *testing.T) {
+	store := newMemStore()
+	c := NewClient(store)
+	runAt := time.Now().Add(time.Hour)
+
+	id, err := c.Enqueue(
+		context.Background(),
+		testArgs{To: "a@example.com"},
+		OnQueue("mail"),
+		RunAt(runAt),
+		MaxAttempts(3),
+	)
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	r := store.get(id)
+	if r.Kind != "test.send" || r.Queue != "mail" || r.MaxAttempts != 3 || !r.RunAt.Equal(runAt) {
+		t.Errorf("stored row = %+v", r.Row)
+	}
+	var args testArgs
+	if err := json.Unmarshal(r.Args, &args); err != nil || args.To != "a@example.com" {
+		t.Errorf("stored args = %s, %v", r.Args, err)
+	}
+}
+
+func TestClient_EnqueueDefaults(t *testing.T) {
+	store := newMemStore()
+	id, err := NewClient(store).Enqueue(context.Background(), testArgs{})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	r := store.get(id)
+	if r.Queue != _defaultQueue || r.MaxAttempts != _defaultMaxAttempts {
+		t.Errorf("queue, max attempts = %s, %d", r.Queue, r.MaxAttempts)
+	}
+}
+
+func TestClient_EnqueueUnique(t *testing.T) {
+	store := newMemStore()
+	c := NewClient(store)
+	ctx := context.Background()
+
+	id, err := c.Enqueue(ctx, testArgs{}, UniqueKey("user:1"))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := c.Enqueue(ctx, testArgs{}, UniqueKey("user:1")); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Enqueue() duplicate error = %v, want ErrDuplicate", err)
+	}
+
+	// finished job frees the key
+	if _, err := store.Claim(ctx, _defaultQueue, 1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Complete(ctx, id, 1); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if _, err := c.Enqueue(ctx, testArgs{}, UniqueKey("user:1")); err != nil {
+		t.Errorf("Enqueue() after completion error = %v", err)
+	}
+}
+
+func TestClient_EnqueueInvalidMaxAttempts(t *testing.T) {
+	_, err := NewClient(newMemStore()).Enqueue(context.Background(), testArgs{}, MaxAttempts(0))
+	if err == nil {
+		t.Error("Enqueue() with zero max attempts should fail")
+	}
+}
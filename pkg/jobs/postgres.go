@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const _enqueueQuery = `
+INSERT INTO jobs (queue, kind, args, max_attempts, unique_key, run_at)
+VALUES ($1, $2, $3, $4, $5, COALESCE($6, now()))
+ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL AND state IN ('pending', 'running')
+DO NOTHING
+RETURNING id`
+
+// _claimQuery locks due jobs skipping rows other workers hold, running jobs past
+// locked_until belong to crashed workers and are claimed again
+const _claimQuery = `
+UPDATE jobs SET
+	state = 'running',
+	attempts = attempts + 1,
+	locked_until = now() + make_interval(secs => $3::float8),
+	updated_at = now()
+WHERE id IN (
+	SELECT id FROM jobs
+	WHERE queue = $1 AND (
+		(state = 'pending' AND run_at <= now()) OR
+		(state = 'running' AND locked_until < now())
+	)
+	ORDER BY run_at, id
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, queue, kind, args, attempts, max_attempts, unique_key, run_at`
+
+// ConnFunc Returns connection for ctx, transaction running in ctx makes enqueue part of it
+type ConnFunc func(ctx context.Context) sqlx.ExtContext
+
+// PostgresStore Keeps jobs in jobs table, created by migrations
+type PostgresStore struct {
+	db   *sqlx.DB
+	conn ConnFunc
+}
+
+// PostgresOption Provides function to postgres store options
+type PostgresOption func(*PostgresStore)
+
+// WithConn enqueues through fn instead of db, e.g. adapter Conn to join running transaction
+func WithConn(fn ConnFunc) PostgresOption {
+	return func(s *PostgresStore) { s.conn = fn }
+}
+
+func NewPostgresStore(db *sqlx.DB, opts ...PostgresOption) *PostgresStore {
+	s := &PostgresStore{db: db}
+	s.conn = func(context.Context) sqlx.ExtContext { return db }
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+var _ Store = (*PostgresStore)(nil)
+
+// Enqueue implements Store
+func (s *PostgresStore) Enqueue(ctx context.Context, r *Row) (int64, error) {
+	var runAt *time.Time
+	if !r.RunAt.IsZero() {
+		runAt = &r.RunAt
+	}
+
+	var id int64
+	err := s.conn(ctx).QueryRowxContext(
+		ctx,
+		_enqueueQuery,
+		r.Queue,
+		r.Kind,
+		[]byte(r.Args),
+		r.MaxAttempts,
+		r.UniqueKey,
+		runAt,
+	).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrDuplicate
+	}
+	if err != nil {
+		return 0, fmt.Errorf("jobs: enqueue %s: %w", r.Kind, err)
+	}
+
+	return id, nil
+}
+
+// Claim implements Store
+func (s *PostgresStore) Claim(
+	ctx context.Context,
+	queue string,
+	limit int,
+	lock time.Duration,
+) ([]Row, error) {
+	var rows []Row
+	err := s.db.SelectContext(ctx, &rows, _claimQuery, queue, limit, lock.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("jobs: claim: %w", err)
+	}
+
+	return rows, nil
+}
+
+// Complete implements Store
+func (s *PostgresStore) Complete(ctx context.Context, id int64, attempt int) error {
+	return s.update(ctx, "complete", `
+UPDATE jobs SET state = 'done', locked_until = NULL, finished_at = now(), updated_at = now()
+WHERE id = $1 AND state = 'running' AND attempts = $2`, id, attempt)
+}
+
+// Retry implements Store
+func (s *PostgresStore) Retry(
+	ctx context.Context,
+	id int64,
+	attempt int,
+	runAt time.Time,
+	msg string,
+) error {
+	return s.update(ctx, "retry", `
+UPDATE jobs SET state = 'pending', run_at = $3, locked_until = NULL, last_error = $4,
+	updated_at = now()
+WHERE id = $1 AND state = 'running' AND attempts = $2`, id, attempt, runAt, msg)
+}
+
+// DeadLetter implements Store
+func (s *PostgresStore) DeadLetter(ctx context.Context, id int64, attempt int, msg string) error {
+	return s.update(ctx, "dead letter", `
+UPDATE jobs SET state = 'dead', locked_until = NULL, last_error = $3, finished_at = now(),
+	updated_at = now()
+WHERE id = $1 AND state = 'running' AND attempts = $2`, id, attempt, msg)
+}
+
+// Purge implements Store, dead jobs are kept until purged too
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM jobs WHERE finished_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("jobs: purge: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// update runs state update of claimed attempt, ErrLockLost when no row matched
+func (s *PostgresStore) update(ctx context.Context, op, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("jobs: %s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("jobs: %s: %w", op, err)
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
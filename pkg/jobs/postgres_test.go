@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/lomifile/api/migrations"
+)
+
+// TestPostgresStore runs against real database when TEST_DATABASE_URL is set
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	db, err := sqlx.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	// temp tables are per connection
+	db.SetMaxOpenConns(1)
+
+	schema, err := migrations.FS.ReadFile("20250103000000_create_jobs.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// temp table shadows jobs table created by migrations
+	ddl := strings.Replace(string(schema), "CREATE TABLE jobs", "CREATE TEMP TABLE jobs", 1)
+	if _, err := db.ExecContext(ctx, ddl); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewPostgresStore(db)
+	c := NewClient(s)
+
+	id, err := c.Enqueue(ctx, testArgs{To: "a@example.com"}, UniqueKey("k"), MaxAttempts(2))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if _, err := c.Enqueue(ctx, testArgs{}, UniqueKey("k")); !errors.Is(err, ErrDuplicate) {
+		t.Errorf("Enqueue() duplicate error = %v, want ErrDuplicate", err)
+	}
+	if _, err := c.Enqueue(ctx, testArgs{}, RunIn(time.Hour)); err != nil {
+		t.Fatalf("Enqueue() delayed error = %v", err)
+	}
+
+	rows, err := s.Claim(ctx, _defaultQueue, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(rows) != 1 || rows[0].ID != id || rows[0].Attempts != 1 {
+		t.Fatalf("Claim() = %+v, want only due job on first attempt", rows)
+	}
+	if !strings.Contains(string(rows[0].Args), "a@example.com") {
+		t.Errorf("claimed args = %s", rows[0].Args)
+	}
+	if rows, _ := s.Claim(ctx, _defaultQueue, 10, time.Minute); len(rows) != 0 {
+		t.Errorf("second Claim() = %d jobs, running job should stay locked", len(rows))
+	}
+
+	if err := s.Retry(ctx, id, 1, time.Now().Add(-time.Second), "boom"); err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	// expired lock is claimed again
+	rows, err = s.Claim(ctx, _defaultQueue, 10, -time.Second)
+	if err != nil || len(rows) != 1 || rows[0].Attempts != 2 {
+		t.Fatalf("Claim() after retry = %+v, %v", rows, err)
+	}
+	rows, err = s.Claim(ctx, _defaultQueue, 10, time.Minute)
+	if err != nil || len(rows) != 1 || rows[0].Attempts != 3 {
+		t.Fatalf("Claim() of expired lock = %+v, %v", rows, err)
+	}
+
+	// worker of attempt 2 lost its lock, it must not touch job running attempt 3
+	if err := s.Retry(ctx, id, 2, time.Now(), "late"); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Retry() of reclaimed attempt error = %v, want ErrLockLost", err)
+	}
+	if err := s.DeadLetter(ctx, id, 3, "boom"); err != nil {
+		t.Fatalf("DeadLetter() error = %v", err)
+	}
+	if _, err := c.Enqueue(ctx, testArgs{}, UniqueKey("k")); err != nil {
+		t.Errorf("Enqueue() after dead letter error = %v", err)
+	}
+	if err := s.Complete(ctx, id, 3); !errors.Is(err, ErrLockLost) {
+		t.Errorf("Complete() of dead job error = %v, want ErrLockLost", err)
+	}
+
+	n, err := s.Purge(ctx, time.Now().Add(time.Second))
+	if err != nil || n != 1 {
+		t.Errorf("Purge() = %d, %v, want 1 dead job", n, err)
+	}
+}
@@ -0,0 +1,255 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/retry"
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultConcurrency  = 10
+	_defaultPollInterval = time.Second
+	_defaultLockTimeout  = 5 * time.Minute
+	_defaultRetryInitial = 10 * time.Second
+	_defaultRetryMax     = time.Hour
+	// _storeTimeout bounds state updates after handler returns, they run even during drain
+	_storeTimeout = 10 * time.Second
+)
+
+type handler func(ctx context.Context, r Row) error
+
+// Register adds handler for jobs with T's kind, call before Start. T should be struct with
+// value receiver Kind, its zero value names the kind. Returning error wrapped with
+// retry.Permanent moves job to dead state without further attempts.
+func Register[T Args](w *Worker, fn func(ctx context.Context, job *Job[T]) error) {
+	var zero T
+	w.handlers[zero.Kind()] = func(ctx context.Context, r Row) error {
+		var args T
+		if err := json.Unmarshal(r.Args, &args); err != nil {
+			return retry.Permanent(fmt.Errorf("unmarshal args: %w", err))
+		}
+		return fn(ctx, &Job[T]{
+			ID:          r.ID,
+			Attempt:     r.Attempts,
+			MaxAttempts: r.MaxAttempts,
+			Args:        args,
+		})
+	}
+}
+
+// WorkerOption Provides function to worker options
+type WorkerOption func(*Worker)
+
+// WithQueue processes named queue, default is "default"
+func WithQueue(name string) WorkerOption { return func(w *Worker) { w.queue = name } }
+
+// WithConcurrency limits jobs running at once, default 10
+func WithConcurrency(n int) WorkerOption { return func(w *Worker) { w.concurrency = n } }
+
+// WithPollInterval sets how often idle worker looks for due jobs, default 1s
+func WithPollInterval(d time.Duration) WorkerOption { return func(w *Worker) { w.poll = d } }
+
+// WithLockTimeout sets how long job is locked to worker, default 5m. Handler context
+// expires with lock, after that crashed worker's job is claimed again.
+func WithLockTimeout(d time.Duration) WorkerOption { return func(w *Worker) { w.lock = d } }
+
+// WithBackoff tunes delay before failed job runs again, default 10s doubling up to 1h.
+// Only delay options apply, attempts are limited per job by MaxAttempts.
+func WithBackoff(opts ...retry.Option) WorkerOption {
+	return func(w *Worker) { w.backoff = append(w.backoff, opts...) }
+}
+
+// Worker Claims jobs of one queue and runs their handlers
+type Worker struct {
+	store    Store
+	l        *logger.Logger
+	handlers map[string]handler
+
+	queue       string
+	concurrency int
+	poll        time.Duration
+	lock        time.Duration
+	backoff     []retry.Option
+	delay       *retry.Backoff
+
+	// ctx is handler parent, cancelled when drain runs out of time
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	done   chan struct{}
+	slots  chan struct{}
+	wg     sync.WaitGroup
+}
+
+func NewWorker(store Store, l *logger.Logger, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		store:       store,
+		l:           l.Named("jobs"),
+		handlers:    make(map[string]handler),
+		queue:       _defaultQueue,
+		concurrency: _defaultConcurrency,
+		poll:        _defaultPollInterval,
+		lock:        _defaultLockTimeout,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.delay = retry.New(append([]retry.Option{
+		retry.WithInitial(_defaultRetryInitial),
+		retry.WithMax(_defaultRetryMax),
+	}, w.backoff...)...)
+	w.slots = make(chan struct{}, max(w.concurrency, 1))
+
+	return w
+}
+
+// Start polls for jobs in background until Shutdown, register handlers first
+func (w *Worker) Start() {
+	w.ctx, w.cancel = context.WithCancel(context.Background())
+	go w.loop()
+}
+
+// Shutdown stops polling, claims due jobs for free slots once more and waits for running
+// ones. When ctx is done first, running handlers are cancelled and their jobs run again after
+// lock expires.
+func (w *Worker) Shutdown(ctx context.Context) error {
+	close(w.stop)
+	<-w.done
+
+	// last pass picks up jobs enqueued by requests and tasks that stopped before the worker
+	if ctx.Err() == nil {
+		w.claim()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		w.cancel()
+		return nil
+	case <-ctx.Done():
+		w.cancel()
+		<-finished
+		return fmt.Errorf("jobs: drain: %w", ctx.Err())
+	}
+}
+
+func (w *Worker) loop() {
+	defer close(w.done)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-timer.C:
+		}
+
+		// full batch means more jobs are likely due, poll again right away
+		wait := w.poll
+		if w.claim() {
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+// claim starts jobs for free slots and reports whether every slot got one
+func (w *Worker) claim() bool {
+	free := cap(w.slots) - len(w.slots)
+	if free == 0 {
+		return false
+	}
+
+	rows, err := w.store.Claim(w.ctx, w.queue, free, w.lock)
+	if err != nil {
+		w.l.Error("claim jobs failed", zap.String("queue", w.queue), zap.Error(err))
+		return false
+	}
+
+	for _, r := range rows {
+		w.slots <- struct{}{}
+		w.wg.Add(1)
+		go func() {
+			defer func() {
+				<-w.slots
+				w.wg.Done()
+			}()
+			w.run(r)
+		}()
+	}
+
+	return len(rows) == free
+}
+
+func (w *Worker) run(r Row) {
+	l := w.l.With(
+		zap.Int64("job_id", r.ID),
+		zap.String("kind", r.Kind),
+		zap.Int("attempt", r.Attempts),
+	)
+
+	err := w.handle(l, r)
+
+	ctx, cancel := context.WithTimeout(context.Background(), _storeTimeout)
+	defer cancel()
+
+	var storeErr error
+	switch {
+	case err == nil:
+		storeErr = w.store.Complete(ctx, r.ID, r.Attempts)
+	case retry.IsPermanent(err) || r.Attempts >= r.MaxAttempts:
+		l.Error("job failed, moved to dead letter", zap.Error(err))
+		storeErr = w.store.DeadLetter(ctx, r.ID, r.Attempts, err.Error())
+	default:
+		delay := w.delay.Delay(r.Attempts)
+		l.Warn("job failed, retrying", zap.Duration("delay", delay), zap.Error(err))
+		storeErr = w.store.Retry(ctx, r.ID, r.Attempts, time.Now().Add(delay), err.Error())
+	}
+	switch {
+	case errors.Is(storeErr, ErrLockLost):
+		// lock expired while handler ran, state belongs to worker that claimed job again
+		l.Warn("job lock lost, state not updated", zap.Error(err))
+	case storeErr != nil:
+		// job stays running and is claimed again when lock expires
+		l.Error("job state update failed", zap.Error(storeErr))
+	}
+}
+
+// handle runs r's handler with lock timeout and l in context, panics are returned as errors
+func (w *Worker) handle(l *logger.Logger, r Row) (err error) {
+	// attempts above max only happen when last attempt's worker died holding the lock
+	if r.Attempts > r.MaxAttempts {
+		return retry.Permanent(errors.New("lock expired on last attempt"))
+	}
+	h, ok := w.handlers[r.Kind]
+	if !ok {
+		return retry.Permanent(fmt.Errorf("no handler for kind %q", r.Kind))
+	}
+
+	ctx, cancel := context.WithTimeout(logger.NewContext(w.ctx, l), w.lock)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return h(ctx, r)
+}
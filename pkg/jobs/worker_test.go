@@ -0,0 +1,260 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/retry"
+)
+
+func newTestWorker(store Store, opts ...WorkerOption) *Worker {
+	opts = append([]WorkerOption{
+		WithPollInterval(time.Millisecond),
+		WithBackoff(retry.WithInitial(time.Millisecond), retry.WithMax(time.Millisecond)),
+	}, opts...)
+	return NewWorker(store, logger.New(logger.Config{}), opts...)
+}
+
+// waitState polls store until job reaches state
+func waitState(t *testing.T, s *memStore, id int64, state State) memRow {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if r := s.get(id); r.state == state {
+			return r
+		}
+		time.Sleep(time.Millisecond)
+	}
+	r := s.get(id)
+	t.Fatalf("job %d state = %s, want %s (last error %q)", id, r.state, state, r.err)
+	return r
+}
+
+func TestWorker_RunsTypedHandler(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+
+	got := make(chan *Job[testArgs], 1)
+	Register(w, func(_ context.Context, job *Job[testArgs]) error {
+		got <- job
+		return nil
+	})
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{To: "a@example.com"})
+
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	waitState(t, store, id, StateDone)
+	job := <-got
+	if job.ID != id || job.Attempt != 1 || job.Args.To != "a@example.com" {
+		t.Errorf("handler got %+v", job)
+	}
+}
+
+func TestWorker_RetriesThenDeadLetters(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+
+	var calls atomic.Int32
+	Register(w, func(context.Context, *Job[testArgs]) error {
+		calls.Add(1)
+		return errors.New("smtp down")
+	})
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{}, MaxAttempts(3))
+
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	r := waitState(t, store, id, StateDead)
+	if calls.Load() != 3 || r.Attempts != 3 || r.err != "smtp down" {
+		t.Errorf("calls, attempts, error = %d, %d, %q", calls.Load(), r.Attempts, r.err)
+	}
+}
+
+func TestWorker_LockLostKeepsNewClaim(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{})
+	Register(w, func(context.Context, *Job[testArgs]) error {
+		// lock expired and another worker claimed job while this attempt ran
+		store.mu.Lock()
+		store.rows[id].Attempts++
+		store.mu.Unlock()
+		return errors.New("smtp down")
+	})
+
+	w.Start()
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := store.get(id); r.state != StateRunning || r.Attempts != 2 || r.err != "" {
+		t.Errorf("state, attempts, error = %s, %d, %q, want job left to attempt 2",
+			r.state, r.Attempts, r.err)
+	}
+}
+
+func TestWorker_PermanentErrorSkipsRetries(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+
+	Register(w, func(context.Context, *Job[testArgs]) error {
+		return retry.Permanent(errors.New("bad address"))
+	})
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{})
+
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	if r := waitState(t, store, id, StateDead); r.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", r.Attempts)
+	}
+}
+
+func TestWorker_UnknownKindAndPanic(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store, WithQueue("other"))
+
+	Register(w, func(context.Context, *Job[testArgs]) error { panic("boom") })
+	c := NewClient(store)
+	unknown, _ := c.Enqueue(context.Background(), otherArgs{}, OnQueue("other"))
+	panicking, _ := c.Enqueue(context.Background(), testArgs{}, OnQueue("other"), MaxAttempts(1))
+
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	if r := waitState(t, store, unknown, StateDead); r.err != `no handler for kind "test.other"` {
+		t.Errorf("unknown kind error = %q", r.err)
+	}
+	if r := waitState(t, store, panicking, StateDead); r.err != "panic: boom" {
+		t.Errorf("panic error = %q", r.err)
+	}
+}
+
+func TestWorker_DelayedJob(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+	Register(w, func(context.Context, *Job[testArgs]) error { return nil })
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{}, RunIn(50*time.Millisecond))
+
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if r := store.get(id); r.state != StatePending {
+		t.Errorf("state before run_at = %s, want pending", r.state)
+	}
+	waitState(t, store, id, StateDone)
+}
+
+func TestWorker_ConcurrencyLimit(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store, WithConcurrency(2))
+
+	var running, peak atomic.Int32
+	Register(w, func(context.Context, *Job[testArgs]) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+	c := NewClient(store)
+	var ids []int64
+	for range 6 {
+		id, _ := c.Enqueue(context.Background(), testArgs{})
+		ids = append(ids, id)
+	}
+
+	w.Start()
+	defer func() { _ = w.Shutdown(context.Background()) }()
+
+	for _, id := range ids {
+		waitState(t, store, id, StateDone)
+	}
+	if peak.Load() != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak.Load())
+	}
+}
+
+func TestWorker_ShutdownDrains(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+
+	started := make(chan struct{})
+	Register(w, func(context.Context, *Job[testArgs]) error {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{})
+
+	w.Start()
+	<-started
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if r := store.get(id); r.state != StateDone {
+		t.Errorf("state after drain = %s, want done", r.state)
+	}
+}
+
+func TestWorker_ShutdownClaimsLastJobs(t *testing.T) {
+	store := newMemStore()
+	// worker doesn't poll again after the first claim
+	w := newTestWorker(store, WithPollInterval(time.Hour))
+	Register(w, func(context.Context, *Job[testArgs]) error { return nil })
+	client := NewClient(store)
+
+	first, _ := client.Enqueue(context.Background(), testArgs{})
+	w.Start()
+	waitState(t, store, first, StateDone)
+
+	last, _ := client.Enqueue(context.Background(), testArgs{})
+	if err := w.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if r := store.get(last); r.state != StateDone {
+		t.Errorf("job enqueued before shutdown state = %s, want done", r.state)
+	}
+}
+
+func TestWorker_ShutdownTimeoutCancelsHandlers(t *testing.T) {
+	store := newMemStore()
+	w := newTestWorker(store)
+
+	started := make(chan struct{})
+	Register(w, func(ctx context.Context, _ *Job[testArgs]) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	id, _ := NewClient(store).Enqueue(context.Background(), testArgs{})
+
+	w.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want deadline exceeded", err)
+	}
+	// cancelled job is returned to queue for next worker
+	if r := store.get(id); r.state != StatePending {
+		t.Errorf("state after cancelled drain = %s, want pending", r.state)
+	}
+}
+
+type otherArgs struct{}
+
+func (otherArgs) Kind() string { return "test.other" }
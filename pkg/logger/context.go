@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// LocalsKey Key under which HTTP middleware stores request *Logger
+const LocalsKey = "logger"
+
+type ctxKey struct{}
+
+// _default logger of contexts without one, discards entries until SetDefault
+var _default atomic.Pointer[Logger]
+
+func init() {
+	_default.Store(&Logger{Logger: zap.NewNop(), level: zap.NewAtomicLevel()})
+}
+
+// SetDefault sets logger FromContext returns for contexts without one
+func SetDefault(l *Logger) {
+	_default.Store(l)
+}
+
+// NewContext returns ctx carrying l, FromContext and Ctx return it
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns logger stored in ctx by NewContext, default logger when there is none
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(ctxKey{}).(*Logger); ok {
+		return l
+	}
+	return _default.Load()
+}
+
+// WithFields returns ctx carrying logger of ctx with fields added
+func WithFields(ctx context.Context, fields ...zap.Field) context.Context {
+	return NewContext(ctx, FromContext(ctx).With(fields...))
+}
+
+// Ctx returns logger of ctx named like l, so components keep their name and log with request
+// fields. Returns l when ctx carries no logger, fields added to l by With aren't kept.
+// Named ctx logger keeps its name as prefix, e.g. email client in job handler logs as
+// jobs.email, name already ending with l's one isn't repeated.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	cl, ok := ctx.Value(ctxKey{}).(*Logger)
+	if !ok {
+		return l
+	}
+	name, parent := l.Logger.Name(), cl.Logger.Name()
+	if name == "" || parent == name || strings.HasSuffix(parent, "."+name) {
+		return cl
+	}
+	return cl.Named(name)
+}
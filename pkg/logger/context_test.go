@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newFileLogger returns logger writing JSON to file and reader of entries written so far
+func newFileLogger(t *testing.T) (*Logger, func() []map[string]any) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	l := New(Config{Output: path})
+
+	return l, func() []map[string]any {
+		_ = l.Sync()
+		raw, _ := os.ReadFile(path)
+
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+			var e map[string]any
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("log line isn't JSON: %v\n%s", err, line)
+			}
+			entries = append(entries, e)
+		}
+		return entries
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if FromContext(context.Background()) == nil {
+		t.Fatal("FromContext() without logger should return default")
+	}
+
+	l, entries := newFileLogger(t)
+	ctx := NewContext(context.Background(), l.With(zap.String("request_id", "r1")))
+	ctx = WithFields(ctx, zap.String("user_id", "42"))
+
+	FromContext(ctx).Info("from context")
+
+	e := entries()[0]
+	if e["request_id"] != "r1" || e["user_id"] != "42" {
+		t.Errorf("entry = %v, want request_id and user_id", e)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	prev := FromContext(context.Background())
+	defer SetDefault(prev)
+
+	l, _ := newFileLogger(t)
+	SetDefault(l)
+	if FromContext(context.Background()) != l {
+		t.Error("FromContext() without logger should return logger set by SetDefault")
+	}
+}
+
+func TestLogger_Ctx(t *testing.T) {
+	l, entries := newFileLogger(t)
+	component := l.Named("user_service")
+
+	if component.Ctx(context.Background()) != component {
+		t.Error("Ctx() without logger in context should return receiver")
+	}
+
+	ctx := NewContext(context.Background(), l.With(zap.String("request_id", "r1")))
+	component.Ctx(ctx).Info("with request")
+
+	e := entries()[0]
+	if e["logger"] != "user_service" || e["request_id"] != "r1" {
+		t.Errorf("entry = %v, want component name and request fields", e)
+	}
+}
+
+func TestLogger_CtxNamed(t *testing.T) {
+	l, entries := newFileLogger(t)
+	email := l.Named("email")
+
+	email.Ctx(NewContext(context.Background(), l.Named("jobs"))).Info("in job")
+	email.Ctx(NewContext(context.Background(), l.Named("jobs").Named("email"))).Info("again")
+
+	got := entries()
+	if got[0]["logger"] != "jobs.email" || got[1]["logger"] != "jobs.email" {
+		t.Errorf("names = %v, %v, want jobs.email", got[0]["logger"], got[1]["logger"])
+	}
+}
+
+func TestLogger_WithStringer(t *testing.T) {
+	l, entries := newFileLogger(t)
+
+	route := routeStub("/api")
+	child := l.WithStringer("route", &route).With(zap.String("user_id", "42"))
+	child.Info("first")
+	route = "/api/users/:id"
+	child.Info("second")
+
+	got := entries()
+	if got[0]["route"] != "/api" || got[1]["route"] != "/api/users/:id" {
+		t.Errorf("routes = %v, %v, want value when entry is written", got[0], got[1])
+	}
+	if got[1]["user_id"] != "42" {
+		t.Errorf("entry = %v, want fields added after WithStringer", got[1])
+	}
+}
+
+type routeStub string
+
+func (r *routeStub) String() string { return string(*r) }
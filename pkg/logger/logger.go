@@ -2,13 +2,22 @@
 package logger
 
 import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Logger extends zap.Logger
 type Logger struct {
 	*zap.Logger
+
+	level zap.AtomicLevel
 }
 
 // Config Logger config
@@ -16,33 +25,87 @@ type Config struct {
 	Debug   bool
 	Service string
 	Env     string
+	// Level minimum enabled level, empty keeps debug in Debug mode and info otherwise
+	Level string
+	// Encoding json or console, empty is console in Debug mode and json otherwise
+	Encoding string
+	// Output stderr, stdout or path of file rotated by Rotation, default stderr
+	Output   string
+	Rotation Rotation
+	Sampling Sampling
+}
+
+// Rotation Starts new log file when current one grows over MaxSize megabytes, rotated files
+// are removed after MaxAge or when there are more than MaxBackups of them
+type Rotation struct {
+	MaxSize    int
+	MaxAge     time.Duration
+	MaxBackups int
+	Compress   bool
+}
+
+// Sampling Logs first Initial entries with same level and message every second and then
+// every Thereafter-th one, zero Initial disables sampling
+type Sampling struct {
+	Initial    int
+	Thereafter int
 }
 
 // New creates new logger instance
 func New(cfg Config) *Logger {
-	var zcfg zap.Config
-
+	level := zap.NewAtomicLevelAt(zap.InfoLevel)
 	if cfg.Debug {
-		zcfg = zap.NewDevelopmentConfig()
-		zcfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	} else {
-		zcfg = zap.NewProductionConfig()
-		zcfg.Encoding = "json"
-		zcfg.EncoderConfig.TimeKey = "ts"
-		zcfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-		zcfg.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+		level.SetLevel(zap.DebugLevel)
+	}
+	if cfg.Level != "" {
+		lvl, err := zapcore.ParseLevel(cfg.Level)
+		if err != nil {
+			panic(err)
+		}
+		level.SetLevel(lvl)
+	}
+
+	encoding := cfg.Encoding
+	if encoding == "" {
+		encoding = "json"
+		if cfg.Debug {
+			encoding = "console"
+		}
+	}
+	var enc zapcore.Encoder
+	switch encoding {
+	case "console":
+		ecfg := zap.NewDevelopmentEncoderConfig()
+		ecfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		enc = zapcore.NewConsoleEncoder(ecfg)
+	case "json":
+		ecfg := zap.NewProductionEncoderConfig()
+		ecfg.TimeKey = "ts"
+		ecfg.EncodeTime = zapcore.ISO8601TimeEncoder
+		enc = zapcore.NewJSONEncoder(ecfg)
+	default:
+		panic(fmt.Sprintf("logger: unknown encoding %q", cfg.Encoding))
 	}
 
-	zcfg.OutputPaths = []string{"stderr"}
-	zcfg.ErrorOutputPaths = []string{"stderr"}
+	core := zapcore.NewCore(enc, output(cfg.Output, cfg.Rotation), level)
+	if cfg.Sampling.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(
+			core,
+			time.Second,
+			cfg.Sampling.Initial,
+			cfg.Sampling.Thereafter,
+		)
+	}
 
-	z, err := zcfg.Build(
+	opts := []zap.Option{
 		zap.AddCaller(),
 		zap.AddStacktrace(zapcore.ErrorLevel),
-	)
-	if err != nil {
-		panic(err)
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	}
+	if cfg.Debug {
+		opts = append(opts, zap.Development())
 	}
+	z := zap.New(core, opts...)
 
 	fields := make([]zap.Field, 0, 2)
 	if cfg.Service != "" {
@@ -55,7 +118,47 @@ func New(cfg Config) *Logger {
 		z = z.With(fields...)
 	}
 
-	return &Logger{Logger: z}
+	return &Logger{Logger: z, level: level}
+}
+
+// output returns writer of path, files are rotated by r
+func output(path string, r Rotation) zapcore.WriteSyncer {
+	switch path {
+	case "", "stderr":
+		return zapcore.Lock(os.Stderr)
+	case "stdout":
+		return zapcore.Lock(os.Stdout)
+	}
+
+	// lumberjack counts age in days, partial days round up
+	return zapcore.AddSync(&lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    r.MaxSize,
+		MaxAge:     int(math.Ceil(r.MaxAge.Hours() / 24)),
+		MaxBackups: r.MaxBackups,
+		Compress:   r.Compress,
+	})
+}
+
+// SetLevel changes minimum level of this logger and every logger derived from it
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.level.SetLevel(lvl)
+
+	return nil
+}
+
+// Level returns current minimum level
+func (l *Logger) Level() zapcore.Level {
+	return l.level.Level()
+}
+
+// LevelHandler serves current level on GET and changes it on PUT with {"level":"debug"} body
+func (l *Logger) LevelHandler() http.Handler {
+	return l.level
 }
 
 // Sugar Sugar wraps the Logger to provide a more ergonomic, but slightly slower, API. Sugaring a
@@ -67,10 +170,42 @@ func (l *Logger) Sugar() *zap.SugaredLogger {
 
 // Named creates new named logger instance
 func (l *Logger) Named(name string) *Logger {
-	return &Logger{Logger: l.Logger.Named(name)}
+	return &Logger{Logger: l.Logger.Named(name), level: l.level}
 }
 
 // With adds defautl fields to logger
 func (l *Logger) With(fields ...zap.Field) *Logger {
-	return &Logger{Logger: l.Logger.With(fields...)}
+	return &Logger{Logger: l.Logger.With(fields...), level: l.level}
+}
+
+// WithStringer adds field read from v every time entry is written, for values that change after
+// logger is created, e.g. route of request that isn't matched yet
+func (l *Logger) WithStringer(key string, v fmt.Stringer) *Logger {
+	wrap := zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &stringerCore{Core: core, key: key, v: v}
+	})
+	return &Logger{Logger: l.Logger.WithOptions(wrap), level: l.level}
+}
+
+// stringerCore adds field of Stringer to every entry it writes
+type stringerCore struct {
+	zapcore.Core
+	key string
+	v   fmt.Stringer
+}
+
+func (c *stringerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stringerCore{Core: c.Core.With(fields), key: c.key, v: c.v}
+}
+
+func (c *stringerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// wrapped core still decides, e.g. sampler, but entry has to be written through c
+	if c.Core.Check(ent, nil) == nil {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *stringerCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, append(fields, zap.Stringer(c.key, c.v)))
 }
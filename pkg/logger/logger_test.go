@@ -1,6 +1,12 @@
 package logger
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"go.uber.org/zap"
@@ -139,3 +145,113 @@ func TestConfig_Empty(t *testing.T) {
 	l.Info("empty config test")
 	_ = l.Sync()
 }
+
+func TestNew_Level(t *testing.T) {
+	l := New(Config{Debug: true, Level: "warn"})
+
+	if l.Level() != zap.WarnLevel {
+		t.Errorf("Level() = %v, want warn", l.Level())
+	}
+	if l.Core().Enabled(zap.InfoLevel) {
+		t.Error("info should be disabled at warn level")
+	}
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	l := New(Config{})
+	named := l.Named("child").With(zap.String("k", "v"))
+
+	if err := l.SetLevel("error"); err != nil {
+		t.Fatalf("SetLevel() error = %v", err)
+	}
+	if named.Core().Enabled(zap.WarnLevel) {
+		t.Error("derived logger should follow parent level")
+	}
+	if named.Level() != zap.ErrorLevel {
+		t.Errorf("derived Level() = %v, want error", named.Level())
+	}
+
+	if err := l.SetLevel("loud"); err == nil {
+		t.Error("SetLevel() with unknown level should fail")
+	}
+	if l.Level() != zap.ErrorLevel {
+		t.Error("failed SetLevel() should keep previous level")
+	}
+}
+
+func TestNew_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	l := New(Config{Output: path, Rotation: Rotation{MaxSize: 1}})
+
+	l.Info("file message", zap.String("k", "v"))
+	_ = l.Sync()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("log file not written: %v", err)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		t.Fatalf("log line isn't JSON: %v\n%s", err, raw)
+	}
+	if entry["msg"] != "file message" || entry["k"] != "v" || entry["ts"] == nil {
+		t.Errorf("entry = %v", entry)
+	}
+}
+
+func TestNew_Encoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	l := New(Config{Debug: false, Encoding: "console", Output: path})
+
+	l.Info("console message")
+	_ = l.Sync()
+
+	raw, _ := os.ReadFile(path)
+	if json.Valid(raw) || !strings.Contains(string(raw), "console message") {
+		t.Errorf("console encoding output = %q", raw)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("New() with unknown encoding should panic")
+		}
+	}()
+	New(Config{Encoding: "text"})
+}
+
+func TestNew_Sampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.log")
+	l := New(Config{Output: path, Sampling: Sampling{Initial: 2, Thereafter: 5}})
+
+	for range 12 {
+		l.Info("repeated")
+	}
+	_ = l.Sync()
+
+	raw, _ := os.ReadFile(path)
+	// first 2 entries, then 5th and 10th of the following ones
+	if n := strings.Count(string(raw), "repeated"); n != 4 {
+		t.Errorf("logged %d sampled entries, want 4", n)
+	}
+}
+
+func TestLogger_LevelHandler(t *testing.T) {
+	l := New(Config{})
+	h := l.LevelHandler()
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"warn"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", rec.Code, rec.Body)
+	}
+	if l.Level() != zap.WarnLevel {
+		t.Errorf("Level() = %v, want warn", l.Level())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(rec.Body.String(), `"level":"warn"`) {
+		t.Errorf("GET body = %s", rec.Body)
+	}
+}
@@ -0,0 +1,98 @@
+// Package metrics provides Prometheus collectors for HTTP, postgres pool, email and Go runtime
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const _namespace = "api"
+
+// Metrics Holds registry and application collectors
+type Metrics struct {
+	Registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	httpInFlight prometheus.Gauge
+	emailSent    *prometheus.CounterVec
+}
+
+// New creates registry with HTTP, email, Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		Registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route template, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: _namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests currently being served.",
+		}),
+		emailSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: _namespace,
+			Subsystem: "email",
+			Name:      "sent_total",
+			Help:      "Number of emails by send result.",
+		}, []string{"result"}),
+	}
+
+	m.Registry.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.emailSent,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return m
+}
+
+// RegisterPool exports pgxpool statistics labelled with node, e.g. primary or replica-1
+func (m *Metrics) RegisterPool(node string, pool *pgxpool.Pool) {
+	m.Registry.MustRegister(newPoolCollector(node, pool))
+}
+
+// ObserveHTTP records finished request
+func (m *Metrics) ObserveHTTP(route, method, status string, seconds float64) {
+	m.httpRequests.WithLabelValues(route, method, status).Inc()
+	m.httpDuration.WithLabelValues(route, method, status).Observe(seconds)
+}
+
+// InFlight returns gauge of requests currently being served
+func (m *Metrics) InFlight() prometheus.Gauge {
+	return m.httpInFlight
+}
+
+// ObserveEmail records email send result
+func (m *Metrics) ObserveEmail(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.emailSent.WithLabelValues(result).Inc()
+}
+
+// Handler serves registry in Prometheus text exposition format
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.Registry, promhttp.HandlerOpts{
+		Registry: m.Registry,
+	}))
+}
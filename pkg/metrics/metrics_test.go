@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestObserveHTTP(t *testing.T) {
+	m := New()
+
+	m.ObserveHTTP("/users/:id", "GET", "200", 0.01)
+	m.ObserveHTTP("/users/:id", "GET", "200", 0.02)
+	m.ObserveHTTP("/users/:id", "GET", "404", 0.01)
+
+	ok := m.httpRequests.WithLabelValues("/users/:id", "GET", "200")
+	if got := testutil.ToFloat64(ok); got != 2 {
+		t.Errorf("requests_total{200} = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(m.httpDuration); got != 2 {
+		t.Errorf("duration series = %d, want 2", got)
+	}
+}
+
+func TestObserveEmail(t *testing.T) {
+	m := New()
+
+	m.ObserveEmail(nil)
+	m.ObserveEmail(nil)
+	m.ObserveEmail(errors.New("smtp down"))
+
+	if got := testutil.ToFloat64(m.emailSent.WithLabelValues("success")); got != 2 {
+		t.Errorf("sent_total{success} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.emailSent.WithLabelValues("failure")); got != 1 {
+		t.Errorf("sent_total{failure} = %v, want 1", got)
+	}
+}
+
+func TestRegisterPool(t *testing.T) {
+	// pgxpool connects lazily, so stats are available without a running server
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/db")
+	if err != nil {
+		t.Fatalf("pgxpool.New() error = %v", err)
+	}
+	defer pool.Close()
+
+	m := New()
+	m.RegisterPool("primary", pool)
+	m.RegisterPool("replica-1", pool)
+
+	expected := `
+# HELP api_db_pool_acquired_conns Connections currently acquired.
+# TYPE api_db_pool_acquired_conns gauge
+api_db_pool_acquired_conns{node="primary"} 0
+api_db_pool_acquired_conns{node="replica-1"} 0
+`
+	err = testutil.GatherAndCompare(
+		m.Registry,
+		strings.NewReader(expected),
+		"api_db_pool_acquired_conns",
+	)
+	if err != nil {
+		t.Errorf("GatherAndCompare() error = %v", err)
+	}
+
+	if got := testutil.CollectAndCount(newPoolCollector("primary", pool)); got != 8 {
+		t.Errorf("pool metrics = %d, want 8", got)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveHTTP("/", "GET", "200", 0.001)
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		t.Errorf("Status = %d, want 200", resp.StatusCode)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		`api_http_requests_total{method="GET",route="/",status="200"} 1`,
+		"api_http_request_duration_seconds_bucket",
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics output missing %q", want)
+		}
+	}
+}
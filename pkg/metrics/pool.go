@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired      *prometheus.Desc
+	idle          *prometheus.Desc
+	total         *prometheus.Desc
+	max           *prometheus.Desc
+	acquireCount  *prometheus.Desc
+	acquireWait   *prometheus.Desc
+	emptyAcquire  *prometheus.Desc
+	canceledCount *prometheus.Desc
+}
+
+func newPoolCollector(node string, pool *pgxpool.Pool) *poolCollector {
+	// one collector per pool, node tells their series apart
+	labels := prometheus.Labels{"node": node}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(_namespace, "db_pool", name),
+			help,
+			nil,
+			labels,
+		)
+	}
+
+	return &poolCollector{
+		pool:          pool,
+		acquired:      desc("acquired_conns", "Connections currently acquired."),
+		idle:          desc("idle_conns", "Connections currently idle."),
+		total:         desc("total_conns", "Connections currently open."),
+		max:           desc("max_conns", "Maximum pool size."),
+		acquireCount:  desc("acquires_total", "Successful connection acquires."),
+		acquireWait:   desc("acquire_wait_seconds_total", "Time spent waiting to acquire."),
+		emptyAcquire:  desc("empty_acquires_total", "Acquires that waited for a connection."),
+		canceledCount: desc("canceled_acquires_total", "Acquires canceled by context."),
+	}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquireCount
+	ch <- c.acquireWait
+	ch <- c.emptyAcquire
+	ch <- c.canceledCount
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.pool.Stat()
+
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	counter := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v)
+	}
+
+	gauge(c.acquired, float64(s.AcquiredConns()))
+	gauge(c.idle, float64(s.IdleConns()))
+	gauge(c.total, float64(s.TotalConns()))
+	gauge(c.max, float64(s.MaxConns()))
+	counter(c.acquireCount, float64(s.AcquireCount()))
+	counter(c.acquireWait, s.AcquireDuration().Seconds())
+	counter(c.emptyAcquire, float64(s.EmptyAcquireCount()))
+	counter(c.canceledCount, float64(s.CanceledAcquireCount()))
+}
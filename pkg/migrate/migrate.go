@@ -0,0 +1,482 @@
+// Package migrate provides versioned SQL schema migrations for postgres
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lomifile/api/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	_defaultTable = "schema_migrations"
+	// _defaultLockID arbitrary key for pg_advisory_lock shared by every runner
+	_defaultLockID int64 = 7_253_194_001
+
+	// NoTransaction directive on the first line runs migration outside transaction
+	NoTransaction = "-- migrate:no-transaction"
+)
+
+var (
+	// ErrChecksumMismatch returned when applied migration file was edited afterwards
+	ErrChecksumMismatch = errors.New("migrate: checksum mismatch")
+	// ErrMissingDown returned when rolling back migration without down file
+	ErrMissingDown = errors.New("migrate: missing down migration")
+
+	fileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+	nameRe = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Migration Single versioned migration loaded from files
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// Status Migration state as reported by Status
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	// Missing applied migration which no longer has a file
+	Missing bool
+}
+
+// Migrator Applies migrations holding postgres advisory lock
+type Migrator struct {
+	pool   *pgxpool.Pool
+	fsys   fs.FS
+	l      *logger.Logger
+	table  string
+	lockID int64
+}
+
+// Option Provides function to migrator options
+type Option func(*Migrator)
+
+// WithTable overrides schema_migrations table name
+func WithTable(name string) Option { return func(m *Migrator) { m.table = name } }
+
+// WithLockID overrides advisory lock key
+func WithLockID(id int64) Option { return func(m *Migrator) { m.lockID = id } }
+
+// New creates migrator reading *.sql files from root of fsys
+func New(pool *pgxpool.Pool, fsys fs.FS, l *logger.Logger, opts ...Option) *Migrator {
+	m := &Migrator{
+		pool:   pool,
+		fsys:   fsys,
+		l:      l.Named("migrate"),
+		table:  _defaultTable,
+		lockID: _defaultLockID,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Load reads and sorts migrations from root of fsys
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read dir: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+
+		match := fileRe.FindStringSubmatch(e.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migrate: invalid file name %q", e.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid version in %q: %w", e.Name(), err)
+		}
+
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: read %q: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d has conflicting names", version)
+		}
+
+		if match[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if strings.TrimSpace(mig.Up) == "" {
+			return nil, fmt.Errorf(
+				"migrate: version %d has empty or missing up migration",
+				mig.Version,
+			)
+		}
+		mig.Checksum = checksum(mig.Up)
+		migrations = append(migrations, *mig)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var count int
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, migrations []Migration) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			row, ok := applied[mig.Version]
+			if ok {
+				if row.checksum != mig.Checksum {
+					return fmt.Errorf(
+						"%w: version %d (%s)",
+						ErrChecksumMismatch,
+						mig.Version,
+						mig.Name,
+					)
+				}
+				continue
+			}
+
+			if err := m.up(ctx, conn, mig); err != nil {
+				return err
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down rolls back n most recently applied migrations and returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	var count int
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, migrations []Migration) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		byVersion := make(map[int64]Migration, len(migrations))
+		for _, mig := range migrations {
+			byVersion[mig.Version] = mig
+		}
+
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+		for _, v := range versions {
+			if count >= n {
+				break
+			}
+			if err := m.down(ctx, conn, byVersion, v); err != nil {
+				return err
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Redo rolls back and reapplies the most recent migration, pending ones stay pending
+func (m *Migrator) Redo(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *pgxpool.Conn, migrations []Migration) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil || len(applied) == 0 {
+			return err
+		}
+
+		byVersion := make(map[int64]Migration, len(migrations))
+		for _, mig := range migrations {
+			byVersion[mig.Version] = mig
+		}
+		var latest int64
+		for v := range applied {
+			latest = max(latest, v)
+		}
+
+		if err := m.down(ctx, conn, byVersion, latest); err != nil {
+			return err
+		}
+		return m.up(ctx, conn, byVersion[latest])
+	})
+}
+
+// Status lists every known migration with applied time
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var out []Status
+
+	err := m.withLock(ctx, func(conn *pgxpool.Conn, migrations []Migration) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range migrations {
+			s := Status{Version: mig.Version, Name: mig.Name}
+			if row, ok := applied[mig.Version]; ok {
+				s.AppliedAt = &row.appliedAt
+				delete(applied, mig.Version)
+			}
+			out = append(out, s)
+		}
+
+		for v, row := range applied {
+			out = append(out, Status{
+				Version:   v,
+				Name:      row.name,
+				AppliedAt: &row.appliedAt,
+				Missing:   true,
+			})
+		}
+
+		sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+
+		return nil
+	})
+
+	return out, err
+}
+
+// Create writes empty up and down files for new migration into dir
+func Create(dir, name string, now time.Time) (string, string, error) {
+	slug := strings.Trim(nameRe.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if slug == "" {
+		return "", "", errors.New("migrate: migration name is required")
+	}
+
+	base := fmt.Sprintf("%s_%s", now.UTC().Format("20060102150405"), slug)
+	up := filepath.Join(dir, base+".up.sql")
+	down := filepath.Join(dir, base+".down.sql")
+
+	for _, path := range []string{up, down} {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err != nil {
+			return "", "", fmt.Errorf("migrate: create %q: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return "", "", err
+		}
+	}
+
+	return up, down, nil
+}
+
+type appliedRow struct {
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+func (m *Migrator) withLock(
+	ctx context.Context,
+	fn func(conn *pgxpool.Conn, migrations []Migration) error,
+) error {
+	migrations, err := Load(m.fsys)
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: acquire conn: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", m.lockID); err != nil {
+		return fmt.Errorf("migrate: advisory lock: %w", err)
+	}
+	defer func() {
+		// unlock on background context so cancelled ctx doesn't leave lock held on pooled conn
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", m.lockID)
+		if err != nil {
+			m.l.Error("advisory unlock failed", zap.Error(err))
+		}
+	}()
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+
+	return fn(conn, migrations)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *pgxpool.Conn) error {
+	_, err := conn.Exec(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT        NOT NULL,
+			checksum   TEXT        NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)`, pgx.Identifier{m.table}.Sanitize()))
+	if err != nil {
+		return fmt.Errorf("migrate: create table: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *pgxpool.Conn) (map[int64]appliedRow, error) {
+	rows, err := conn.Query(ctx, fmt.Sprintf(
+		"SELECT version, name, checksum, applied_at FROM %s",
+		pgx.Identifier{m.table}.Sanitize(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read applied: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[int64]appliedRow{}
+	for rows.Next() {
+		var (
+			v   int64
+			row appliedRow
+		)
+		if err := rows.Scan(&v, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		out[v] = row
+	}
+
+	return out, rows.Err()
+}
+
+// up applies mig and records it
+func (m *Migrator) up(ctx context.Context, conn *pgxpool.Conn, mig Migration) error {
+	start := time.Now()
+	if err := m.run(ctx, conn, mig.Up, func(tx pgx.Tx) error {
+		_, err := tx.Exec(
+			ctx,
+			fmt.Sprintf(
+				"INSERT INTO %s (version, name, checksum) VALUES ($1, $2, $3)",
+				pgx.Identifier{m.table}.Sanitize(),
+			),
+			mig.Version,
+			mig.Name,
+			mig.Checksum,
+		)
+		return err
+	}); err != nil {
+		return fmt.Errorf("migrate: up %d_%s: %w", mig.Version, mig.Name, err)
+	}
+
+	m.l.Info(
+		"migration applied",
+		zap.Int64("version", mig.Version),
+		zap.String("name", mig.Name),
+		zap.Duration("took", time.Since(start)),
+	)
+	return nil
+}
+
+// down rolls back applied version v and removes its record
+func (m *Migrator) down(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+	byVersion map[int64]Migration,
+	v int64,
+) error {
+	mig, ok := byVersion[v]
+	if !ok || strings.TrimSpace(mig.Down) == "" {
+		return fmt.Errorf("%w: version %d", ErrMissingDown, v)
+	}
+
+	if err := m.run(ctx, conn, mig.Down, func(tx pgx.Tx) error {
+		_, err := tx.Exec(
+			ctx,
+			fmt.Sprintf(
+				"DELETE FROM %s WHERE version = $1",
+				pgx.Identifier{m.table}.Sanitize(),
+			),
+			mig.Version,
+		)
+		return err
+	}); err != nil {
+		return fmt.Errorf("migrate: down %d_%s: %w", mig.Version, mig.Name, err)
+	}
+
+	m.l.Info(
+		"migration rolled back",
+		zap.Int64("version", mig.Version),
+		zap.String("name", mig.Name),
+	)
+	return nil
+}
+
+// run executes migration body and bookkeeping, in one transaction unless NoTransaction is set
+func (m *Migrator) run(
+	ctx context.Context,
+	conn *pgxpool.Conn,
+	body string,
+	record func(tx pgx.Tx) error,
+) error {
+	if strings.HasPrefix(strings.TrimSpace(body), NoTransaction) {
+		if _, err := conn.Exec(ctx, body); err != nil {
+			return err
+		}
+		return pgx.BeginFunc(ctx, conn, record)
+	}
+
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, body); err != nil {
+			return err
+		}
+		return record(tx)
+	})
+}
+
+func checksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,181 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lomifile/api/migrations"
+	"github.com/lomifile/api/pkg/logger"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"2_add_index.up.sql":      {Data: []byte("CREATE INDEX i ON t (c);")},
+		"1_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c INT);")},
+		"1_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+		"README.md":               {Data: []byte("ignored")},
+	}
+
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("len(Load()) = %d, want 2", len(got))
+	}
+	if got[0].Version != 1 || got[0].Name != "create_table" {
+		t.Errorf("got[0] = %d_%s, want 1_create_table", got[0].Version, got[0].Name)
+	}
+	if got[0].Down != "DROP TABLE t;" {
+		t.Errorf("got[0].Down = %q", got[0].Down)
+	}
+	if got[1].Version != 2 || got[1].Down != "" {
+		t.Errorf("got[1] = %+v, want version 2 without down", got[1])
+	}
+	if got[0].Checksum == "" || got[0].Checksum == got[1].Checksum {
+		t.Error("checksums should be set and differ")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"bad name", fstest.MapFS{"create_table.up.sql": {Data: []byte("x")}}},
+		{"uppercase name", fstest.MapFS{"1_Create.up.sql": {Data: []byte("x")}}},
+		{"down only", fstest.MapFS{"1_create.down.sql": {Data: []byte("x")}}},
+		{"conflicting names", fstest.MapFS{
+			"1_a.up.sql": {Data: []byte("x")},
+			"1_b.up.sql": {Data: []byte("y")},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.fsys); err == nil {
+				t.Error("Load() should return error")
+			}
+		})
+	}
+}
+
+func TestLoad_Embedded(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load(migrations.FS) error = %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatal("embedded migrations are empty")
+	}
+	for _, mig := range got {
+		if strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("migration %d_%s has no down file", mig.Version, mig.Name)
+		}
+	}
+}
+
+func TestChecksum_Stable(t *testing.T) {
+	if checksum("SELECT 1") != checksum("SELECT 1") {
+		t.Error("checksum should be deterministic")
+	}
+	if checksum("SELECT 1") == checksum("SELECT 2") {
+		t.Error("checksum should change with content")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	up, down, err := Create(dir, "Add Orders Table!", now)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if filepath.Base(up) != "20250304050607_add_orders_table.up.sql" {
+		t.Errorf("up = %v", filepath.Base(up))
+	}
+	if filepath.Base(down) != "20250304050607_add_orders_table.down.sql" {
+		t.Errorf("down = %v", filepath.Base(down))
+	}
+	for _, p := range []string{up, down} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("file %v not created: %v", p, err)
+		}
+	}
+
+	if _, _, err := Create(dir, "Add Orders Table!", now); err == nil {
+		t.Error("Create() should refuse to overwrite existing files")
+	}
+
+	// created files must be filled in before Load accepts them
+	if _, err := Load(os.DirFS(dir)); err == nil {
+		t.Error("Load() should reject empty up migration")
+	}
+}
+
+func TestCreate_EmptyName(t *testing.T) {
+	if _, _, err := Create(t.TempDir(), " !! ", time.Now()); err == nil {
+		t.Error("Create() with empty name should return error")
+	}
+}
+
+// TestMigrator_RedoKeepsPending runs against real database when TEST_DATABASE_URL is set
+func TestMigrator_RedoKeepsPending(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	table := "schema_migrations_redo_test"
+	drop := func() {
+		_, _ = pool.Exec(ctx, "DROP TABLE IF EXISTS "+table+", redo_a, redo_b")
+	}
+	drop()
+	t.Cleanup(drop)
+
+	applied := fstest.MapFS{
+		"1_create_a.up.sql":   {Data: []byte("CREATE TABLE redo_a (id INT);")},
+		"1_create_a.down.sql": {Data: []byte("DROP TABLE redo_a;")},
+	}
+	l := logger.New(logger.Config{})
+	if _, err := New(pool, applied, l, WithTable(table)).Up(ctx); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	// 2 is pending when redo runs, it must not be applied
+	all := fstest.MapFS{
+		"2_create_b.up.sql":   {Data: []byte("CREATE TABLE redo_b (id INT);")},
+		"2_create_b.down.sql": {Data: []byte("DROP TABLE redo_b;")},
+	}
+	for name, f := range applied {
+		all[name] = f
+	}
+	m := New(pool, all, l, WithTable(table))
+	if err := m.Redo(ctx); err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 2 || status[0].AppliedAt == nil || status[1].AppliedAt != nil {
+		t.Errorf("Status() = %+v, want 1 applied and 2 pending", status)
+	}
+}
@@ -0,0 +1,115 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lomifile/api/pkg/utils"
+)
+
+// ErrInvalidCursor returned when cursor can't be decoded
+var ErrInvalidCursor = errors.New("pagination: invalid cursor")
+
+// Cursor Position in keyset pagination, Values are sort column and tiebreaker of boundary row.
+// Before marks cursor pointing to previous page.
+type Cursor struct {
+	Sort   utils.SortOptions
+	Order  utils.SQLOrderTypes
+	Values []any
+	Before bool
+}
+
+// cursorValue keeps type of value so database receives time or number, not string
+type cursorValue struct {
+	T string `json:"t"`
+	V string `json:"v"`
+}
+
+type cursorJSON struct {
+	Sort   string        `json:"s"`
+	Order  string        `json:"o"`
+	Values []cursorValue `json:"v"`
+	Before bool          `json:"b,omitempty"`
+}
+
+// Encode returns opaque url safe cursor
+func (c Cursor) Encode() string {
+	raw := cursorJSON{Sort: string(c.Sort), Order: string(c.Order), Before: c.Before}
+	for _, v := range c.Values {
+		raw.Values = append(raw.Values, encodeValue(v))
+	}
+
+	b, _ := json.Marshal(raw)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses cursor created by Encode
+func DecodeCursor(s string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var raw cursorJSON
+	if err := json.Unmarshal(b, &raw); err != nil || len(raw.Values) == 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	c := Cursor{
+		Sort:   utils.SortOptions(raw.Sort),
+		Order:  utils.SQLOrderTypes(raw.Order),
+		Before: raw.Before,
+	}
+	// cursor comes from client, order must not carry anything else into SQL
+	if c.Order != utils.Asc && c.Order != utils.Desc {
+		return Cursor{}, ErrInvalidCursor
+	}
+	for _, v := range raw.Values {
+		dv, err := decodeValue(v)
+		if err != nil {
+			return Cursor{}, ErrInvalidCursor
+		}
+		c.Values = append(c.Values, dv)
+	}
+
+	return c, nil
+}
+
+func encodeValue(v any) cursorValue {
+	switch v := v.(type) {
+	case time.Time:
+		return cursorValue{T: "t", V: v.Format(time.RFC3339Nano)}
+	case int:
+		return cursorValue{T: "i", V: strconv.FormatInt(int64(v), 10)}
+	case int32:
+		return cursorValue{T: "i", V: strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return cursorValue{T: "i", V: strconv.FormatInt(v, 10)}
+	case float64:
+		return cursorValue{T: "f", V: strconv.FormatFloat(v, 'g', -1, 64)}
+	case bool:
+		return cursorValue{T: "b", V: strconv.FormatBool(v)}
+	default:
+		return cursorValue{T: "s", V: fmt.Sprint(v)}
+	}
+}
+
+func decodeValue(v cursorValue) (any, error) {
+	switch v.T {
+	case "t":
+		return time.Parse(time.RFC3339Nano, v.V)
+	case "i":
+		return strconv.ParseInt(v.V, 10, 64)
+	case "f":
+		return strconv.ParseFloat(v.V, 64)
+	case "b":
+		return strconv.ParseBool(v.V)
+	case "s":
+		return v.V, nil
+	}
+	return nil, ErrInvalidCursor
+}
@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lomifile/api/pkg/utils"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := Cursor{
+		Sort:  "created_at",
+		Order: utils.Asc,
+		Values: []any{
+			time.Date(2025, 1, 2, 3, 4, 5, 600, time.UTC),
+			int64(9007199254740993),
+			1.5,
+			true,
+			"name",
+		},
+		Before: true,
+	}
+
+	encoded := c.Encode()
+	if strings.ContainsAny(encoded, "+/=") {
+		t.Errorf("Encode() = %q, want url safe", encoded)
+	}
+
+	got, err := DecodeCursor(encoded)
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("DecodeCursor() = %#v, want %#v", got, c)
+	}
+}
+
+func TestCursor_IntTypes(t *testing.T) {
+	got, err := DecodeCursor(Cursor{Order: utils.Asc, Values: []any{7, int32(8)}}.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Values, []any{int64(7), int64(8)}) {
+		t.Errorf("Values = %#v, want int64", got.Values)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"not base64!",
+		"bm90IGpzb24",
+		"eyJ2IjpbXX0",
+		// order other than ASC or DESC
+		base64.RawURLEncoding.EncodeToString([]byte(`{"o":"up","v":[{"t":"i","v":"1"}]}`)),
+	} {
+		if _, err := DecodeCursor(raw); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) error = %v, want ErrInvalidCursor", raw, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pagination
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+// OffsetPage builds page response from rows fetched with LimitClause and total row count,
+// and sets Link header with first, prev, next and last pages
+func OffsetPage[T any](
+	c *fiber.Ctx,
+	p Params,
+	rows []T,
+	total int,
+) utils.PaginationResponse[[]T] {
+	hasNext := len(rows) > p.Limit
+	if hasNext {
+		rows = rows[:p.Limit]
+	}
+
+	meta := utils.PaginationResponseMeta{HasNextPage: hasNext, HasPrevPage: p.Page > 1}
+	links := []string{link(c, "first", "page", "1")}
+	if meta.HasPrevPage {
+		prev := p.Page - 1
+		meta.Prev = &prev
+		links = append(links, link(c, "prev", "page", strconv.Itoa(prev)))
+	}
+	if hasNext {
+		next := p.Page + 1
+		meta.Next = &next
+		links = append(links, link(c, "next", "page", strconv.Itoa(next)))
+	}
+	if total > 0 {
+		last := (total + p.Limit - 1) / p.Limit
+		links = append(links, link(c, "last", "page", strconv.Itoa(last)))
+	}
+	c.Set(fiber.HeaderLink, strings.Join(links, ", "))
+
+	return utils.PaginationResponse[[]T]{Items: rows, Total: total, Meta: meta}
+}
+
+// KeysetPage builds page response from rows fetched with Where and LimitClause, key returns
+// sort column and tiebreaker values of row in that order. Total is left for caller to set,
+// counting rows defeats keyset pagination on large tables.
+func KeysetPage[T any](
+	c *fiber.Ctx,
+	p Params,
+	rows []T,
+	key func(T) []any,
+) utils.PaginationResponse[[]T] {
+	more := len(rows) > p.Limit
+	if more {
+		rows = rows[:p.Limit]
+	}
+	if p.backward() {
+		// backward pages are fetched in reverse order
+		slices.Reverse(rows)
+	}
+
+	meta := utils.PaginationResponseMeta{
+		HasNextPage: more || p.backward(),
+		HasPrevPage: p.Cursor != nil && (more || !p.backward()),
+	}
+	links := []string{link(c, "first", "cursor", "")}
+	if len(rows) > 0 && meta.HasPrevPage {
+		meta.PrevCursor = p.cursor(key(rows[0]), true).Encode()
+		links = append(links, link(c, "prev", "cursor", meta.PrevCursor))
+	}
+	if len(rows) > 0 && meta.HasNextPage {
+		meta.NextCursor = p.cursor(key(rows[len(rows)-1]), false).Encode()
+		links = append(links, link(c, "next", "cursor", meta.NextCursor))
+	}
+	c.Set(fiber.HeaderLink, strings.Join(links, ", "))
+
+	return utils.PaginationResponse[[]T]{Items: rows, Meta: meta}
+}
+
+func (p Params) cursor(values []any, before bool) Cursor {
+	return Cursor{Sort: p.Sort, Order: p.Order, Values: values, Before: before}
+}
+
+// link formats RFC 8288 link to current URL with param replaced, empty value removes param
+func link(c *fiber.Ctx, rel, param, value string) string {
+	q := url.Values{}
+	c.Request().URI().QueryArgs().VisitAll(func(k, v []byte) {
+		q.Add(string(k), string(v))
+	})
+	if value == "" {
+		q.Del(param)
+	} else {
+		q.Set(param, value)
+	}
+
+	u := c.BaseURL() + c.Path()
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	return "<" + u + `>; rel="` + rel + `"`
+}
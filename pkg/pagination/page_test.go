@@ -0,0 +1,148 @@
+package pagination
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+type row struct {
+	ID int64
+}
+
+func rows(ids ...int64) []row {
+	out := make([]row, len(ids))
+	for i, id := range ids {
+		out[i] = row{ID: id}
+	}
+	return out
+}
+
+func rowKey(r row) []any {
+	return []any{r.ID}
+}
+
+// serve runs page builder for request and returns response with Link header
+func serve[T any](
+	t *testing.T,
+	target string,
+	build func(*fiber.Ctx) utils.PaginationResponse[T],
+) (utils.PaginationResponse[T], string) {
+	t.Helper()
+
+	var got utils.PaginationResponse[T]
+	app := fiber.New()
+	app.Get("/users", func(c *fiber.Ctx) error {
+		got = build(c)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	if err != nil {
+		t.Fatalf("app.Test failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	return got, resp.Header.Get(fiber.HeaderLink)
+}
+
+func TestOffsetPage(t *testing.T) {
+	p := Params{Page: 2, Limit: 2}
+
+	got, links := serve(t, "/users?page=2&limit=2&sort=name",
+		func(c *fiber.Ctx) utils.PaginationResponse[[]row] {
+			return OffsetPage(c, p, rows(3, 4, 5), 7)
+		})
+
+	if !reflect.DeepEqual(got.Items, rows(3, 4)) || got.Total != 7 {
+		t.Errorf("Items, Total = %v, %d", got.Items, got.Total)
+	}
+	m := got.Meta
+	if !m.HasNextPage || !m.HasPrevPage || *m.Next != 3 || *m.Prev != 1 {
+		t.Errorf("Meta = %+v", m)
+	}
+
+	want := []string{
+		`<http://example.com/users?limit=2&page=1&sort=name>; rel="first"`,
+		`<http://example.com/users?limit=2&page=1&sort=name>; rel="prev"`,
+		`<http://example.com/users?limit=2&page=3&sort=name>; rel="next"`,
+		`<http://example.com/users?limit=2&page=4&sort=name>; rel="last"`,
+	}
+	if links != strings.Join(want, ", ") {
+		t.Errorf("Link = %s", links)
+	}
+}
+
+func TestOffsetPage_LastPage(t *testing.T) {
+	got, links := serve(t, "/users", func(c *fiber.Ctx) utils.PaginationResponse[[]row] {
+		return OffsetPage(c, Params{Page: 1, Limit: 5}, rows(1, 2), 2)
+	})
+
+	if got.Meta.HasNextPage || got.Meta.HasPrevPage || got.Meta.Next != nil {
+		t.Errorf("Meta = %+v, want single page", got.Meta)
+	}
+	if strings.Contains(links, `rel="next"`) || strings.Contains(links, `rel="prev"`) {
+		t.Errorf("Link = %s, want only first and last", links)
+	}
+}
+
+func TestKeysetPage_Forward(t *testing.T) {
+	p := Params{Limit: 2, Keyset: true, Sort: "id", Order: utils.Asc}
+
+	first, links := serve(t, "/users?limit=2", func(c *fiber.Ctx) utils.PaginationResponse[[]row] {
+		return KeysetPage(c, p, rows(1, 2, 3), rowKey)
+	})
+	if !reflect.DeepEqual(first.Items, rows(1, 2)) {
+		t.Errorf("Items = %v", first.Items)
+	}
+	if !first.Meta.HasNextPage || first.Meta.HasPrevPage || first.Meta.PrevCursor != "" {
+		t.Errorf("first page Meta = %+v", first.Meta)
+	}
+	wantNext := "/users?cursor=" + first.Meta.NextCursor + `&limit=2>; rel="next"`
+	if !strings.Contains(links, wantNext) {
+		t.Errorf("Link = %s, want next cursor", links)
+	}
+
+	next, err := DecodeCursor(first.Meta.NextCursor)
+	if err != nil || next.Before || !reflect.DeepEqual(next.Values, []any{int64(2)}) {
+		t.Fatalf("next cursor = %+v, %v", next, err)
+	}
+
+	p.Cursor = &next
+	second, _ := serve(t, "/users", func(c *fiber.Ctx) utils.PaginationResponse[[]row] {
+		return KeysetPage(c, p, rows(3, 4), rowKey)
+	})
+	if second.Meta.HasNextPage || !second.Meta.HasPrevPage || second.Meta.NextCursor != "" {
+		t.Errorf("last page Meta = %+v", second.Meta)
+	}
+
+	prev, _ := DecodeCursor(second.Meta.PrevCursor)
+	if !prev.Before || !reflect.DeepEqual(prev.Values, []any{int64(3)}) {
+		t.Errorf("prev cursor = %+v", prev)
+	}
+}
+
+func TestKeysetPage_Backward(t *testing.T) {
+	p := Params{
+		Limit:  2,
+		Keyset: true,
+		Order:  utils.Asc,
+		Cursor: &Cursor{Values: []any{int64(5)}, Before: true},
+	}
+
+	// backward query returns rows in reverse order, with extra row when more exist
+	got, _ := serve(t, "/users", func(c *fiber.Ctx) utils.PaginationResponse[[]row] {
+		return KeysetPage(c, p, rows(4, 3, 2), rowKey)
+	})
+
+	if !reflect.DeepEqual(got.Items, rows(3, 4)) {
+		t.Errorf("Items = %v, want original order", got.Items)
+	}
+	if !got.Meta.HasNextPage || !got.Meta.HasPrevPage {
+		t.Errorf("Meta = %+v", got.Meta)
+	}
+}
@@ -0,0 +1,248 @@
+// Package pagination parses page, limit, sort and cursor query parameters, builds SQL
+// clauses for them and fills utils.PaginationResponse with RFC 8288 Link headers
+package pagination
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+const (
+	_defaultLimit      = 20
+	_defaultMaxLimit   = 100
+	_defaultTiebreaker = "id"
+)
+
+// Options Endpoint pagination settings, Sortable maps sort names clients may use to columns.
+// Sortable columns and Tiebreaker must be NOT NULL, Tiebreaker must be unique.
+type Options struct {
+	Sortable     map[string]string
+	DefaultSort  utils.SortOptions
+	DefaultOrder utils.SQLOrderTypes
+	DefaultLimit int
+	MaxLimit     int
+	Tiebreaker   string
+	// Keyset uses cursor instead of page, page query parameter is rejected
+	Keyset bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.DefaultLimit <= 0 {
+		o.DefaultLimit = _defaultLimit
+	}
+	if o.MaxLimit <= 0 {
+		o.MaxLimit = _defaultMaxLimit
+	}
+	if o.Tiebreaker == "" {
+		o.Tiebreaker = _defaultTiebreaker
+	}
+	if o.DefaultOrder == "" {
+		o.DefaultOrder = utils.Desc
+	}
+	return o
+}
+
+// Params Parsed pagination request, Cursor is set for keyset pages after the first one
+type Params struct {
+	Page   int
+	Limit  int
+	Sort   utils.SortOptions
+	Order  utils.SQLOrderTypes
+	Cursor *Cursor
+	Keyset bool
+
+	column     string
+	tiebreaker string
+}
+
+// Parse reads page, limit, sort, order and cursor query parameters, sort must be one of
+// o.Sortable. "sort=-name" is shorthand for "sort=name&order=desc".
+func Parse(c *fiber.Ctx, o Options) (Params, error) {
+	o = o.withDefaults()
+	p := Params{
+		Page:       1,
+		Limit:      o.DefaultLimit,
+		Sort:       o.DefaultSort,
+		Order:      o.DefaultOrder,
+		Keyset:     o.Keyset,
+		tiebreaker: o.Tiebreaker,
+	}
+
+	var fields []apperror.FieldError
+	invalid := func(field, code, message string) {
+		fields = append(fields, apperror.FieldError{Field: field, Code: code, Message: message})
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || n < 1:
+			invalid("limit", "min", "must be a positive number")
+		case n > o.MaxLimit:
+			invalid("limit", "max", fmt.Sprintf("must be at most %d", o.MaxLimit))
+		default:
+			p.Limit = n
+		}
+	}
+
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		switch {
+		case o.Keyset:
+			invalid("page", "unsupported", "use cursor to paginate")
+		case err != nil || n < 1:
+			invalid("page", "min", "must be a positive number")
+		default:
+			p.Page = n
+		}
+	}
+
+	if raw := c.Query("sort"); raw != "" {
+		if name, ok := strings.CutPrefix(raw, "-"); ok {
+			raw, p.Order = name, utils.Desc
+		}
+		p.Sort = utils.SortOptions(raw)
+	}
+	if raw := c.Query("order"); raw != "" {
+		switch order := utils.SQLOrderTypes(strings.ToUpper(raw)); order {
+		case utils.Asc, utils.Desc:
+			p.Order = order
+		default:
+			invalid("order", "oneof", "must be one of: asc, desc")
+		}
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := DecodeCursor(raw)
+		switch {
+		case !o.Keyset:
+			invalid("cursor", "unsupported", "use page to paginate")
+		case err != nil:
+			invalid("cursor", "invalid_cursor", "is not a valid cursor")
+		default:
+			// cursor is bound to sort it was created with
+			p.Cursor, p.Sort, p.Order = &cur, cur.Sort, cur.Order
+		}
+	}
+
+	// without sort rows are ordered by tiebreaker only
+	if p.Sort != "" {
+		column, ok := o.Sortable[string(p.Sort)]
+		if !ok {
+			names := strings.Join(sortNames(o.Sortable), ", ")
+			invalid("sort", "oneof", "must be one of: "+names)
+		}
+		p.column = column
+	}
+	if p.Cursor != nil && len(p.Cursor.Values) != len(p.keyColumns()) {
+		invalid("cursor", "invalid_cursor", "is not a valid cursor")
+	}
+
+	if len(fields) > 0 {
+		return Params{}, apperror.Validation("Invalid pagination parameters", fields...)
+	}
+	return p, nil
+}
+
+// Offset returns number of rows skipped by page
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// OrderBy returns ORDER BY clause, order is reversed when paging backwards with cursor
+func (p Params) OrderBy() string {
+	// fixed keywords, Order is never written to SQL as is
+	dir := " ASC"
+	if p.queryOrder() == utils.Desc {
+		dir = " DESC"
+	}
+	cols := p.keyColumns()
+	for i, col := range cols {
+		cols[i] = col + dir
+	}
+	return "ORDER BY " + strings.Join(cols, ", ")
+}
+
+// LimitClause returns LIMIT, and OFFSET for page pagination. One extra row is requested
+// to detect next page, pass all fetched rows to OffsetPage or KeysetPage.
+func (p Params) LimitClause() string {
+	if p.Keyset {
+		return fmt.Sprintf("LIMIT %d", p.Limit+1)
+	}
+	return fmt.Sprintf("LIMIT %d OFFSET %d", p.Limit+1, p.Offset())
+}
+
+// Where returns keyset condition using placeholders starting at $argN, and its args.
+// Condition is empty on first page.
+func (p Params) Where(argN int) (string, []any) {
+	if p.Cursor == nil {
+		return "", nil
+	}
+
+	op := ">"
+	if p.queryOrder() == utils.Desc {
+		op = "<"
+	}
+
+	cols := p.keyColumns()
+	holders := make([]string, len(cols))
+	for i := range cols {
+		holders[i] = "$" + strconv.Itoa(argN+i)
+	}
+
+	if len(cols) == 1 {
+		return fmt.Sprintf("%s %s %s", cols[0], op, holders[0]), p.Cursor.Values
+	}
+	return fmt.Sprintf(
+		"(%s) %s (%s)",
+		strings.Join(cols, ", "),
+		op,
+		strings.Join(holders, ", "),
+	), p.Cursor.Values
+}
+
+// Clause returns ORDER BY with LIMIT and OFFSET clauses
+func (p Params) Clause() string {
+	return p.OrderBy() + " " + p.LimitClause()
+}
+
+// keyColumns returns sort column followed by tiebreaker for stable order
+func (p Params) keyColumns() []string {
+	tiebreaker := p.tiebreaker
+	if tiebreaker == "" {
+		tiebreaker = _defaultTiebreaker
+	}
+	if p.column == "" || p.column == tiebreaker {
+		return []string{tiebreaker}
+	}
+	return []string{p.column, tiebreaker}
+}
+
+func (p Params) backward() bool {
+	return p.Cursor != nil && p.Cursor.Before
+}
+
+func (p Params) queryOrder() utils.SQLOrderTypes {
+	if !p.backward() {
+		return p.Order
+	}
+	if p.Order == utils.Desc {
+		return utils.Asc
+	}
+	return utils.Desc
+}
+
+func sortNames(m map[string]string) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,176 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lomifile/api/pkg/apperror"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+var _testOptions = Options{
+	Sortable: map[string]string{
+		"created_at": "u.created_at",
+		"name":       "u.name",
+		"id":         "u.id",
+	},
+	DefaultSort: "created_at",
+	Tiebreaker:  "u.id",
+}
+
+// parse runs Parse for query string inside fiber handler
+func parse(t *testing.T, o Options, query string) (Params, error) {
+	t.Helper()
+
+	var p Params
+	var err error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		p, err = Parse(c, o)
+		return nil
+	})
+	if _, testErr := app.Test(httptest.NewRequest("GET", "/?"+query, nil)); testErr != nil {
+		t.Fatalf("app.Test failed: %v", testErr)
+	}
+	return p, err
+}
+
+func TestParse_Defaults(t *testing.T) {
+	p, err := parse(t, _testOptions, "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if p.Page != 1 || p.Limit != _defaultLimit || p.Sort != "created_at" || p.Order != utils.Desc {
+		t.Errorf("Parse() = %+v", p)
+	}
+	if got := p.Clause(); got != "ORDER BY u.created_at DESC, u.id DESC LIMIT 21 OFFSET 0" {
+		t.Errorf("Clause() = %q", got)
+	}
+}
+
+func TestParse_Query(t *testing.T) {
+	p, err := parse(t, _testOptions, "page=3&limit=10&sort=name&order=asc")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if p.Offset() != 20 {
+		t.Errorf("Offset() = %d, want 20", p.Offset())
+	}
+	if got := p.Clause(); got != "ORDER BY u.name ASC, u.id ASC LIMIT 11 OFFSET 20" {
+		t.Errorf("Clause() = %q", got)
+	}
+
+	p, err = parse(t, _testOptions, "sort=-id")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := p.OrderBy(); got != "ORDER BY u.id DESC" {
+		t.Errorf("OrderBy() sorted by tiebreaker = %q", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := parse(t, _testOptions,
+		"page=0&limit=500&sort=password_hash&order=sideways&cursor=abc")
+
+	ae, ok := apperror.As(err)
+	if !ok || ae.Kind != apperror.KindValidation {
+		t.Fatalf("Parse() error = %v, want validation error", err)
+	}
+	got := map[string]string{}
+	for _, f := range ae.Fields {
+		got[f.Field] = f.Code
+	}
+	want := map[string]string{
+		"page":   "min",
+		"limit":  "max",
+		"sort":   "oneof",
+		"order":  "oneof",
+		"cursor": "unsupported",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("field errors = %v, want %v", got, want)
+	}
+}
+
+func TestParse_Keyset(t *testing.T) {
+	o := _testOptions
+	o.Keyset = true
+
+	if _, err := parse(t, o, "page=2"); err == nil {
+		t.Error("Parse() with page on keyset endpoint should fail")
+	}
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+	cur := Cursor{Sort: "created_at", Order: utils.Desc, Values: []any{ts, int64(42)}}
+
+	// cursor keeps sort it was created with
+	p, err := parse(t, o, "sort=name&limit=5&cursor="+cur.Encode())
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if p.Sort != "created_at" || p.LimitClause() != "LIMIT 6" {
+		t.Errorf("Parse() = %+v, %q", p, p.LimitClause())
+	}
+
+	where, args := p.Where(3)
+	if where != "(u.created_at, u.id) < ($3, $4)" {
+		t.Errorf("Where() = %q", where)
+	}
+	if !reflect.DeepEqual(args, []any{ts, int64(42)}) {
+		t.Errorf("Where() args = %#v", args)
+	}
+
+	short := Cursor{Sort: "created_at", Order: utils.Desc, Values: []any{ts}}
+	if _, err := parse(t, o, "cursor="+short.Encode()); err == nil {
+		t.Error("Parse() with cursor missing tiebreaker should fail")
+	}
+}
+
+func TestParse_MaliciousCursor(t *testing.T) {
+	o := _testOptions
+	o.Keyset = true
+
+	// base64 of {"s":"","o":"ASC; DROP TABLE users; --","v":[{"t":"i","v":"1"}]}
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(
+		`{"s":"","o":"ASC; DROP TABLE users; --","v":[{"t":"i","v":"1"}]}`,
+	))
+	p, err := parse(t, o, "cursor="+cursor)
+
+	ae, ok := apperror.As(err)
+	if !ok || len(ae.Fields) != 1 || ae.Fields[0].Code != "invalid_cursor" {
+		t.Fatalf("Parse() = %q, %v, want invalid_cursor", p.Clause(), err)
+	}
+
+	// order outside of ASC and DESC never reaches SQL
+	p = Params{Order: "ASC; DROP TABLE users; --", Limit: 20}
+	if got := p.OrderBy(); got != "ORDER BY id ASC" {
+		t.Errorf("OrderBy() = %q, want ORDER BY id ASC", got)
+	}
+}
+
+func TestParams_Backward(t *testing.T) {
+	p := Params{
+		Order:      utils.Asc,
+		Limit:      10,
+		Keyset:     true,
+		Cursor:     &Cursor{Values: []any{int64(7)}, Before: true},
+		tiebreaker: "id",
+	}
+
+	if got := p.OrderBy(); got != "ORDER BY id DESC" {
+		t.Errorf("OrderBy() = %q, want reversed order", got)
+	}
+	if where, _ := p.Where(1); where != "id < $1" {
+		t.Errorf("Where() = %q", where)
+	}
+	if where, args := (Params{tiebreaker: "id"}).Where(1); where != "" || args != nil {
+		t.Errorf("Where() without cursor = %q, %v", where, args)
+	}
+}
@@ -0,0 +1,148 @@
+// Package password provides password hashing with argon2id and bcrypt verification
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	_defaultTime    uint32 = 3
+	_defaultMemory  uint32 = 64 * 1024
+	_defaultThreads uint8  = 2
+	_defaultKeyLen  uint32 = 32
+	_defaultSaltLen        = 16
+)
+
+var (
+	// ErrMismatch returned when password doesn't match hash
+	ErrMismatch = errors.New("password: mismatch")
+	// ErrUnknownFormat returned when hash format is not supported
+	ErrUnknownFormat = errors.New("password: unknown hash format")
+)
+
+// Params argon2id cost parameters
+type Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen int
+}
+
+// DefaultParams argon2id parameters recommended by RFC 9106 for low memory environments
+var DefaultParams = Params{
+	Time:    _defaultTime,
+	Memory:  _defaultMemory,
+	Threads: _defaultThreads,
+	KeyLen:  _defaultKeyLen,
+	SaltLen: _defaultSaltLen,
+}
+
+// Hash hashes password with argon2id using DefaultParams and returns PHC encoded string
+func Hash(password string) (string, error) {
+	return HashWithParams(password, DefaultParams)
+}
+
+// HashWithParams hashes password with argon2id using given params
+func HashWithParams(password string, p Params) (string, error) {
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("password: salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		p.Memory,
+		p.Time,
+		p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify compares password with argon2id or bcrypt encoded hash
+func Verify(password, encoded string) error {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"),
+		strings.HasPrefix(encoded, "$2b$"),
+		strings.HasPrefix(encoded, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			return ErrMismatch
+		}
+		return nil
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// NeedsRehash reports whether hash should be upgraded to argon2id with DefaultParams
+func NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Time != DefaultParams.Time ||
+		p.Memory != DefaultParams.Memory ||
+		p.Threads != DefaultParams.Threads
+}
+
+func verifyArgon2id(password, encoded string) error {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrMismatch
+	}
+
+	return nil
+}
+
+func decodeArgon2id(encoded string) (Params, []byte, []byte, error) {
+	var p Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+	if version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("password: unsupported argon2 version %d", version)
+	}
+
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads)
+	if err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownFormat
+	}
+
+	return p, salt, key, nil
+}
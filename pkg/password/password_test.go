@@ -0,0 +1,88 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHash_Verify(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$") {
+		t.Errorf("Hash() = %v, want argon2id PHC string", hash)
+	}
+	if err := Verify("correct horse battery staple", hash); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := Verify("wrong", hash); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Verify(wrong) error = %v, want %v", err, ErrMismatch)
+	}
+}
+
+func TestHash_UniqueSalt(t *testing.T) {
+	a, _ := Hash("secret")
+	b, _ := Hash("secret")
+
+	if a == b {
+		t.Error("Hash() should produce different hashes for the same password")
+	}
+}
+
+func TestVerify_Bcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword() error = %v", err)
+	}
+
+	if err := Verify("secret", string(hash)); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := Verify("wrong", string(hash)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("Verify(wrong) error = %v, want %v", err, ErrMismatch)
+	}
+	if !NeedsRehash(string(hash)) {
+		t.Error("NeedsRehash(bcrypt) = false, want true")
+	}
+}
+
+func TestVerify_UnknownFormat(t *testing.T) {
+	tests := []string{
+		"",
+		"plaintext",
+		"$argon2id$v=19$broken",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+	}
+
+	for _, encoded := range tests {
+		if err := Verify("secret", encoded); err == nil {
+			t.Errorf("Verify(%q) should return error", encoded)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current, _ := Hash("secret")
+	if NeedsRehash(current) {
+		t.Error("NeedsRehash(current) = true, want false")
+	}
+
+	weak, _ := HashWithParams("secret", Params{
+		Time:    1,
+		Memory:  8 * 1024,
+		Threads: 1,
+		KeyLen:  32,
+		SaltLen: 16,
+	})
+	if !NeedsRehash(weak) {
+		t.Error("NeedsRehash(weak) = false, want true")
+	}
+	if err := Verify("secret", weak); err != nil {
+		t.Errorf("Verify(weak) error = %v", err)
+	}
+}
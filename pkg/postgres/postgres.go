@@ -3,27 +3,59 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/retry"
+	"go.uber.org/zap"
 )
 
 const (
-	_defaultMaxConns     int32         = 4
-	_defaultConnAttempts               = 10
-	_defaultConnTimeout  time.Duration = time.Second
+	_defaultMaxConns      int32         = 4
+	_defaultConnAttempts                = 10
+	_defaultConnTimeout   time.Duration = time.Second
+	_defaultMaxReplicaLag               = 10 * time.Second
+	_defaultCheckInterval               = 5 * time.Second
+	_defaultRetryInitial                = 250 * time.Millisecond
+	_defaultRetryMax                    = 5 * time.Second
 )
 
 // Postgres main postgres pool struct
 type Postgres struct {
-	maxConns     int32
-	connAttempts int
-	connTimeout  time.Duration
+	l *logger.Logger
 
-	Pool *pgxpool.Pool
+	maxConns       int32
+	minConns       int32
+	minIdleConns   int32
+	maxLifetime    time.Duration
+	lifetimeJitter time.Duration
+	maxIdleTime    time.Duration
+	healthPeriod   time.Duration
+	execMode       *pgx.QueryExecMode
+	appName        string
+	connAttempts   int
+	connTimeout    time.Duration
+	retry          []retry.Option
+	tracer         pgx.QueryTracer
+	replicaDSNs    []string
+	balancer       Balancer
+	maxReplicaLag  time.Duration
+	checkInterval  time.Duration
+
+	// Pool primary pool, same as Primary.Pool
+	Pool     *pgxpool.Pool
+	Primary  *Node
+	Replicas []*Node
+
+	next uint64
+	stop context.CancelFunc
+	done chan struct{}
 }
 
 // Option Provides function to postgres options
@@ -32,25 +64,166 @@ type Option func(*Postgres)
 // WithMaxConns Adds maxMaxConns
 func WithMaxConns(n int32) Option { return func(p *Postgres) { p.maxConns = n } }
 
-// WithConnAttempts Increments connAttempts
+// WithMinConns sets connections pool keeps open even when idle
+func WithMinConns(n int32) Option { return func(p *Postgres) { p.minConns = n } }
+
+// WithMinIdleConns sets idle connections pool keeps ready for new requests
+func WithMinIdleConns(n int32) Option { return func(p *Postgres) { p.minIdleConns = n } }
+
+// WithMaxConnLifetime closes connections older than d plus random jitter, so they aren't
+// all replaced at once. Zero keeps pgx default of one hour.
+func WithMaxConnLifetime(d, jitter time.Duration) Option {
+	return func(p *Postgres) {
+		p.maxLifetime = d
+		p.lifetimeJitter = jitter
+	}
+}
+
+// WithMaxConnIdleTime closes connections idle longer than d, zero keeps pgx default
+func WithMaxConnIdleTime(d time.Duration) Option {
+	return func(p *Postgres) { p.maxIdleTime = d }
+}
+
+// WithHealthCheckPeriod sets how often idle connections are checked, zero keeps pgx default
+func WithHealthCheckPeriod(d time.Duration) Option {
+	return func(p *Postgres) { p.healthPeriod = d }
+}
+
+// WithQueryExecMode sets how queries are sent, exec or simple protocol are needed behind
+// PgBouncer in transaction mode. Default caches prepared statements.
+func WithQueryExecMode(m pgx.QueryExecMode) Option {
+	return func(p *Postgres) { p.execMode = &m }
+}
+
+// WithApplicationName sets application_name shown in pg_stat_activity
+func WithApplicationName(name string) Option { return func(p *Postgres) { p.appName = name } }
+
+// WithConnAttempts sets how many times connecting is tried, default 10
 func WithConnAttempts(n int) Option { return func(p *Postgres) { p.connAttempts = n } }
 
-// WithConnTimeout adds conn timeout
+// WithConnRetry tunes backoff between connect attempts, e.g. retry.WithMaxElapsed
+func WithConnRetry(opts ...retry.Option) Option {
+	return func(p *Postgres) { p.retry = append(p.retry, opts...) }
+}
+
+// WithConnTimeout sets timeout of connecting and of startup ping
 func WithConnTimeout(d time.Duration) Option {
 	return func(p *Postgres) { p.connTimeout = d }
 }
 
-// New creates new postgres pool
-func New(dsn string, opts ...Option) (*Postgres, error) {
+// WithQueryTracer sets tracer called around every query on pool connections
+func WithQueryTracer(t pgx.QueryTracer) Option { return func(p *Postgres) { p.tracer = t } }
+
+// WithReplicas adds read replicas, empty DSNs are ignored
+func WithReplicas(dsns ...string) Option {
+	return func(p *Postgres) {
+		for _, dsn := range dsns {
+			if dsn != "" {
+				p.replicaDSNs = append(p.replicaDSNs, dsn)
+			}
+		}
+	}
+}
+
+// WithBalancer sets how reads are spread over replicas, default is RoundRobin
+func WithBalancer(b Balancer) Option { return func(p *Postgres) { p.balancer = b } }
+
+// WithMaxReplicaLag sets replication lag after which replica stops serving reads,
+// zero disables lag check
+func WithMaxReplicaLag(d time.Duration) Option {
+	return func(p *Postgres) { p.maxReplicaLag = d }
+}
+
+// WithCheckInterval sets how often replica health and lag are checked
+func WithCheckInterval(d time.Duration) Option {
+	return func(p *Postgres) { p.checkInterval = d }
+}
+
+// New creates new postgres pool, connecting is retried with backoff until it succeeds,
+// attempts run out or ctx is done. Replicas are connected lazily and don't fail startup,
+// their health checks run until Close.
+func New(ctx context.Context, dsn string, l *logger.Logger, opts ...Option) (*Postgres, error) {
 	p := &Postgres{
-		maxConns:     _defaultMaxConns,
-		connAttempts: _defaultConnAttempts,
-		connTimeout:  _defaultConnTimeout,
+		l:             l.Named("postgres"),
+		maxConns:      _defaultMaxConns,
+		connAttempts:  _defaultConnAttempts,
+		connTimeout:   _defaultConnTimeout,
+		maxReplicaLag: _defaultMaxReplicaLag,
+		checkInterval: _defaultCheckInterval,
 	}
 	for _, opt := range opts {
 		opt(p)
 	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	cfg, err := p.poolConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	retryOpts := append([]retry.Option{
+		retry.WithInitial(_defaultRetryInitial),
+		retry.WithMax(_defaultRetryMax),
+		retry.WithMaxElapsed(0),
+		retry.WithMaxAttempts(p.connAttempts),
+		retry.WithOnRetry(func(attempt int, delay time.Duration, err error) {
+			p.l.Warn(
+				"connect failed, retrying",
+				zap.Int("attempt", attempt),
+				zap.Duration("delay", delay),
+				zap.Error(err),
+			)
+		}),
+	}, p.retry...)
+
+	var pool *pgxpool.Pool
+	err = retry.Do(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, p.connTimeout)
+		defer cancel()
+
+		candidate, err := pgxpool.NewWithConfig(ctx, cfg)
+		if err != nil {
+			// config errors won't go away
+			return retry.Permanent(err)
+		}
+		if err := candidate.Ping(ctx); err != nil {
+			candidate.Close()
+			return err
+		}
+		pool = candidate
+		return nil
+	}, retryOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("postgres: connect: %w", err)
+	}
+
+	p.Pool = pool
+	p.Primary = &Node{Name: "primary", Pool: pool}
+	p.Primary.healthy.Store(true)
+
+	for i, dsn := range p.replicaDSNs {
+		cfg, err := p.poolConfig(dsn)
+		if err == nil {
+			// pool without min conns doesn't dial until first use
+			pool, err = pgxpool.NewWithConfig(ctx, cfg)
+		}
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("postgres: replica %d: %w", i+1, err)
+		}
+		p.Replicas = append(p.Replicas, &Node{Name: fmt.Sprintf("replica-%d", i+1), Pool: pool})
+	}
+	if len(p.Replicas) > 0 {
+		p.checkReplicas(ctx)
+		p.monitor()
+	}
+
+	return p, nil
+}
 
+func (p *Postgres) poolConfig(dsn string) (*pgxpool.Config, error) {
 	u, err := url.Parse(dsn)
 	if err != nil {
 		return nil, fmt.Errorf("postgres: parse dsn: %w", err)
@@ -64,41 +237,92 @@ func New(dsn string, opts ...Option) (*Postgres, error) {
 		return nil, fmt.Errorf("postgres: parse pool cfg: %w", err)
 	}
 	cfg.MaxConns = p.maxConns
+	cfg.MinConns = p.minConns
+	cfg.MinIdleConns = p.minIdleConns
+	if p.maxLifetime > 0 {
+		cfg.MaxConnLifetime = p.maxLifetime
+	}
+	cfg.MaxConnLifetimeJitter = p.lifetimeJitter
+	if p.maxIdleTime > 0 {
+		cfg.MaxConnIdleTime = p.maxIdleTime
+	}
+	if p.healthPeriod > 0 {
+		cfg.HealthCheckPeriod = p.healthPeriod
+	}
+	if p.execMode != nil {
+		cfg.ConnConfig.DefaultQueryExecMode = *p.execMode
+	}
+	if p.appName != "" {
+		cfg.ConnConfig.RuntimeParams["application_name"] = p.appName
+	}
+	if p.connTimeout > 0 {
+		cfg.ConnConfig.ConnectTimeout = p.connTimeout
+	}
+	if p.tracer != nil {
+		cfg.ConnConfig.Tracer = p.tracer
+	}
+	return cfg, nil
+}
 
-	var pool *pgxpool.Pool
-	attempts := p.connAttempts
-
-	for {
-		ctx, cancel := context.WithTimeout(context.Background(), p.connTimeout)
-		pool, err = pgxpool.NewWithConfig(ctx, cfg)
-		cancel()
-		if err == nil {
-			ctx, cancel = context.WithTimeout(context.Background(), p.connTimeout)
-			err = pool.Ping(ctx)
-			cancel()
+// validate rejects pool settings pgxpool would silently misuse
+func (p *Postgres) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
 		}
-		if err == nil {
-			break
-		}
-		attempts--
-		if attempts <= 0 {
-			return nil, fmt.Errorf("postgres: connection attempts exhausted: %w", err)
-		}
-		log.Printf("postgres: retrying, attempts left=%d, err=%v", attempts, err)
-		time.Sleep(p.connTimeout)
 	}
 
-	return &Postgres{
-		Pool:         pool,
-		maxConns:     p.maxConns,
-		connAttempts: p.connAttempts,
-		connTimeout:  p.connTimeout,
-	}, nil
+	check(p.maxConns > 0, "max conns must be positive")
+	check(p.minConns >= 0 && p.minConns <= p.maxConns, "min conns must be between 0 and max conns")
+	check(
+		p.minIdleConns >= 0 && p.minIdleConns <= p.maxConns,
+		"min idle conns must be between 0 and max conns",
+	)
+	check(p.maxLifetime >= 0 && p.lifetimeJitter >= 0, "conn lifetime must not be negative")
+	check(p.lifetimeJitter == 0 || p.maxLifetime > 0, "conn lifetime jitter needs max lifetime")
+	check(p.maxIdleTime >= 0, "conn idle time must not be negative")
+	check(p.healthPeriod >= 0, "health check period must not be negative")
+	check(p.connTimeout > 0, "conn timeout must be positive")
+	check(p.connAttempts > 0, "conn attempts must be positive")
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("postgres: invalid options: %w", err)
+	}
+	return nil
+}
+
+// ParseQueryExecMode parses cache_statement, cache_describe, describe_exec, exec
+// or simple_protocol
+func ParseQueryExecMode(s string) (pgx.QueryExecMode, error) {
+	for _, m := range []pgx.QueryExecMode{
+		pgx.QueryExecModeCacheStatement,
+		pgx.QueryExecModeCacheDescribe,
+		pgx.QueryExecModeDescribeExec,
+		pgx.QueryExecModeExec,
+		pgx.QueryExecModeSimpleProtocol,
+	} {
+		// String uses spaces, DSN default_query_exec_mode uses underscores
+		if strings.ReplaceAll(m.String(), " ", "_") == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("postgres: unknown query exec mode %q", s)
 }
 
-// Close closes postgres pool
+// Close stops replica checks and closes all pools
 func (p *Postgres) Close() {
-	if p != nil && p.Pool != nil {
+	if p == nil {
+		return
+	}
+	if p.stop != nil {
+		p.stop()
+		<-p.done
+	}
+	for _, n := range p.Replicas {
+		n.Pool.Close()
+	}
+	if p.Pool != nil {
 		p.Pool.Close()
 	}
 }
@@ -1,10 +1,19 @@
 package postgres
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lomifile/api/pkg/logger"
+	"github.com/lomifile/api/pkg/retry"
 )
 
+var _testLogger = logger.New(logger.Config{})
+
 func TestWithMaxConns(t *testing.T) {
 	p := &Postgres{}
 	opt := WithMaxConns(10)
@@ -36,29 +45,51 @@ func TestWithConnTimeout(t *testing.T) {
 	}
 }
 
+type stubTracer struct{}
+
+func (stubTracer) TraceQueryStart(
+	ctx context.Context,
+	_ *pgx.Conn,
+	_ pgx.TraceQueryStartData,
+) context.Context {
+	return ctx
+}
+
+func (stubTracer) TraceQueryEnd(context.Context, *pgx.Conn, pgx.TraceQueryEndData) {}
+
+func TestWithQueryTracer(t *testing.T) {
+	p := &Postgres{}
+	opt := WithQueryTracer(stubTracer{})
+	opt(p)
+
+	if _, ok := p.tracer.(stubTracer); !ok {
+		t.Errorf("tracer = %T, want stubTracer", p.tracer)
+	}
+}
+
 func TestNew_InvalidDSN(t *testing.T) {
-	_, err := New("invalid-dsn")
+	_, err := New(context.Background(), "invalid-dsn", _testLogger)
 	if err == nil {
 		t.Error("New() with invalid DSN should return error")
 	}
 }
 
 func TestNew_InvalidScheme(t *testing.T) {
-	_, err := New("mysql://localhost:3306/db")
+	_, err := New(context.Background(), "mysql://localhost:3306/db", _testLogger)
 	if err == nil {
 		t.Error("New() with invalid scheme should return error")
 	}
 }
 
 func TestNew_EmptyDSN(t *testing.T) {
-	_, err := New("")
+	_, err := New(context.Background(), "", _testLogger)
 	if err == nil {
 		t.Error("New() with empty DSN should return error")
 	}
 }
 
 func TestNew_HTTPScheme(t *testing.T) {
-	_, err := New("http://localhost:5432/db")
+	_, err := New(context.Background(), "http://localhost:5432/db", _testLogger)
 	if err == nil {
 		t.Error("New() with http scheme should return error")
 	}
@@ -66,7 +97,13 @@ func TestNew_HTTPScheme(t *testing.T) {
 
 func TestNew_ValidSchemePostgres(t *testing.T) {
 	// This will fail to connect but should pass scheme validation
-	_, err := New("postgres://localhost:5432/db", WithConnAttempts(1), WithConnTimeout(100*time.Millisecond))
+	_, err := New(
+		context.Background(),
+		"postgres://localhost:5432/db",
+		_testLogger,
+		WithConnAttempts(1),
+		WithConnTimeout(100*time.Millisecond),
+	)
 	if err == nil {
 		t.Skip("Skipping: postgres server is available")
 	}
@@ -78,7 +115,13 @@ func TestNew_ValidSchemePostgres(t *testing.T) {
 
 func TestNew_ValidSchemePostgresql(t *testing.T) {
 	// This will fail to connect but should pass scheme validation
-	_, err := New("postgresql://localhost:5432/db", WithConnAttempts(1), WithConnTimeout(100*time.Millisecond))
+	_, err := New(
+		context.Background(),
+		"postgresql://localhost:5432/db",
+		_testLogger,
+		WithConnAttempts(1),
+		WithConnTimeout(100*time.Millisecond),
+	)
 	if err == nil {
 		t.Skip("Skipping: postgres server is available")
 	}
@@ -135,3 +178,128 @@ func TestMultipleOptions(t *testing.T) {
 		t.Errorf("connTimeout = %v, want %v", p.connTimeout, 2*time.Second)
 	}
 }
+
+func TestPoolOptions(t *testing.T) {
+	mode := pgx.QueryExecModeExec
+	p := &Postgres{
+		maxConns:       10,
+		minConns:       2,
+		minIdleConns:   1,
+		maxLifetime:    time.Hour,
+		lifetimeJitter: time.Minute,
+		maxIdleTime:    5 * time.Minute,
+		healthPeriod:   30 * time.Second,
+		execMode:       &mode,
+		appName:        "api-test",
+		connTimeout:    3 * time.Second,
+	}
+
+	cfg, err := p.poolConfig("postgres://localhost:5432/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxConns != 10 || cfg.MinConns != 2 || cfg.MinIdleConns != 1 {
+		t.Errorf("conns = %d/%d/%d, want 10/2/1", cfg.MaxConns, cfg.MinConns, cfg.MinIdleConns)
+	}
+	if cfg.MaxConnLifetime != time.Hour || cfg.MaxConnLifetimeJitter != time.Minute {
+		t.Errorf("lifetime = %v + %v", cfg.MaxConnLifetime, cfg.MaxConnLifetimeJitter)
+	}
+	if cfg.MaxConnIdleTime != 5*time.Minute || cfg.HealthCheckPeriod != 30*time.Second {
+		t.Errorf("idle time, health period = %v, %v", cfg.MaxConnIdleTime, cfg.HealthCheckPeriod)
+	}
+	cc := cfg.ConnConfig
+	if cc.DefaultQueryExecMode != mode || cc.RuntimeParams["application_name"] != "api-test" {
+		t.Errorf("exec mode, app name = %v, %q", cc.DefaultQueryExecMode,
+			cc.RuntimeParams["application_name"])
+	}
+	if cc.ConnectTimeout != 3*time.Second {
+		t.Errorf("ConnectTimeout = %v, want 3s", cc.ConnectTimeout)
+	}
+}
+
+func TestPoolOptions_ZeroKeepsDefaults(t *testing.T) {
+	cfg, err := (&Postgres{maxConns: 4}).poolConfig("postgres://localhost:5432/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxConnLifetime != time.Hour || cfg.HealthCheckPeriod != time.Minute {
+		t.Errorf("pgx defaults overwritten: %v, %v", cfg.MaxConnLifetime, cfg.HealthCheckPeriod)
+	}
+	if cfg.ConnConfig.DefaultQueryExecMode != pgx.QueryExecModeCacheStatement {
+		t.Errorf("DefaultQueryExecMode = %v", cfg.ConnConfig.DefaultQueryExecMode)
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	tests := map[string][]Option{
+		"max conns":    {WithMaxConns(0)},
+		"min conns":    {WithMaxConns(2), WithMinConns(3)},
+		"min idle":     {WithMinIdleConns(-1)},
+		"jitter":       {WithMaxConnLifetime(0, time.Second)},
+		"idle time":    {WithMaxConnIdleTime(-time.Second)},
+		"conn timeout": {WithConnTimeout(0)},
+	}
+	for name, opts := range tests {
+		_, err := New(context.Background(), "postgres://localhost:5432/db", _testLogger, opts...)
+		if err == nil || !strings.Contains(err.Error(), "invalid options") {
+			t.Errorf("New() with bad %s = %v, want invalid options error", name, err)
+		}
+	}
+}
+
+func TestParseQueryExecMode(t *testing.T) {
+	tests := map[string]pgx.QueryExecMode{
+		"cache_statement": pgx.QueryExecModeCacheStatement,
+		"cache_describe":  pgx.QueryExecModeCacheDescribe,
+		"describe_exec":   pgx.QueryExecModeDescribeExec,
+		"exec":            pgx.QueryExecModeExec,
+		"simple_protocol": pgx.QueryExecModeSimpleProtocol,
+	}
+	for in, want := range tests {
+		if got, err := ParseQueryExecMode(in); err != nil || got != want {
+			t.Errorf("ParseQueryExecMode(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseQueryExecMode("cache statement"); err == nil {
+		t.Error("ParseQueryExecMode() should reject unknown mode")
+	}
+}
+
+func TestNew_RetriesUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := New(
+		ctx,
+		"postgres://localhost:1/db",
+		_testLogger,
+		WithConnAttempts(1000),
+		WithConnTimeout(50*time.Millisecond),
+		WithConnRetry(retry.WithInitial(10*time.Millisecond), retry.WithMax(20*time.Millisecond)),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("New() = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("New() kept retrying %v after ctx was done", time.Since(start))
+	}
+}
+
+func TestNew_StopsAfterAttempts(t *testing.T) {
+	attempts := 0
+	_, err := New(
+		context.Background(),
+		"postgres://localhost:1/db",
+		_testLogger,
+		WithConnAttempts(3),
+		WithConnTimeout(50*time.Millisecond),
+		WithConnRetry(
+			retry.WithInitial(time.Millisecond),
+			retry.WithOnRetry(func(int, time.Duration, error) { attempts++ }),
+		),
+	)
+	if err == nil || attempts != 2 {
+		t.Errorf("New() = %v after %d retries, want error after 2", err, attempts)
+	}
+}
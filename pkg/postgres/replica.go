@@ -0,0 +1,157 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// _lagSQL returns replication lag in seconds, zero when replica replayed everything it
+// received so idle primary doesn't look like lag, NULL on primary
+const _lagSQL = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+END`
+
+// Balancer Strategy picking replica for reads
+type Balancer int
+
+const (
+	// RoundRobin rotates over healthy replicas
+	RoundRobin Balancer = iota
+	// LeastConns picks healthy replica with fewest acquired connections
+	LeastConns
+)
+
+// ParseBalancer parses round_robin or least_conns
+func ParseBalancer(s string) (Balancer, error) {
+	switch s {
+	case "round_robin", "":
+		return RoundRobin, nil
+	case "least_conns":
+		return LeastConns, nil
+	}
+	return 0, fmt.Errorf("postgres: unknown balancer %q", s)
+}
+
+// Node One database server of the cluster
+type Node struct {
+	// Name primary or replica-N, used in logs
+	Name string
+	Pool *pgxpool.Pool
+
+	healthy atomic.Bool
+	lag     atomic.Int64
+}
+
+// Healthy reports whether node passed last check and isn't lagging
+func (n *Node) Healthy() bool {
+	return n.healthy.Load()
+}
+
+// Lag returns replication lag measured by last check
+func (n *Node) Lag() time.Duration {
+	return time.Duration(n.lag.Load())
+}
+
+// Reader returns node for read only work, healthy replica when there is one,
+// primary otherwise
+func (p *Postgres) Reader() *Node {
+	var picked *Node
+
+	switch p.balancer {
+	case LeastConns:
+		var least int32
+		for _, n := range p.Replicas {
+			if !n.Healthy() {
+				continue
+			}
+			if c := n.Pool.Stat().AcquiredConns(); picked == nil || c < least {
+				picked, least = n, c
+			}
+		}
+	default:
+		healthy := make([]*Node, 0, len(p.Replicas))
+		for _, n := range p.Replicas {
+			if n.Healthy() {
+				healthy = append(healthy, n)
+			}
+		}
+		if len(healthy) > 0 {
+			picked = healthy[atomic.AddUint64(&p.next, 1)%uint64(len(healthy))]
+		}
+	}
+
+	if picked == nil {
+		return p.Primary
+	}
+	return picked
+}
+
+// monitor checks replicas every check interval until Close
+func (p *Postgres) monitor() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.stop = cancel
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		t := time.NewTicker(p.checkInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				p.checkReplicas(ctx)
+			}
+		}
+	}()
+}
+
+func (p *Postgres) checkReplicas(ctx context.Context) {
+	for _, n := range p.Replicas {
+		p.checkReplica(ctx, n)
+	}
+}
+
+// checkReplica marks replica unhealthy when it's unreachable or lags more than allowed
+func (p *Postgres) checkReplica(ctx context.Context, n *Node) {
+	ctx, cancel := context.WithTimeout(ctx, p.connTimeout)
+	defer cancel()
+
+	var lag *float64
+	if err := n.Pool.QueryRow(ctx, _lagSQL).Scan(&lag); err != nil {
+		if n.healthy.Swap(false) {
+			p.l.Warn(
+				"replica unhealthy, reads fall back to primary",
+				zap.String("node", n.Name),
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	var d time.Duration
+	if lag != nil {
+		d = time.Duration(*lag * float64(time.Second))
+	}
+	n.lag.Store(int64(d))
+
+	ok := p.maxReplicaLag <= 0 || d <= p.maxReplicaLag
+	if was := n.healthy.Swap(ok); was != ok {
+		if ok {
+			p.l.Info("replica healthy", zap.String("node", n.Name))
+		} else {
+			p.l.Warn(
+				"replica lags, reads fall back to primary",
+				zap.String("node", n.Name),
+				zap.Duration("lag", d),
+			)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestWithReplicas(t *testing.T) {
+	p := &Postgres{}
+	WithReplicas("postgres://r1/db", "", "postgres://r2/db")(p)
+	WithBalancer(LeastConns)(p)
+	WithMaxReplicaLag(time.Minute)(p)
+	WithCheckInterval(time.Second)(p)
+
+	if len(p.replicaDSNs) != 2 {
+		t.Errorf("replicaDSNs = %v, want 2 non empty DSNs", p.replicaDSNs)
+	}
+	if p.balancer != LeastConns || p.maxReplicaLag != time.Minute {
+		t.Errorf("balancer, maxReplicaLag = %v, %v", p.balancer, p.maxReplicaLag)
+	}
+	if p.checkInterval != time.Second {
+		t.Errorf("checkInterval = %v, want 1s", p.checkInterval)
+	}
+}
+
+func TestParseBalancer(t *testing.T) {
+	tests := map[string]Balancer{
+		"":            RoundRobin,
+		"round_robin": RoundRobin,
+		"least_conns": LeastConns,
+	}
+	for in, want := range tests {
+		if got, err := ParseBalancer(in); err != nil || got != want {
+			t.Errorf("ParseBalancer(%q) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseBalancer("random"); err == nil {
+		t.Error("ParseBalancer() should reject unknown balancer")
+	}
+}
+
+// lazyPool creates pool that never dials, pgxpool connects on first use
+func lazyPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://localhost:1/db?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func newCluster(t *testing.T, healthy ...bool) *Postgres {
+	p := &Postgres{l: _testLogger, Primary: &Node{Name: "primary"}}
+	for i, ok := range healthy {
+		n := &Node{Name: "replica-" + string(rune('1'+i)), Pool: lazyPool(t)}
+		n.healthy.Store(ok)
+		p.Replicas = append(p.Replicas, n)
+	}
+	return p
+}
+
+func TestReader_RoundRobin(t *testing.T) {
+	p := newCluster(t, true, false, true)
+
+	seen := map[string]int{}
+	for range 4 {
+		seen[p.Reader().Name]++
+	}
+	if seen["replica-1"] != 2 || seen["replica-3"] != 2 {
+		t.Errorf("Reader() spread = %v, want healthy replicas twice each", seen)
+	}
+}
+
+func TestReader_LeastConns(t *testing.T) {
+	p := newCluster(t, false, true)
+	p.balancer = LeastConns
+
+	if n := p.Reader(); n.Name != "replica-2" {
+		t.Errorf("Reader() = %s, want only healthy replica-2", n.Name)
+	}
+}
+
+func TestReader_FallbackToPrimary(t *testing.T) {
+	for _, b := range []Balancer{RoundRobin, LeastConns} {
+		p := newCluster(t, false, false)
+		p.balancer = b
+		if n := p.Reader(); n != p.Primary {
+			t.Errorf("Reader() with balancer %d = %s, want primary", b, n.Name)
+		}
+	}
+
+	p := &Postgres{Primary: &Node{Name: "primary"}}
+	if n := p.Reader(); n != p.Primary {
+		t.Errorf("Reader() without replicas = %s, want primary", n.Name)
+	}
+}
+
+func TestCheckReplica_Unreachable(t *testing.T) {
+	p := newCluster(t, true)
+	p.connTimeout = 200 * time.Millisecond
+
+	p.checkReplica(context.Background(), p.Replicas[0])
+	if p.Replicas[0].Healthy() {
+		t.Error("unreachable replica should be marked unhealthy")
+	}
+}
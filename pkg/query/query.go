@@ -0,0 +1,252 @@
+// Package query builds parameterized SQL conditions for Postgres. Values are always sent as
+// $n arguments, column names are validated and quoted.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var _ident = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Ident quotes possibly qualified identifier, e.g. users.email becomes "users"."email".
+// It panics on names that aren't plain identifiers, they come from code, never from clients.
+func Ident(name string) string {
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		if !_ident.MatchString(p) {
+			panic(fmt.Sprintf("query: invalid identifier %q", name))
+		}
+		parts[i] = `"` + p + `"`
+	}
+	return strings.Join(parts, ".")
+}
+
+// Builder Collects SQL text and arguments, placeholders continue from previous arguments
+type Builder struct {
+	sb     strings.Builder
+	args   []any
+	offset int
+}
+
+// NewBuilder creates builder whose first placeholder is $start
+func NewBuilder(start int) *Builder {
+	return &Builder{offset: max(start-1, 0)}
+}
+
+// Write appends raw SQL
+func (b *Builder) Write(sql string) *Builder {
+	b.sb.WriteString(sql)
+	return b
+}
+
+// Arg appends placeholder for value
+func (b *Builder) Arg(v any) *Builder {
+	b.args = append(b.args, v)
+	b.sb.WriteString("$" + strconv.Itoa(b.offset+len(b.args)))
+	return b
+}
+
+// Next returns number of next placeholder
+func (b *Builder) Next() int {
+	return b.offset + len(b.args) + 1
+}
+
+// Cond appends condition
+func (b *Builder) Cond(c Cond) *Builder {
+	c.build(b)
+	return b
+}
+
+// String returns SQL built so far
+func (b *Builder) String() string {
+	return b.sb.String()
+}
+
+// Args returns arguments in placeholder order
+func (b *Builder) Args() []any {
+	return b.args
+}
+
+// Cond SQL boolean expression
+type Cond interface {
+	build(b *Builder)
+}
+
+// Where returns "WHERE ..." clause and args for conditions joined with AND,
+// empty string when there are none. Placeholders start at $1.
+func Where(conds ...Cond) (string, []any) {
+	c := And(conds...)
+	if c == nil {
+		return "", nil
+	}
+
+	b := NewBuilder(1)
+	b.Write("WHERE ").Cond(c)
+	return b.String(), b.Args()
+}
+
+type compare struct {
+	column string
+	op     string
+	value  any
+}
+
+func (c compare) build(b *Builder) {
+	b.Write(Ident(c.column) + " " + c.op + " ").Arg(c.value)
+}
+
+// Eq column = value
+func Eq(column string, value any) Cond { return compare{column, "=", value} }
+
+// Ne column <> value
+func Ne(column string, value any) Cond { return compare{column, "<>", value} }
+
+// Gt column > value
+func Gt(column string, value any) Cond { return compare{column, ">", value} }
+
+// Gte column >= value
+func Gte(column string, value any) Cond { return compare{column, ">=", value} }
+
+// Lt column < value
+func Lt(column string, value any) Cond { return compare{column, "<", value} }
+
+// Lte column <= value
+func Lte(column string, value any) Cond { return compare{column, "<=", value} }
+
+// Like column LIKE pattern
+func Like(column, pattern string) Cond { return compare{column, "LIKE", pattern} }
+
+// ILike column ILIKE pattern, case insensitive
+func ILike(column, pattern string) Cond { return compare{column, "ILIKE", pattern} }
+
+type in struct {
+	column string
+	values []any
+}
+
+func (c in) build(b *Builder) {
+	if len(c.values) == 0 {
+		// empty IN list matches nothing
+		b.Write("FALSE")
+		return
+	}
+
+	b.Write(Ident(c.column) + " IN (")
+	for i, v := range c.values {
+		if i > 0 {
+			b.Write(", ")
+		}
+		b.Arg(v)
+	}
+	b.Write(")")
+}
+
+// In column IN (values...), matches nothing when values are empty
+func In[T any](column string, values ...T) Cond {
+	c := in{column: column, values: make([]any, len(values))}
+	for i, v := range values {
+		c.values[i] = v
+	}
+	return c
+}
+
+type null struct {
+	column string
+	not    bool
+}
+
+func (c null) build(b *Builder) {
+	if c.not {
+		b.Write(Ident(c.column) + " IS NOT NULL")
+		return
+	}
+	b.Write(Ident(c.column) + " IS NULL")
+}
+
+// IsNull column IS NULL
+func IsNull(column string) Cond { return null{column: column} }
+
+// NotNull column IS NOT NULL
+func NotNull(column string) Cond { return null{column: column, not: true} }
+
+type group struct {
+	op    string
+	conds []Cond
+}
+
+func (g group) build(b *Builder) {
+	b.Write("(")
+	for i, c := range g.conds {
+		if i > 0 {
+			b.Write(" " + g.op + " ")
+		}
+		c.build(b)
+	}
+	b.Write(")")
+}
+
+func newGroup(op string, conds []Cond) Cond {
+	var kept []Cond
+	for _, c := range conds {
+		if c != nil {
+			kept = append(kept, c)
+		}
+	}
+
+	switch len(kept) {
+	case 0:
+		return nil
+	case 1:
+		return kept[0]
+	}
+	return group{op: op, conds: kept}
+}
+
+// And joins conditions with AND, nil conditions are skipped so filters can be optional
+func And(conds ...Cond) Cond { return newGroup("AND", conds) }
+
+// Or joins conditions with OR, nil conditions are skipped
+func Or(conds ...Cond) Cond { return newGroup("OR", conds) }
+
+type not struct {
+	cond Cond
+}
+
+func (n not) build(b *Builder) {
+	b.Write("NOT (")
+	n.cond.build(b)
+	b.Write(")")
+}
+
+// Not negates condition
+func Not(c Cond) Cond {
+	if c == nil {
+		return nil
+	}
+	return not{cond: c}
+}
+
+type expr struct {
+	fn func(next int) (string, []any)
+}
+
+func (e expr) build(b *Builder) {
+	sql, args := e.fn(b.Next())
+	b.Write(sql)
+	b.args = append(b.args, args...)
+}
+
+// Expr wraps condition rendered by other code, fn receives number of its first placeholder,
+// e.g. pagination.Params.Where. Empty SQL renders TRUE.
+func Expr(fn func(next int) (string, []any)) Cond {
+	return expr{fn: func(next int) (string, []any) {
+		sql, args := fn(next)
+		if sql == "" {
+			return "TRUE", nil
+		}
+		return sql, args
+	}}
+}
@@ -0,0 +1,97 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIdent(t *testing.T) {
+	if got := Ident("users.email"); got != `"users"."email"` {
+		t.Errorf("Ident() = %v", got)
+	}
+
+	for _, bad := range []string{"", "email; DROP TABLE users", `a"b`, "users.", "1col"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Ident(%q) should panic", bad)
+				}
+			}()
+			Ident(bad)
+		}()
+	}
+}
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		name     string
+		conds    []Cond
+		wantSQL  string
+		wantArgs []any
+	}{
+		{"empty", nil, "", nil},
+		{"nil skipped", []Cond{nil, nil}, "", nil},
+		{"single", []Cond{Eq("email", "a@example.com")}, `WHERE "email" = $1`,
+			[]any{"a@example.com"}},
+		{
+			"and or",
+			[]Cond{
+				Gte("age", 18),
+				Or(ILike("name", "%ana%"), IsNull("deleted_at")),
+				Not(In("role", "admin", "owner")),
+			},
+			`WHERE ("age" >= $1 AND ("name" ILIKE $2 OR "deleted_at" IS NULL) ` +
+				`AND NOT ("role" IN ($3, $4)))`,
+			[]any{18, "%ana%", "admin", "owner"},
+		},
+		{"empty in", []Cond{In[int]("id")}, "WHERE FALSE", nil},
+		{"not nil", []Cond{NotNull("verified_at"), Not(nil)}, `WHERE "verified_at" IS NOT NULL`,
+			nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := Where(tt.conds...)
+			if sql != tt.wantSQL {
+				t.Errorf("Where() sql = %q, want %q", sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Where() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuilder_Offset(t *testing.T) {
+	b := NewBuilder(3)
+	b.Write("SET name = ").Arg("x").Write(" WHERE ").Cond(Lt("id", 10))
+
+	if b.String() != `SET name = $3 WHERE "id" < $4` {
+		t.Errorf("String() = %q", b.String())
+	}
+	if b.Next() != 5 || len(b.Args()) != 2 {
+		t.Errorf("Next(), Args() = %d, %v", b.Next(), b.Args())
+	}
+}
+
+func TestExpr(t *testing.T) {
+	keyset := func(next int) (string, []any) {
+		if next != 2 {
+			t.Errorf("Expr got first placeholder %d, want 2", next)
+		}
+		return "(created_at, id) < ($2, $3)", []any{"t", 7}
+	}
+
+	sql, args := Where(Eq("tenant", 1), Expr(keyset))
+	if sql != `WHERE ("tenant" = $1 AND (created_at, id) < ($2, $3))` {
+		t.Errorf("Where() sql = %q", sql)
+	}
+	if !reflect.DeepEqual(args, []any{1, "t", 7}) {
+		t.Errorf("Where() args = %v", args)
+	}
+
+	empty := Expr(func(int) (string, []any) { return "", nil })
+	if sql, _ := Where(empty); sql != "WHERE TRUE" {
+		t.Errorf("Where() empty expr = %q", sql)
+	}
+}
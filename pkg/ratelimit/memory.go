@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const _sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// MemoryStore Keeps buckets in process memory, counters are not shared between instances
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// MemoryOption Provides function to memory store options
+type MemoryOption func(*MemoryStore)
+
+// WithClock overrides time source, used by tests
+func WithClock(now func() time.Time) MemoryOption {
+	return func(s *MemoryStore) { s.now = now }
+}
+
+// NewMemoryStore creates empty in-memory store
+func NewMemoryStore(opts ...MemoryOption) *MemoryStore {
+	s := &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.lastSweep = s.now()
+
+	return s
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = refill(b.tokens, now.Sub(b.updated), limit)
+	b.updated = now
+	b.limit = limit
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return newResult(b.tokens, allowed, limit), nil
+}
+
+// Len returns number of tracked buckets
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.buckets)
+}
+
+// sweep drops buckets that refilled completely, they are equal to a new bucket
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < _sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if refill(b.tokens, now.Sub(b.updated), b.limit) >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const _defaultTable = "rate_limit_buckets"
+
+// refill expression of stored bucket b, $2 rate and $3 burst,
+// database clock is used so instances with skewed clocks agree
+const _refillExpr = `LEAST($3::float8, b.tokens + ` +
+	`GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at)::float8, 0) * $2::float8)`
+
+const _takeQuery = `
+INSERT INTO %[1]s AS b (key, tokens, allowed, updated_at)
+VALUES ($1, GREATEST($3::float8 - 1, 0), $3::float8 >= 1, now())
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE WHEN {refill} >= 1 THEN {refill} - 1 ELSE {refill} END,
+	allowed = {refill} >= 1,
+	updated_at = now()
+RETURNING tokens, allowed`
+
+// PostgresStore Keeps buckets in postgres so every instance shares the same counters,
+// each Take is one atomic upsert
+type PostgresStore struct {
+	pool *pgxpool.Pool
+	// table is quoted identifier
+	table string
+	take  string
+}
+
+// PostgresOption Provides function to postgres store options
+type PostgresOption func(*PostgresStore)
+
+// WithTable overrides default rate_limit_buckets table
+func WithTable(name string) PostgresOption {
+	return func(s *PostgresStore) { s.table = name }
+}
+
+// NewPostgresStore creates store, table is created by migrations
+func NewPostgresStore(pool *pgxpool.Pool, opts ...PostgresOption) *PostgresStore {
+	s := &PostgresStore{pool: pool, table: _defaultTable}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.table = pgx.Identifier{s.table}.Sanitize()
+	s.take = fmt.Sprintf(strings.ReplaceAll(_takeQuery, "{refill}", _refillExpr), s.table)
+
+	return s
+}
+
+// Take implements Store
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := s.pool.QueryRow(ctx, s.take, key, limit.Rate, float64(limit.Burst)).
+		Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: take: %w", err)
+	}
+
+	return newResult(tokens, allowed, limit), nil
+}
+
+// Purge deletes buckets untouched for idle, they would be full and equal to a new bucket
+func (s *PostgresStore) Purge(ctx context.Context, idle time.Duration) (int64, error) {
+	tag, err := s.pool.Exec(
+		ctx,
+		fmt.Sprintf(
+			`DELETE FROM %s WHERE updated_at < now() - make_interval(secs => $1::float8)`,
+			s.table,
+		),
+		idle.Seconds(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("ratelimit: purge: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestNewPostgresStore_Query(t *testing.T) {
+	s := NewPostgresStore(nil, WithTable("custom_buckets"))
+
+	if !strings.Contains(s.take, `INSERT INTO "custom_buckets" AS b`) {
+		t.Errorf("take query should use custom table:\n%s", s.take)
+	}
+	if strings.Contains(s.take, "{refill}") {
+		t.Errorf("take query has unexpanded placeholder:\n%s", s.take)
+	}
+}
+
+func TestNewPostgresStore_QuotesTable(t *testing.T) {
+	s := NewPostgresStore(nil, WithTable(`buckets"; DROP TABLE users; --`))
+
+	want := `INSERT INTO "buckets""; DROP TABLE users; --" AS b`
+	if !strings.Contains(s.take, want) {
+		t.Errorf("take query should quote table:\n%s", s.take)
+	}
+}
+
+// TestPostgresStore runs against real database when TEST_DATABASE_URL is set
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx := context.Background()
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// temp tables are per connection
+	cfg.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	table := "rate_limit_buckets_test"
+	_, err = pool.Exec(ctx, `CREATE TEMP TABLE `+table+` (
+		key TEXT PRIMARY KEY,
+		tokens DOUBLE PRECISION NOT NULL,
+		allowed BOOLEAN NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewPostgresStore(pool, WithTable(table))
+	limit := Limit{Rate: 0.001, Burst: 2}
+
+	for _, want := range []bool{true, true, false} {
+		res, err := s.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if res.Allowed != want {
+			t.Fatalf("Take() allowed = %v, want %v", res.Allowed, want)
+		}
+	}
+
+	if _, err := s.Purge(ctx, time.Hour); err != nil {
+		t.Errorf("Purge() error = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Package ratelimit provides token bucket rate limiting with pluggable bucket storage
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit Token bucket parameters, bucket holds up to Burst tokens refilled at Rate per second
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Window returns time to refill empty bucket, advertised as policy window
+func (l Limit) Window() time.Duration {
+	if l.Rate <= 0 {
+		return 0
+	}
+	return seconds(float64(l.Burst) / l.Rate)
+}
+
+// Result Outcome of single Take
+type Result struct {
+	Allowed bool
+	// Limit bucket capacity
+	Limit     int
+	Remaining int
+	// Reset time until bucket is full again
+	Reset time.Duration
+	// RetryAfter time until next token, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store Keeps buckets, Take must refill and consume atomically
+type Store interface {
+	// Take refills bucket for key and consumes one token if available
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// newResult builds result from tokens left in bucket after Take
+func newResult(tokens float64, allowed bool, l Limit) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	if l.Rate <= 0 {
+		return res
+	}
+
+	res.Reset = seconds((float64(l.Burst) - tokens) / l.Rate)
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+
+	return res
+}
+
+// refill returns tokens in bucket after elapsed time, capped at burst
+func refill(tokens float64, elapsed time.Duration, l Limit) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
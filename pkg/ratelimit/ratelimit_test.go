@@ -0,0 +1,99 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestLimit_Window(t *testing.T) {
+	if got := (Limit{Rate: 2, Burst: 4}).Window(); got != 2*time.Second {
+		t.Errorf("Window() = %v, want 2s", got)
+	}
+	if got := (Limit{Burst: 4}).Window(); got != 0 {
+		t.Errorf("Window() with zero rate = %v, want 0", got)
+	}
+}
+
+func TestMemoryStore_Burst(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s := NewMemoryStore(WithClock(clock.now))
+	limit := Limit{Rate: 1, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		res, err := s.Take(context.Background(), "k", limit)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("Take() = %+v, want allowed with %d remaining", res, i)
+		}
+	}
+
+	res, _ := s.Take(context.Background(), "k", limit)
+	if res.Allowed {
+		t.Fatal("Take() beyond burst should be denied")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Errorf("Reset = %v, want 3s", res.Reset)
+	}
+	if res.Limit != 3 {
+		t.Errorf("Limit = %v, want 3", res.Limit)
+	}
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s := NewMemoryStore(WithClock(clock.now))
+	limit := Limit{Rate: 2, Burst: 2}
+
+	for range 2 {
+		_, _ = s.Take(context.Background(), "k", limit)
+	}
+	if res, _ := s.Take(context.Background(), "k", limit); res.Allowed {
+		t.Fatal("bucket should be empty")
+	}
+
+	clock.advance(500 * time.Millisecond)
+	if res, _ := s.Take(context.Background(), "k", limit); !res.Allowed {
+		t.Error("one token should refill after 500ms at 2 rps")
+	}
+
+	clock.advance(time.Hour)
+	res, _ := s.Take(context.Background(), "k", limit)
+	if res.Remaining != 1 {
+		t.Errorf("Remaining = %d, refill should be capped at burst", res.Remaining)
+	}
+}
+
+func TestMemoryStore_KeysIsolated(t *testing.T) {
+	s := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, _ = s.Take(context.Background(), "a", limit)
+	if res, _ := s.Take(context.Background(), "b", limit); !res.Allowed {
+		t.Error("buckets of different keys should be independent")
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	s := NewMemoryStore(WithClock(clock.now))
+	limit := Limit{Rate: 1, Burst: 1}
+
+	_, _ = s.Take(context.Background(), "old", limit)
+	clock.advance(2 * _sweepInterval)
+	_, _ = s.Take(context.Background(), "new", limit)
+
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, full buckets should be swept", s.Len())
+	}
+}
@@ -0,0 +1,164 @@
+// Package retry runs operations again with exponential backoff and full jitter
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	_defaultInitial    = 100 * time.Millisecond
+	_defaultMax        = 10 * time.Second
+	_defaultMultiplier = 2
+	_defaultMaxElapsed = time.Minute
+)
+
+// Backoff Retry policy, safe for concurrent use
+type Backoff struct {
+	initial     time.Duration
+	max         time.Duration
+	multiplier  float64
+	maxElapsed  time.Duration
+	maxAttempts int
+	retryIf     func(error) bool
+	onRetry     func(attempt int, delay time.Duration, err error)
+
+	// sleep waits for d or until ctx is done, replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Option Configures Backoff
+type Option func(*Backoff)
+
+// WithInitial sets delay cap of first retry, default 100ms
+func WithInitial(d time.Duration) Option { return func(b *Backoff) { b.initial = d } }
+
+// WithMax sets delay cap no retry exceeds, default 10s
+func WithMax(d time.Duration) Option { return func(b *Backoff) { b.max = d } }
+
+// WithMultiplier sets how fast delay cap grows, default 2
+func WithMultiplier(m float64) Option { return func(b *Backoff) { b.multiplier = m } }
+
+// WithMaxElapsed stops retrying when next attempt would start after d since first one,
+// zero disables the limit. Default is one minute.
+func WithMaxElapsed(d time.Duration) Option { return func(b *Backoff) { b.maxElapsed = d } }
+
+// WithMaxAttempts limits attempts including the first one, zero means no limit
+func WithMaxAttempts(n int) Option { return func(b *Backoff) { b.maxAttempts = n } }
+
+// WithRetryIf retries only errors fn accepts, by default every error but Permanent ones
+func WithRetryIf(fn func(error) bool) Option { return func(b *Backoff) { b.retryIf = fn } }
+
+// WithOnRetry calls fn before waiting for retry, e.g. to log failed attempt
+func WithOnRetry(fn func(attempt int, delay time.Duration, err error)) Option {
+	return func(b *Backoff) { b.onRetry = fn }
+}
+
+// New creates retry policy
+func New(opts ...Option) *Backoff {
+	b := &Backoff{
+		initial:    _defaultInitial,
+		max:        _defaultMax,
+		multiplier: _defaultMultiplier,
+		maxElapsed: _defaultMaxElapsed,
+		sleep:      sleep,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Do runs fn until it succeeds, returns Permanent or unretryable error, attempts or elapsed
+// time run out, or ctx is done. Returned error is the last one from fn, joined with ctx error
+// when cancelled while waiting.
+func Do(ctx context.Context, fn func(ctx context.Context) error, opts ...Option) error {
+	return New(opts...).Do(ctx, fn)
+}
+
+// Do runs fn with b's policy, see package level Do
+func (b *Backoff) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	start := time.Now()
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var p *permanentError
+		if errors.As(err, &p) {
+			return p.err
+		}
+		if b.retryIf != nil && !b.retryIf(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return errors.Join(err, ctx.Err())
+		}
+		if b.maxAttempts > 0 && attempt >= b.maxAttempts {
+			return err
+		}
+
+		delay := b.Delay(attempt)
+		if b.maxElapsed > 0 && time.Since(start)+delay > b.maxElapsed {
+			return err
+		}
+		if b.onRetry != nil {
+			b.onRetry(attempt, delay, err)
+		}
+		if serr := b.sleep(ctx, delay); serr != nil {
+			return errors.Join(err, serr)
+		}
+	}
+}
+
+// Delay returns random wait before retry following attempt, between zero and
+// min(max, initial * multiplier^(attempt-1))
+func (b *Backoff) Delay(attempt int) time.Duration {
+	limit := float64(b.initial)
+	for i := 1; i < attempt && limit < float64(b.max); i++ {
+		limit *= b.multiplier
+	}
+	limit = min(limit, float64(b.max))
+	if limit < 1 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(limit) + 1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string { return p.err.Error() }
+
+func (p *permanentError) Unwrap() error { return p.err }
+
+// Permanent marks err as not worth retrying, Do returns err itself
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,185 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// noSleep records requested delays without waiting
+func noSleep(delays *[]time.Duration) Option {
+	return func(b *Backoff) {
+		b.sleep = func(ctx context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return ctx.Err()
+		}
+	}
+}
+
+func TestDo_SucceedsAfterRetries(t *testing.T) {
+	var delays []time.Duration
+	calls := 0
+	err := Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	}, noSleep(&delays))
+
+	if err != nil || calls != 3 || len(delays) != 2 {
+		t.Errorf("Do() = %v after %d calls and %d waits, want nil after 3 and 2", err, calls,
+			len(delays))
+	}
+}
+
+func TestDo_Stops(t *testing.T) {
+	errBoom := errors.New("boom")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		fn        func(calls int) error
+		wantCalls int
+		wantErr   error
+	}{
+		{
+			"max attempts",
+			[]Option{WithMaxAttempts(3)},
+			func(int) error { return errBoom },
+			3,
+			errBoom,
+		},
+		{
+			"permanent",
+			nil,
+			func(calls int) error {
+				if calls == 2 {
+					return Permanent(errFatal)
+				}
+				return errBoom
+			},
+			2,
+			errFatal,
+		},
+		{
+			"retry if",
+			[]Option{WithRetryIf(func(err error) bool { return !errors.Is(err, errFatal) })},
+			func(int) error { return errFatal },
+			1,
+			errFatal,
+		},
+		{
+			"max elapsed",
+			[]Option{WithInitial(time.Hour), WithMax(time.Hour), WithMaxElapsed(time.Second)},
+			func(int) error { return errBoom },
+			// delay can be drawn under a second, so allow few attempts
+			-1,
+			errBoom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			calls := 0
+			opts := append([]Option{noSleep(&delays)}, tt.opts...)
+			err := Do(context.Background(), func(context.Context) error {
+				calls++
+				return tt.fn(calls)
+			}, opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantCalls > 0 && calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantCalls < 0 && calls > 10 {
+				t.Errorf("calls = %d, max elapsed didn't stop retries", calls)
+			}
+		})
+	}
+}
+
+func TestDo_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errBoom := errors.New("boom")
+
+	calls := 0
+	err := Do(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return errBoom
+	}, WithInitial(time.Hour))
+
+	if !errors.Is(err, errBoom) || !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("Do() = %v after %d calls, want boom and context.Canceled after 1", err, calls)
+	}
+}
+
+func TestDo_ContextCancelledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := Do(ctx, func(context.Context) error {
+		return errors.New("boom")
+	}, WithInitial(time.Hour), WithMax(time.Hour), WithMaxElapsed(0), WithMultiplier(1))
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Do() waited %v after cancellation", time.Since(start))
+	}
+}
+
+func TestBackoff_Delay(t *testing.T) {
+	b := New(WithInitial(10*time.Millisecond), WithMax(50*time.Millisecond))
+
+	for attempt, limit := range []time.Duration{10, 20, 40, 50, 50} {
+		limit *= time.Millisecond
+		for range 20 {
+			if d := b.Delay(attempt + 1); d < 0 || d > limit {
+				t.Fatalf("Delay(%d) = %v, want in [0, %v]", attempt+1, d, limit)
+			}
+		}
+	}
+	if d := b.Delay(1000); d < 0 || d > 50*time.Millisecond {
+		t.Errorf("Delay() of large attempt = %v", d)
+	}
+}
+
+func TestOnRetry(t *testing.T) {
+	var delays []time.Duration
+	var attempts []int
+	_ = Do(context.Background(), func(context.Context) error {
+		return errors.New("boom")
+	}, noSleep(&delays), WithMaxAttempts(3), WithOnRetry(func(n int, _ time.Duration, _ error) {
+		attempts = append(attempts, n)
+	}))
+
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("OnRetry attempts = %v, want [1 2]", attempts)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+
+	errBoom := errors.New("boom")
+	err := fmt.Errorf("job: %w", Permanent(errBoom))
+	if !IsPermanent(err) || !errors.Is(err, errBoom) {
+		t.Errorf("IsPermanent(), errors.Is() of wrapped permanent = %v, %v", IsPermanent(err),
+			errors.Is(err, errBoom))
+	}
+	if IsPermanent(errBoom) {
+		t.Error("IsPermanent() of plain error should be false")
+	}
+}
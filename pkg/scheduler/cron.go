@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule Returns next run after t, zero time when there is none
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type every time.Duration
+
+// Every runs task every d, counted from end of previous run
+func Every(d time.Duration) Schedule { return every(d) }
+
+func (e every) Next(t time.Time) time.Time {
+	if e <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(e))
+}
+
+// cron Five field schedule, bit n of each field is set when value n matches
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	// domStar, dowStar unrestricted day fields, restricted ones match when either does
+	domStar, dowStar bool
+}
+
+// _maxSearch bounds Next for schedules that never match, e.g. 30 February
+const _maxSearch = 5 * 366 * 24 * time.Hour
+
+var _descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	_months = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	_days = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// Parse parses cron expression "minute hour day-of-month month day-of-week" with *, lists,
+// ranges, steps and month or day names, descriptor like @daily or interval "@every 5m".
+// Times are matched in location of time passed to Next.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("scheduler: invalid interval in %q", spec)
+		}
+		return Every(interval), nil
+	}
+	if expr, ok := _descriptors[spec]; ok {
+		spec = expr
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("scheduler: %q must have 5 fields", spec)
+	}
+
+	var (
+		c   cron
+		err error
+	)
+	parsers := []struct {
+		dst      *uint64
+		min, max int
+		names    map[string]int
+	}{
+		{&c.minute, 0, 59, nil},
+		{&c.hour, 0, 23, nil},
+		{&c.dom, 1, 31, nil},
+		{&c.month, 1, 12, _months},
+		// 7 is sunday too
+		{&c.dow, 0, 7, _days},
+	}
+	for i, p := range parsers {
+		if *p.dst, err = parseField(fields[i], p.min, p.max, p.names); err != nil {
+			return nil, fmt.Errorf("scheduler: %q: %w", spec, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+
+	return &c, nil
+}
+
+// MustParse is like Parse but panics on invalid spec, for specs known at compile time
+func MustParse(spec string) Schedule {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField parses comma separated list of *, n, a-b, */s, a-b/s or n/s
+func parseField(field string, lo, hi int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		expr, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		start, end := lo, hi
+		switch from, to, isRange := strings.Cut(expr, "-"); {
+		case expr == "*":
+		case isRange:
+			var err error
+			if start, err = parseValue(from, lo, hi, names); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(to, lo, hi, names); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q", expr)
+			}
+		default:
+			v, err := parseValue(expr, lo, hi, names)
+			if err != nil {
+				return 0, err
+			}
+			start = v
+			// n/s means from n to the end
+			if !hasStep {
+				end = v
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func parseValue(s string, lo, hi int, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < lo || v > hi {
+		return 0, fmt.Errorf("value %q out of range %d-%d", s, lo, hi)
+	}
+	return v, nil
+}
+
+// Next implements Schedule
+func (c *cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(_maxSearch)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
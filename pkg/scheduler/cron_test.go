@@ -0,0 +1,94 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Next(t *testing.T) {
+	// 2025-01-15 is Wednesday
+	from := time.Date(2025, 1, 15, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2025, 1, 15, 11, 5, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2025, 1, 15, 13, 0, 0, 0, time.UTC)},
+		{"30 8 * * mon-fri", time.Date(2025, 1, 16, 8, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 feb,jun *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// restricted day of month and week match when either does
+		{"0 0 20 * fri", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"10/20 * * * *", time.Date(2025, 1, 15, 10, 50, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.spec, err)
+			continue
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Parse(%q).Next() = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Location(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	s := MustParse("0 3 * * *")
+
+	got := s.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 1, 15, 3, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next() in UTC = %v, want %v", got, want)
+	}
+	got = s.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC).In(loc))
+	// 03:00 local is 01:00 UTC
+	if want := time.Date(2025, 1, 15, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next() in UTC+2 = %v, want %v", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every",
+		"@every -1m",
+		"@every soon",
+	}
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+}
+
+func TestNext_NeverMatches(t *testing.T) {
+	if got := MustParse("0 0 30 2 *").Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() of 30 February = %v, want zero", got)
+	}
+	if got := Every(0).Next(time.Now()); !got.IsZero() {
+		t.Errorf("Every(0).Next() = %v, want zero", got)
+	}
+}
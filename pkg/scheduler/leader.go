@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lomifile/api/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	// _defaultLockID arbitrary key for pg_advisory_lock held by the leader
+	_defaultLockID        = 7_424_118_290
+	_defaultCheckInterval = 10 * time.Second
+	_checkTimeout         = 5 * time.Second
+)
+
+// Leader Reports whether this instance should run scheduled tasks
+type Leader interface {
+	IsLeader() bool
+}
+
+// LeaderOption Provides function to postgres leader options
+type LeaderOption func(*PostgresLeader)
+
+// WithLockID overrides advisory lock key, instances sharing it elect one leader
+func WithLockID(id int64) LeaderOption { return func(p *PostgresLeader) { p.lockID = id } }
+
+// WithCheckInterval sets how often leader verifies its session and followers try to take
+// over, default 10s
+func WithCheckInterval(d time.Duration) LeaderOption {
+	return func(p *PostgresLeader) { p.interval = d }
+}
+
+// PostgresLeader Elects leader with session advisory lock. Leader keeps one pool connection
+// holding the lock, when its session ends postgres releases the lock and next instance to
+// check takes over.
+type PostgresLeader struct {
+	pool     *pgxpool.Pool
+	l        *logger.Logger
+	lockID   int64
+	interval time.Duration
+	leader   atomic.Bool
+
+	// conn holds the lock, used only by loop
+	conn *pgxpool.Conn
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewPostgresLeader(pool *pgxpool.Pool, l *logger.Logger, opts ...LeaderOption) *PostgresLeader {
+	p := &PostgresLeader{
+		pool:     pool,
+		l:        l.Named("leader"),
+		lockID:   _defaultLockID,
+		interval: _defaultCheckInterval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// IsLeader implements Leader
+func (p *PostgresLeader) IsLeader() bool {
+	return p.leader.Load()
+}
+
+// Start campaigns for leadership in background until Close
+func (p *PostgresLeader) Start() {
+	go p.loop()
+}
+
+// Close stops campaigning and releases the lock so another instance can take over
+func (p *PostgresLeader) Close() {
+	close(p.stop)
+	<-p.done
+}
+
+func (p *PostgresLeader) loop() {
+	defer close(p.done)
+	defer p.resign()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), _checkTimeout)
+		p.check(ctx)
+		cancel()
+
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check verifies held lock's session is alive or tries to take the lock
+func (p *PostgresLeader) check(ctx context.Context) {
+	if p.conn != nil {
+		if err := p.conn.Ping(ctx); err != nil {
+			p.l.Warn("leadership lost", zap.Error(err))
+			p.drop()
+		}
+		return
+	}
+
+	acquired, err := p.tryLock(ctx)
+	if err != nil {
+		p.l.Warn("leader election failed", zap.Error(err))
+		return
+	}
+	if acquired {
+		p.l.Info("became leader")
+	}
+}
+
+func (p *PostgresLeader) tryLock(ctx context.Context) (bool, error) {
+	conn, err := p.pool.Acquire(ctx)
+	if err != nil {
+		return false, fmt.Errorf("acquire: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", p.lockID).
+		Scan(&acquired); err != nil {
+		conn.Release()
+		return false, fmt.Errorf("advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return false, nil
+	}
+
+	p.conn = conn
+	p.leader.Store(true)
+	return true, nil
+}
+
+// drop closes lock's connection, server ends the session and frees the lock even if its
+// state is unknown
+func (p *PostgresLeader) drop() {
+	p.leader.Store(false)
+	ctx, cancel := context.WithTimeout(context.Background(), _checkTimeout)
+	defer cancel()
+	_ = p.conn.Conn().Close(ctx)
+	p.conn.Release()
+	p.conn = nil
+}
+
+func (p *PostgresLeader) resign() {
+	if p.conn == nil {
+		return
+	}
+	p.leader.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), _checkTimeout)
+	defer cancel()
+	if _, err := p.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", p.lockID); err != nil {
+		p.l.Warn("advisory unlock failed", zap.Error(err))
+		p.drop()
+		return
+	}
+	p.conn.Release()
+	p.conn = nil
+	p.l.Info("resigned leadership")
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lomifile/api/pkg/logger"
+)
+
+// TestPostgresLeader runs against real database when TEST_DATABASE_URL is set
+func TestPostgresLeader(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	pool, err := pgxpool.New(context.Background(), dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	l := logger.New(logger.Config{})
+	lockID := WithLockID(int64(os.Getpid()))
+	interval := WithCheckInterval(10 * time.Millisecond)
+	first := NewPostgresLeader(pool, l, lockID, interval)
+	second := NewPostgresLeader(pool, l, lockID, interval)
+
+	first.Start()
+	waitLeader(t, first)
+	second.Start()
+	defer second.Close()
+
+	time.Sleep(50 * time.Millisecond)
+	if second.IsLeader() {
+		t.Fatal("second instance became leader while first holds the lock")
+	}
+
+	first.Close()
+	if first.IsLeader() {
+		t.Error("closed leader still reports leadership")
+	}
+	waitLeader(t, second)
+}
+
+func waitLeader(t *testing.T, p *PostgresLeader) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !p.IsLeader() {
+		if time.Now().After(deadline) {
+			t.Fatal("instance didn't become leader")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
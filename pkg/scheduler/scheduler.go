@@ -0,0 +1,161 @@
+// Package scheduler runs recurring tasks on cron schedules or intervals. With Leader only
+// the elected instance runs them, so replicas don't repeat work.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lomifile/api/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const _defaultTimeout = time.Minute
+
+// Task Recurring work, ctx expires after task timeout or when Stop runs out of time
+type Task func(ctx context.Context) error
+
+// Option Provides function to scheduler options
+type Option func(*Scheduler)
+
+// WithLeader runs tasks only while l reports leadership, default runs them on every instance
+func WithLeader(l Leader) Option { return func(s *Scheduler) { s.leader = l } }
+
+// TaskOption Provides function to task options
+type TaskOption func(*task)
+
+// WithTimeout limits single run of task, default 1m
+func WithTimeout(d time.Duration) TaskOption { return func(t *task) { t.timeout = d } }
+
+type task struct {
+	name     string
+	schedule Schedule
+	fn       Task
+	timeout  time.Duration
+}
+
+// Scheduler Runs tasks at their scheduled times, runs of one task never overlap
+type Scheduler struct {
+	l      *logger.Logger
+	leader Leader
+	tasks  []*task
+
+	// ctx is task parent, cancelled when Stop runs out of time
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func New(l *logger.Logger, opts ...Option) *Scheduler {
+	s := &Scheduler{l: l.Named("scheduler"), stop: make(chan struct{})}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add registers task under unique name, call before Start
+func (s *Scheduler) Add(name string, schedule Schedule, fn Task, opts ...TaskOption) error {
+	for _, t := range s.tasks {
+		if t.name == name {
+			return fmt.Errorf("scheduler: task %q already added", name)
+		}
+	}
+	if now := time.Now(); !schedule.Next(now).After(now) {
+		return fmt.Errorf("scheduler: task %q never runs", name)
+	}
+
+	t := &task{name: name, schedule: schedule, fn: fn, timeout: _defaultTimeout}
+	for _, opt := range opts {
+		opt(t)
+	}
+	s.tasks = append(s.tasks, t)
+
+	return nil
+}
+
+// Start runs every task in background until Stop
+func (s *Scheduler) Start() {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.loop(t)
+	}
+}
+
+// Stop stops scheduling and waits for running tasks. When ctx is done first, running tasks
+// are cancelled.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-finished
+		return fmt.Errorf("scheduler: stop: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) loop(t *task) {
+	defer s.wg.Done()
+
+	for {
+		next := t.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.l.Warn("task has no next run", zap.String("task", t.name))
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if s.leader != nil && !s.leader.IsLeader() {
+			continue
+		}
+		s.run(t)
+	}
+}
+
+func (s *Scheduler) run(t *task) {
+	l := s.l.With(zap.String("task", t.name))
+	start := time.Now()
+
+	err := s.call(l, t)
+	if err != nil {
+		l.Error("task failed", zap.Duration("duration", time.Since(start)), zap.Error(err))
+		return
+	}
+	l.Info("task finished", zap.Duration("duration", time.Since(start)))
+}
+
+// call runs t with its timeout and l in context, panics are returned as errors
+func (s *Scheduler) call(l *logger.Logger, t *task) (err error) {
+	ctx, cancel := context.WithTimeout(logger.NewContext(s.ctx, l), t.timeout)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return t.fn(ctx)
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lomifile/api/pkg/logger"
+)
+
+type fakeLeader struct{ leader atomic.Bool }
+
+func (f *fakeLeader) IsLeader() bool { return f.leader.Load() }
+
+func newTestScheduler(opts ...Option) *Scheduler {
+	return New(logger.New(logger.Config{}), opts...)
+}
+
+func waitCount(t *testing.T, n *atomic.Int32, want int32) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for n.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("ran %d times, want at least %d", n.Load(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduler_RunsTasks(t *testing.T) {
+	s := newTestScheduler()
+
+	var runs, failures atomic.Int32
+	_ = s.Add("count", Every(time.Millisecond), func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	// failing and panicking tasks keep their schedule
+	_ = s.Add("fail", Every(time.Millisecond), func(context.Context) error {
+		if failures.Add(1)%2 == 0 {
+			panic("boom")
+		}
+		return errors.New("boom")
+	})
+
+	s.Start()
+	waitCount(t, &runs, 3)
+	waitCount(t, &failures, 3)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() error = %v", err)
+	}
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := newTestScheduler()
+	noop := func(context.Context) error { return nil }
+
+	if err := s.Add("a", MustParse("@hourly"), noop); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add("a", Every(time.Minute), noop); err == nil {
+		t.Error("Add() with duplicate name should fail")
+	}
+	if err := s.Add("b", MustParse("0 0 30 2 *"), noop); err == nil {
+		t.Error("Add() with schedule that never runs should fail")
+	}
+	if err := s.Add("c", Every(0), noop); err == nil {
+		t.Error("Add() with zero interval should fail")
+	}
+}
+
+func TestScheduler_FollowerSkipsRuns(t *testing.T) {
+	leader := &fakeLeader{}
+	s := newTestScheduler(WithLeader(leader))
+
+	var runs atomic.Int32
+	_ = s.Add("count", Every(time.Millisecond), func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	s.Start()
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Fatalf("follower ran task %d times", runs.Load())
+	}
+	leader.leader.Store(true)
+	waitCount(t, &runs, 1)
+}
+
+func TestScheduler_TaskTimeout(t *testing.T) {
+	s := newTestScheduler()
+
+	errs := make(chan error, 1)
+	_ = s.Add("slow", Every(time.Millisecond), func(ctx context.Context) error {
+		<-ctx.Done()
+		select {
+		case errs <- ctx.Err():
+		default:
+		}
+		return ctx.Err()
+	}, WithTimeout(5*time.Millisecond))
+
+	s.Start()
+	defer func() { _ = s.Stop(context.Background()) }()
+
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("task ctx error = %v, want deadline exceeded", err)
+	}
+}
+
+func TestScheduler_StopTimeoutCancelsTasks(t *testing.T) {
+	s := newTestScheduler()
+
+	started := make(chan struct{})
+	var once atomic.Bool
+	_ = s.Add("stuck", Every(time.Millisecond), func(ctx context.Context) error {
+		if once.CompareAndSwap(false, true) {
+			close(started)
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithTimeout(time.Hour))
+
+	s.Start()
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want deadline exceeded", err)
+	}
+}
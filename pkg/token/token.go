@@ -0,0 +1,233 @@
+// Package token provides JWT access and refresh token issuing and verification
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lomifile/api/pkg/utils"
+)
+
+const (
+	_defaultIssuer     = "api"
+	_defaultAccessTTL  = 15 * time.Minute
+	_defaultRefreshTTL = 7 * 24 * time.Hour
+	_minSecretLength   = 32
+)
+
+var (
+	// ErrMissingKey returned when manager has no signing key configured
+	ErrMissingKey = errors.New("token: signing key is not configured")
+	// ErrWeakSecret returned when HMAC secret is shorter than 32 bytes
+	ErrWeakSecret = errors.New("token: secret key must be at least 32 bytes")
+	// ErrInvalidToken returned when token can't be parsed or verified
+	ErrInvalidToken = errors.New("token: invalid token")
+	// ErrWrongType returned when access token is used as refresh token or vice versa
+	ErrWrongType = errors.New("token: wrong token type")
+)
+
+// ClaimsKey Key under which HTTP middleware stores verified *Claims
+const ClaimsKey = "claims"
+
+// Type Token type stored in typ claim
+type Type string
+
+const (
+	// Access short lived token used on every request
+	Access Type = "access"
+	// Refresh long lived token used to obtain new pair
+	Refresh Type = "refresh"
+)
+
+// Claims JWT claims issued by Manager
+type Claims struct {
+	Type Type `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// Pair Access and refresh token pair
+type Pair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// Manager Issues and verifies tokens
+type Manager struct {
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+
+	issuer     string
+	audience   []string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+// Option Provides function to manager options
+type Option func(*Manager)
+
+// WithIssuer sets iss claim and verifies it on parse
+func WithIssuer(iss string) Option { return func(m *Manager) { m.issuer = iss } }
+
+// WithAudience sets aud claim and verifies it on parse
+func WithAudience(aud ...string) Option { return func(m *Manager) { m.audience = aud } }
+
+// WithAccessTTL sets access token lifetime
+func WithAccessTTL(d time.Duration) Option { return func(m *Manager) { m.accessTTL = d } }
+
+// WithRefreshTTL sets refresh token lifetime
+func WithRefreshTTL(d time.Duration) Option { return func(m *Manager) { m.refreshTTL = d } }
+
+// WithRSAKey signs tokens with RS256 instead of HS256
+func WithRSAKey(key *rsa.PrivateKey) Option {
+	return func(m *Manager) {
+		m.method = jwt.SigningMethodRS256
+		m.signKey = key
+		m.verifyKey = &key.PublicKey
+	}
+}
+
+// WithEdDSAKey signs tokens with EdDSA instead of HS256
+func WithEdDSAKey(key ed25519.PrivateKey) Option {
+	return func(m *Manager) {
+		m.method = jwt.SigningMethodEdDSA
+		m.signKey = key
+		m.verifyKey = key.Public()
+	}
+}
+
+// WithClock overrides time source, used in tests
+func WithClock(now func() time.Time) Option { return func(m *Manager) { m.now = now } }
+
+// New creates new token manager. Secret is used for HS256 unless asymmetric key option is passed
+func New(secret string, opts ...Option) (*Manager, error) {
+	m := &Manager{
+		issuer:     _defaultIssuer,
+		accessTTL:  _defaultAccessTTL,
+		refreshTTL: _defaultRefreshTTL,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.method == nil {
+		if secret == "" {
+			return nil, ErrMissingKey
+		}
+		if len(secret) < _minSecretLength {
+			return nil, ErrWeakSecret
+		}
+		m.method = jwt.SigningMethodHS256
+		m.signKey = []byte(secret)
+		m.verifyKey = []byte(secret)
+	}
+
+	if m.signKey == nil {
+		return nil, ErrMissingKey
+	}
+
+	return m, nil
+}
+
+// Issue signs single token of given type for subject
+func (m *Manager) Issue(subject string, typ Type) (string, time.Time, error) {
+	ttl := m.accessTTL
+	if typ == Refresh {
+		ttl = m.refreshTTL
+	}
+
+	jti, err := utils.RandToken(16)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token: jti: %w", err)
+	}
+
+	now := m.now()
+	exp := now.Add(ttl)
+	claims := Claims{
+		Type: typ,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   subject,
+			Audience:  m.audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			ID:        jti,
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("token: sign: %w", err)
+	}
+
+	return signed, exp, nil
+}
+
+// IssuePair signs access and refresh tokens for subject
+func (m *Manager) IssuePair(subject string) (*Pair, error) {
+	access, accessExp, err := m.Issue(subject, Access)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, refreshExp, err := m.Issue(subject, Refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pair{
+		AccessToken:      access,
+		RefreshToken:     refresh,
+		AccessExpiresAt:  accessExp,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+// Verify parses token and checks signature, expiry, issuer, audience and type
+func (m *Manager) Verify(raw string, typ Type) (*Claims, error) {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(m.now),
+	}
+	if m.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(m.issuer))
+	}
+	if len(m.audience) > 0 {
+		parserOpts = append(parserOpts, jwt.WithAudience(m.audience...))
+	}
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(*jwt.Token) (any, error) {
+		return m.verifyKey, nil
+	}, parserOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if claims.Type != typ {
+		return nil, ErrWrongType
+	}
+
+	return claims, nil
+}
+
+// Refresh verifies refresh token and issues new pair for the same subject
+func (m *Manager) Refresh(refreshToken string) (*Pair, error) {
+	claims, err := m.Verify(refreshToken, Refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.IssuePair(claims.Subject)
+}
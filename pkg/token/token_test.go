@@ -0,0 +1,210 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestNew_MissingSecret(t *testing.T) {
+	_, err := New("")
+	if !errors.Is(err, ErrMissingKey) {
+		t.Errorf("New(\"\") error = %v, want %v", err, ErrMissingKey)
+	}
+}
+
+func TestNew_WeakSecret(t *testing.T) {
+	_, err := New("short")
+	if !errors.Is(err, ErrWeakSecret) {
+		t.Errorf("New(short) error = %v, want %v", err, ErrWeakSecret)
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	m, err := New(testSecret)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if m.issuer != _defaultIssuer {
+		t.Errorf("issuer = %v, want %v", m.issuer, _defaultIssuer)
+	}
+	if m.accessTTL != _defaultAccessTTL {
+		t.Errorf("accessTTL = %v, want %v", m.accessTTL, _defaultAccessTTL)
+	}
+	if m.refreshTTL != _defaultRefreshTTL {
+		t.Errorf("refreshTTL = %v, want %v", m.refreshTTL, _defaultRefreshTTL)
+	}
+	if m.method.Alg() != "HS256" {
+		t.Errorf("method = %v, want HS256", m.method.Alg())
+	}
+}
+
+func TestIssuePair_Verify(t *testing.T) {
+	m, err := New(testSecret, WithIssuer("test"), WithAudience("web"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	pair, err := m.IssuePair("user-1")
+	if err != nil {
+		t.Fatalf("IssuePair() error = %v", err)
+	}
+
+	claims, err := m.Verify(pair.AccessToken, Access)
+	if err != nil {
+		t.Fatalf("Verify(access) error = %v", err)
+	}
+	if claims.Subject != "user-1" {
+		t.Errorf("Subject = %v, want user-1", claims.Subject)
+	}
+	if claims.Issuer != "test" {
+		t.Errorf("Issuer = %v, want test", claims.Issuer)
+	}
+	if claims.ID == "" {
+		t.Error("ID should not be empty")
+	}
+
+	if _, err := m.Verify(pair.RefreshToken, Refresh); err != nil {
+		t.Errorf("Verify(refresh) error = %v", err)
+	}
+	if !pair.RefreshExpiresAt.After(pair.AccessExpiresAt) {
+		t.Error("refresh token should outlive access token")
+	}
+}
+
+func TestVerify_WrongType(t *testing.T) {
+	m, _ := New(testSecret)
+	pair, _ := m.IssuePair("user-1")
+
+	_, err := m.Verify(pair.RefreshToken, Access)
+	if !errors.Is(err, ErrWrongType) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrWrongType)
+	}
+}
+
+func TestVerify_Expired(t *testing.T) {
+	now := time.Now()
+	m, _ := New(testSecret, WithClock(func() time.Time { return now }))
+	raw, _, err := m.Issue("user-1", Access)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	now = now.Add(_defaultAccessTTL + time.Minute)
+	_, err = m.Verify(raw, Access)
+	if !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerify_WrongIssuerAndAudience(t *testing.T) {
+	issuer, _ := New(testSecret, WithIssuer("other"), WithAudience("mobile"))
+	raw, _, _ := issuer.Issue("user-1", Access)
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"issuer", []Option{WithIssuer("test"), WithAudience("mobile")}},
+		{"audience", []Option{WithIssuer("other"), WithAudience("web")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := New(testSecret, tt.opts...)
+			if _, err := m.Verify(raw, Access); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
+
+func TestVerify_WrongSecret(t *testing.T) {
+	m, _ := New(testSecret)
+	raw, _, _ := m.Issue("user-1", Access)
+
+	other, _ := New("abcdef0123456789abcdef0123456789")
+	if _, err := other.Verify(raw, Access); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestVerify_Garbage(t *testing.T) {
+	m, _ := New(testSecret)
+	if _, err := m.Verify("not-a-jwt", Access); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	m, _ := New(testSecret)
+	pair, _ := m.IssuePair("user-1")
+
+	next, err := m.Refresh(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	claims, err := m.Verify(next.AccessToken, Access)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if claims.Subject != "user-1" {
+		t.Errorf("Subject = %v, want user-1", claims.Subject)
+	}
+
+	if _, err := m.Refresh(pair.AccessToken); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Refresh(access) error = %v, want %v", err, ErrWrongType)
+	}
+}
+
+func TestAsymmetricKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		opt  Option
+		alg  string
+	}{
+		{"RS256", WithRSAKey(rsaKey), "RS256"},
+		{"EdDSA", WithEdDSAKey(edKey), "EdDSA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New("", tt.opt)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if m.method.Alg() != tt.alg {
+				t.Errorf("method = %v, want %v", m.method.Alg(), tt.alg)
+			}
+
+			raw, _, err := m.Issue("user-1", Access)
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+			if _, err := m.Verify(raw, Access); err != nil {
+				t.Errorf("Verify() error = %v", err)
+			}
+
+			hs, _ := New(testSecret)
+			if _, err := hs.Verify(raw, Access); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("HS256 Verify() error = %v, want %v", err, ErrInvalidToken)
+			}
+		})
+	}
+}
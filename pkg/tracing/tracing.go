@@ -0,0 +1,133 @@
+// Package tracing provides OpenTelemetry tracer provider, propagation and log correlation
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Exporter Where finished spans are sent
+type Exporter string
+
+const (
+	// ExporterNone spans are created for log correlation but not exported
+	ExporterNone Exporter = "none"
+	// ExporterStdout spans are printed as JSON, useful locally
+	ExporterStdout Exporter = "stdout"
+	// ExporterOTLP spans are sent to OTLP/HTTP collector
+	ExporterOTLP Exporter = "otlp"
+
+	_instrumentation = "github.com/lomifile/api"
+)
+
+// Config Tracing settings
+type Config struct {
+	ServiceName string
+	Environment string
+	Exporter    Exporter
+	// Endpoint OTLP/HTTP collector host:port, empty uses OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string
+	Insecure bool
+	// SampleRatio fraction of new traces sampled, parent decision is always honoured
+	SampleRatio float64
+
+	// Writer stdout exporter destination, defaults to os.Stdout
+	Writer io.Writer
+}
+
+// Provider Owns tracer provider and its exporter
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// New creates tracer provider and installs it with W3C propagator as otel globals
+func New(ctx context.Context, cfg Config) (*Provider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("deployment.environment", cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: resource: %w", err)
+	}
+	opts = append(opts, sdktrace.WithResource(res))
+
+	switch cfg.Exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		w := cfg.Writer
+		if w == nil {
+			w = os.Stdout
+		}
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, fmt.Errorf("tracing: stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithSyncer(exp))
+	case ExporterOTLP:
+		expOpts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			expOpts = append(expOpts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			expOpts = append(expOpts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, expOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return &Provider{tp: tp}, nil
+}
+
+// Shutdown flushes pending spans and stops exporter
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil || p.tp == nil {
+		return nil
+	}
+	return p.tp.Shutdown(ctx)
+}
+
+// Tracer returns tracer of this module from global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(_instrumentation)
+}
+
+// Fields returns trace_id and span_id zap fields for span in ctx
+func Fields(ctx context.Context) []zap.Field {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String("trace_id", sc.TraceID().String()),
+		zap.String("span_id", sc.SpanID().String()),
+	}
+}
@@ -0,0 +1,103 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_UnknownExporter(t *testing.T) {
+	if _, err := New(context.Background(), Config{Exporter: "kafka"}); err == nil {
+		t.Error("New() with unknown exporter should return error")
+	}
+}
+
+func TestNew_StdoutExporter(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := New(context.Background(), Config{
+		ServiceName: "api-test",
+		Exporter:    ExporterStdout,
+		SampleRatio: 1,
+		Writer:      &buf,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	_, span := Tracer().Start(context.Background(), "unit")
+	span.End()
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"Name":"unit"`) {
+		t.Errorf("exported spans = %s, want span named unit", buf.String())
+	}
+	if !strings.Contains(buf.String(), "api-test") {
+		t.Error("exported span should carry service.name resource")
+	}
+}
+
+func TestNew_ZeroRatioHonoursParent(t *testing.T) {
+	p, err := New(context.Background(), Config{SampleRatio: 0})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer p.Shutdown(context.Background())
+
+	_, root := Tracer().Start(context.Background(), "root")
+	if root.SpanContext().IsSampled() {
+		t.Error("root span should not be sampled with ratio 0")
+	}
+	root.End()
+
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	_, child := Tracer().Start(ctx, "child")
+	if !child.SpanContext().IsSampled() {
+		t.Error("child of sampled parent should be sampled")
+	}
+	child.End()
+}
+
+func TestFields(t *testing.T) {
+	if got := Fields(context.Background()); got != nil {
+		t.Errorf("Fields() without span = %v, want nil", got)
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0xab},
+		SpanID:  trace.SpanID{0xcd},
+	})
+	fields := Fields(trace.ContextWithSpanContext(context.Background(), sc))
+	if len(fields) != 2 {
+		t.Fatalf("len(Fields()) = %d, want 2", len(fields))
+	}
+	if fields[0].Key != "trace_id" || fields[0].String != sc.TraceID().String() {
+		t.Errorf("trace_id field = %+v", fields[0])
+	}
+	if fields[1].Key != "span_id" || fields[1].String != sc.SpanID().String() {
+		t.Errorf("span_id field = %+v", fields[1])
+	}
+}
+
+func TestOperation(t *testing.T) {
+	tests := map[string]string{
+		"select 1":                   "SELECT",
+		"  INSERT INTO users VALUES": "INSERT",
+		"":                           "query",
+	}
+	for sql, want := range tests {
+		if got := operation(sql); got != want {
+			t.Errorf("operation(%q) = %v, want %v", sql, got, want)
+		}
+	}
+}
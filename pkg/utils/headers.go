@@ -18,10 +18,10 @@ func ExtractJwtTokenFromHeader(c *fiber.Ctx) (string, error) {
 		return "", errors.New("headers doesn't contain token")
 	}
 
-	fullToken := authorization[0]
-
-	split := strings.Split(fullToken, " ")
-	token := split[1]
+	scheme, token, found := strings.Cut(authorization[0], " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", errors.New("authorization header is not a bearer token")
+	}
 
 	return token, nil
 }
@@ -31,6 +31,18 @@ func TestExtractJwtTokenFromHeader(t *testing.T) {
 			wantToken:  "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
 			wantErr:    false,
 		},
+		{
+			name:       "bearer without token",
+			authHeader: "Bearer",
+			wantToken:  "",
+			wantErr:    true,
+		},
+		{
+			name:       "basic scheme",
+			authHeader: "Basic dXNlcjpwYXNz",
+			wantToken:  "",
+			wantErr:    true,
+		},
 		{
 			name:       "missing authorization header",
 			authHeader: "",
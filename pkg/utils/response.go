@@ -40,6 +40,8 @@ type SuccessResponseMap[T any] struct {
 }
 
 // ErrorResponseMap Base error response
+//
+// Deprecated: errors are rendered as RFC 9457 problem details, see handler.Problem
 type ErrorResponseMap struct {
 	RequestID string `json:"request_id"`
 	Status    int    `json:"status"`
@@ -53,6 +55,9 @@ type PaginationResponseMeta struct {
 	Prev        *int `json:"previous"`
 	HasNextPage bool `json:"has_next_page"`
 	HasPrevPage bool `json:"has_previous_page"`
+	// NextCursor and PrevCursor are set by keyset pagination instead of Next and Prev
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"previous_cursor,omitempty"`
 }
 
 // PaginationResponse Base pagination response